@@ -11,6 +11,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -20,6 +21,8 @@ import (
 
 	"yourproject/internal/auth"
 	"yourproject/internal/crawler"
+	"yourproject/internal/dag"
+	"yourproject/internal/eth"
 	"yourproject/internal/handlers"
 	"yourproject/internal/ipfsdb"
 )
@@ -150,39 +153,51 @@ func main() {
 	e.Use(middleware.Logger())
 	e.Use(middleware.CORS())
 
-	// Initialize mocked IPFS DB
-	db := ipfsdb.New()
-
 	// Ensure storage directories exist
 	artifactsDir := "storage/artifacts"
 	manifestsDir := "storage/manifests"
 	_ = os.MkdirAll(artifactsDir, 0o755)
 	_ = os.MkdirAll(manifestsDir, 0o755)
 
-	// Handlers for node/artifact workflow
-	h := handlers.NewHandlers(db, artifactsDir, manifestsDir)
+	// Open the file-backed IPFS DB (users, artworks, crawler results, manifests)
+	dbPath := os.Getenv("STORAGE_DB_PATH")
+	if dbPath == "" {
+		dbPath = "storage/ipfsdb.bolt"
+	}
+	db, err := ipfsdb.Open(dbPath)
+	if err != nil {
+		log.Fatalf("❌ Failed to open storage: %v", err)
+	}
+	log.Printf("💾 Storage: %s (%d records)", dbPath, len(db.ListKeys()))
+	if err := auth.PromoteAdminsFromEnv(db); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
 
 	// Log configuration status
 	log.Println("🚀 Starting Proof-of-Art API Server")
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-	rpcURL := os.Getenv("RPC_URL")
-	if rpcURL != "" {
-		// Mask sensitive parts of RPC URL
-		maskedURL := rpcURL
-		if len(maskedURL) > 50 {
-			maskedURL = maskedURL[:30] + "..." + maskedURL[len(maskedURL)-10:]
-		}
-		log.Printf("✅ RPC URL: %s", maskedURL)
+	if chainsConfig := os.Getenv("CHAINS_CONFIG"); chainsConfig != "" {
+		log.Printf("✅ Chains config: %s", chainsConfig)
 	} else {
-		log.Println("⚠️  RPC_URL not set - Ethereum features disabled")
-	}
+		rpcURL := os.Getenv("RPC_URL")
+		if rpcURL != "" {
+			// Mask sensitive parts of RPC URL
+			maskedURL := rpcURL
+			if len(maskedURL) > 50 {
+				maskedURL = maskedURL[:30] + "..." + maskedURL[len(maskedURL)-10:]
+			}
+			log.Printf("✅ RPC URL: %s", maskedURL)
+		} else {
+			log.Println("⚠️  RPC_URL not set - Ethereum features disabled")
+		}
 
-	contractAddr := os.Getenv("CONTRACT_ADDRESS")
-	if contractAddr != "" {
-		log.Printf("✅ Contract Address: %s", contractAddr)
-	} else {
-		log.Println("⚠️  CONTRACT_ADDRESS not set - deploy contract first and add to .env")
+		contractAddr := os.Getenv("CONTRACT_ADDRESS")
+		if contractAddr != "" {
+			log.Printf("✅ Contract Address: %s", contractAddr)
+		} else {
+			log.Println("⚠️  CONTRACT_ADDRESS not set - deploy contract first and add to .env")
+		}
 	}
 
 	pinataKey := os.Getenv("PINATA_API_KEY")
@@ -192,20 +207,70 @@ func main() {
 		log.Println("⚠️  PINATA_API_KEY not set - IPFS features disabled")
 	}
 
-	azureTenantID := os.Getenv("AZURE_TENANT_ID")
-	if azureTenantID != "" {
-		log.Printf("Azure Tenant ID: %s", azureTenantID)
-	} else {
-		log.Println("AZURE_TENANT_ID not set - using 'common' for multi-tenant")
+	// Identity providers (Azure AD and any OIDC issuer). Token signing keys
+	// are cached and refreshed in the background, not fetched per request.
+	providers, err := auth.ProvidersFromEnv()
+	if err != nil {
+		log.Fatalf("❌ Invalid identity provider configuration: %v", err)
 	}
+	for _, p := range providers {
+		log.Printf("🪪 Identity provider %s: %s (audiences: %s)", p.Name, p.Issuer, strings.Join(p.Audiences, ", "))
+		if len(p.AllowedTenants) > 0 {
+			log.Printf("   Allowed tenants: %s", strings.Join(p.AllowedTenants, ", "))
+		}
+	}
+	authn := auth.NewAuthenticator(context.Background(), providers, auth.JWKSRefreshFromEnv())
+	go authn.Discover(context.Background())
 
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
 	// Handlers for generate/import/certificate workflow
 	storage := ipfsdb.NewStorageService(db)
 	ipfsClient := ipfsdb.NewIPFSClient(db)
-	bcClient := ipfsdb.NewBlockchainClient(db)
-	api := handlers.NewHandler(storage, ipfsClient, bcClient)
+	// Chain registry: per-chain signer, contract bindings and event indexer
+	chains, err := eth.LoadChainRegistry(context.Background(), db)
+	if err != nil {
+		log.Printf("⚠️  Blockchain disabled: %v", err)
+		chains, _ = eth.NewChainRegistry("")
+	}
+	defer chains.Close()
+	for _, chain := range chains.Chains() {
+		log.Printf("⛓️  Chain %s (ID %s)", chain.Name, chain.ChainID)
+		if chain.TxManager != nil {
+			log.Printf("   ✅ Signer: %s", chain.TxManager.From().Hex())
+		}
+		if chain.Artworks != nil {
+			log.Printf("   ✅ ProofOfArt Contract: %s", chain.Artworks.ContractAddress().Hex())
+		}
+		if chain.Provenance != nil {
+			log.Printf("   ✅ ImageProvenance Contract: %s", chain.Provenance.ContractAddress().Hex())
+		}
+	}
+	if len(chains.Chains()) == 0 {
+		log.Println("⚠️  No chain configured - artworks and manifests will not be anchored on-chain")
+	} else if chains.Default() != "" {
+		log.Printf("⛓️  Default chain: %s", chains.Default())
+	}
+
+	bcClient := ipfsdb.NewBlockchainClient(db, chains)
+
+	// Per-user Ed25519 signing keys, sealed at rest
+	keys, err := auth.NewKeystore(db, auth.KeystoreKeyFromEnv())
+	if err != nil {
+		log.Fatalf("❌ Failed to open keystore: %v", err)
+	}
+	api := handlers.NewHandler(db, storage, ipfsClient, bcClient, keys)
+
+	// Nodes, artifacts and manifests are content-addressed IPLD blocks
+	dagCodec, dagHash := dag.ConfigFromEnv()
+	blocks, err := dag.New(db, dagCodec, dagHash)
+	if err != nil {
+		log.Fatalf("❌ Invalid DAG configuration: %v", err)
+	}
+	log.Printf("🧱 DAG blocks: %s, %s CIDv1", dagCodec, dagHash)
+
+	// Handlers for node/artifact workflow
+	h := handlers.NewHandlers(db, artifactsDir, manifestsDir, keys, blocks)
 
 	// Initialize crawler for reverse image search and similarity detection
 	similarityThreshold := 0.70 // Default 70% similarity threshold
@@ -228,6 +293,9 @@ func main() {
 	// Start crawler in background
 	crawlerCtx, crawlerCancel := context.WithCancel(context.Background())
 
+	// Track submitted transactions and follow contract events on every chain
+	go chains.Run(crawlerCtx)
+
 	go func() {
 		if err := crawlerInstance.Start(crawlerCtx); err != nil && err != context.Canceled {
 			log.Printf("⚠️  Crawler error: %v", err)
@@ -243,42 +311,91 @@ func main() {
 	e.POST("/model/predict", proxyToTorchServe)
 	e.POST("/model/predict/:model", proxyToTorchServe)
 
-	// Protected endpoints (require Microsoft authentication)
+	// Protected endpoints (require a token from a configured identity provider)
 	protected := e.Group("")
-	protected.Use(auth.JWTAuthMiddleware(db))
-
-	// Verification endpoints - now require authentication
-	protected.GET("/verify", h.Verify)
-	protected.GET("/verify/:id", api.VerifyArtwork)
-	protected.GET("/certificate/:id", api.GetCertificate)
-
-	// Core endpoints (node/artifact flow) - protected
-	protected.POST("/ext/push", h.ExtPush)
-	protected.POST("/node", h.CreateNode)
-	protected.POST("/artifact", h.UploadArtifact)
-	protected.POST("/finalize", h.FinalizeManifest)
-
-	// API endpoints (generation/import/certificates) - protected
-	protected.POST("/generate", api.GenerateArt)
-	protected.POST("/import", api.ImportArt)
-	protected.POST("/verify/upload", api.UploadForVerification)
-
-	// Manifest upload endpoint (Pinata + Ethereum) - PUBLIC for hackathon testing
-	e.POST("/upload", api.UploadManifest)
-	e.POST("/manifests", api.UploadManifest) // Alias for convenience
-
-	// Crawler notification endpoints - protected
-	protected.GET("/notifications", api.GetNotifications)
-	protected.GET("/notifications/artwork/:artworkId", api.GetNotificationsByArtwork)
-	protected.PUT("/notifications/:id/read", api.MarkNotificationAsRead)
-	protected.PUT("/notifications/:id/verify", api.MarkNotificationAsVerified)
-	protected.PUT("/notifications/:id/dismiss", api.DismissNotification)
-	protected.GET("/crawler/stats", api.GetCrawlerStats)
-	protected.POST("/crawler/scan/:artworkId", api.TriggerManualScan)
+	protected.Use(auth.JWTAuthMiddleware(db, keys, authn))
+
+	// Role groups: what each role may do is set in auth's permission table
+	verifiers := protected.Group("", auth.RequireRole(auth.RolesWith(auth.PermVerify)...))
+	reporters := protected.Group("", auth.RequireRole(auth.RolesWith(auth.PermReport)...))
+	artists := protected.Group("", auth.RequireRole(auth.RolesWith(auth.PermGenerate)...))
+	admins := protected.Group("/admin", auth.RequireRole(auth.RolesWith(auth.PermModerate)...))
+
+	// Verification endpoints - admirers, artists and admins
+	verifiers.GET("/verify", h.Verify)
+	verifiers.GET("/verify/:id", api.VerifyArtwork)
+	verifiers.GET("/certificate/:id", api.GetCertificate)
+	verifiers.GET("/registration/:id", api.GetRegistration)
+	verifiers.POST("/verify/upload", api.UploadForVerification)
+	verifiers.GET("/dag/:cid", h.GetDAG)
+	verifiers.GET("/dag/:cid/*", h.GetDAG)
+
+	// Reporting suspected copies - admirers, artists and admins
+	reporters.POST("/reports", api.ReportInfringement)
+
+	// Core endpoints (node/artifact flow) - artists and admins
+	artists.POST("/ext/push", h.ExtPush)
+	artists.POST("/node", h.CreateNode)
+	artists.POST("/artifact", h.UploadArtifact)
+	artists.POST("/finalize", h.FinalizeManifest)
+
+	// API endpoints (generation/import) - artists and admins
+	artists.POST("/generate", api.GenerateArt)
+	artists.POST("/import", api.ImportArt)
+
+	// Manifest upload (Pinata + Ethereum), anchored with the platform
+	// signer - artists and admins
+	artists.POST("/upload", api.UploadManifest)
+	artists.POST("/manifests", api.UploadManifest) // Alias for convenience
+
+	// Manifest proofs, transactions and indexed chain events - public reads
+	e.GET("/manifests/:cid/proof", api.GetManifestProof)
+	e.GET("/tx/:hash", api.GetTransaction)
+	e.GET("/chain/manifests", api.GetChainManifests)
+	e.GET("/chain/artworks", api.GetChainArtworks)
+
+	// Signing keys - listing, rotation and revocation are protected, key
+	// records are public so anyone can check a signature
+	e.GET("/keys/:kid", api.GetSigningKey)
+	protected.GET("/keys", api.GetSigningKeys)
+	protected.POST("/keys/rotate", api.RotateSigningKey)
+	protected.POST("/keys/:kid/revoke", api.RevokeSigningKey)
+
+	// Profile and wallet linking (Sign-In with Ethereum) - protected
+	protected.GET("/me", api.GetMe)
+	protected.PATCH("/me", api.UpdateMe)
+	protected.POST("/me/wallet/nonce", api.GetWalletNonce)
+	protected.POST("/me/wallet", api.LinkWallet)
+
+	// Crawler notification endpoints - artists (their own artworks) and admins
+	artists.GET("/notifications", api.GetNotifications)
+	artists.GET("/notifications/artwork/:artworkId", api.GetNotificationsByArtwork)
+	artists.PUT("/notifications/:id/read", api.MarkNotificationAsRead)
+	artists.PUT("/notifications/:id/verify", api.MarkNotificationAsVerified)
+	artists.PUT("/notifications/:id/dismiss", api.DismissNotification)
+	artists.GET("/crawler/stats", api.GetCrawlerStats)
+	artists.POST("/crawler/scan/:artworkId", api.TriggerManualScan)
+
+	// Admin endpoints - roles and every artist's crawler data
+	admins.GET("/users", api.ListUsers)
+	admins.PUT("/users/:id/role", api.SetUserRole)
+	admins.GET("/crawler/results", api.GetAllCrawlerResults)
 
 	addr := ":8080"
 	log.Printf("🌐 API listening on %s", addr)
 	log.Println("📝 POST /upload - Upload manifest to Pinata and store CID on Ethereum")
+	log.Println("🧾 GET  /tx/:hash - Transaction status, receipt and ManifestStored event")
+	log.Println("⛓️  GET  /chain/manifests?creator= - Indexed ManifestStored events")
+	log.Println("⛓️  GET  /chain/artworks?artist= - Indexed ArtworkRegistered events")
+	log.Println("🧱 GET  /dag/:cid[/path] - Resolve a DAG block and walk its links")
+	log.Println("🔑 GET  /keys/:kid - Public signing key record and JWK")
+	log.Println("👤 GET  /me, PATCH /me - Profile of the authenticated user")
+	log.Println("👛 POST /me/wallet/nonce, POST /me/wallet - Link a wallet with Sign-In with Ethereum")
+	log.Println("🚩 POST /reports - Report a suspected copy of an artwork")
+	log.Println("🛡️  Admin endpoints:")
+	log.Println("   GET  /admin/users - List users and their roles")
+	log.Println("   PUT  /admin/users/:id/role - Change a user's role")
+	log.Println("   GET  /admin/crawler/results - Crawler findings and reports for every artwork")
 	log.Println("🤖 POST /model/predict - Run model inference (proxies to TorchServe)")
 	log.Println("🕷️  Crawler endpoints:")
 	log.Println("   GET  /notifications - Get all infringement notifications")
@@ -315,5 +432,10 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// Flush and close storage
+	if err := db.Close(); err != nil {
+		log.Printf("⚠️  Failed to close storage: %v", err)
+	}
+
 	log.Println("✅ Server stopped")
 }
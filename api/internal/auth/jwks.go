@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+const (
+	// defaultJWKSRefresh is how often a key set is refetched when the
+	// server's Cache-Control does not ask for sooner
+	defaultJWKSRefresh = time.Hour
+	// unknownKidRefetch is the least time between refetches triggered by a
+	// token naming a kid the key set does not hold. Tokens are
+	// attacker-controlled, so these must not reach the provider unthrottled.
+	unknownKidRefetch = 30 * time.Second
+)
+
+// JWKSURLFromEnv returns AZURE_JWKS_URL, or the Microsoft key set for
+// AZURE_TENANT_ID when it is not set
+func JWKSURLFromEnv() string {
+	if url := os.Getenv("AZURE_JWKS_URL"); url != "" {
+		return url
+	}
+	return MicrosoftJWKSURL(os.Getenv("AZURE_TENANT_ID"))
+}
+
+// JWKSRefreshFromEnv returns JWKS_REFRESH_INTERVAL, defaulting to an hour
+func JWKSRefreshFromEnv() time.Duration {
+	if s := os.Getenv("JWKS_REFRESH_INTERVAL"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err == nil && d > 0 {
+			return d
+		}
+		log.Printf("⚠️  Invalid JWKS_REFRESH_INTERVAL %q, using %v", s, defaultJWKSRefresh)
+	}
+	return defaultJWKSRefresh
+}
+
+// KeySet is a JWKS kept in a jwk.Cache. It is fetched on first use,
+// refreshed in the background and refetched when a token names a kid it does
+// not hold, so provider key rotation is picked up without a round trip per
+// request. When a refresh fails the last fetched keys stay in use.
+type KeySet struct {
+	url   string
+	cache *jwk.Cache
+
+	mu          sync.Mutex
+	lastRefetch time.Time
+}
+
+// NewKeySet registers url with a cache refreshed in the background until
+// ctx is done. Nothing is fetched until the first token is checked.
+func NewKeySet(ctx context.Context, url string, refresh time.Duration) (*KeySet, error) {
+	cache := jwk.NewCache(ctx, jwk.WithErrSink(jwksErrSink{url: url}))
+	if err := cache.Register(url, jwk.WithMinRefreshInterval(refresh)); err != nil {
+		return nil, fmt.Errorf("failed to register JWKS %s: %w", url, err)
+	}
+	return &KeySet{url: url, cache: cache}, nil
+}
+
+// URL returns the JWKS URL the set is fetched from
+func (k *KeySet) URL() string { return k.url }
+
+// LookupKey returns the key with keyID, refetching the set once if it is
+// not there and the last refetch is long enough ago
+func (k *KeySet) LookupKey(ctx context.Context, keyID string) (jwk.Key, error) {
+	set, err := k.cache.Get(ctx, k.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	if key, ok := set.LookupKeyID(keyID); ok {
+		return key, nil
+	}
+
+	k.mu.Lock()
+	if time.Since(k.lastRefetch) < unknownKidRefetch {
+		k.mu.Unlock()
+		return nil, fmt.Errorf("unknown signing key %q", keyID)
+	}
+	k.lastRefetch = time.Now()
+	k.mu.Unlock()
+
+	log.Printf("🔑 Unknown kid %q, refetching JWKS %s", keyID, k.url)
+	if set, err = k.cache.Refresh(ctx, k.url); err != nil {
+		return nil, fmt.Errorf("failed to refetch JWKS: %w", err)
+	}
+	if key, ok := set.LookupKeyID(keyID); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", keyID)
+}
+
+// FetchKeys implements jws.KeyProvider: it offers the key the token's kid
+// names, for the algorithms that key supports
+func (k *KeySet) FetchKeys(ctx context.Context, sink jws.KeySink, sig *jws.Signature, _ *jws.Message) error {
+	keyID := sig.ProtectedHeaders().KeyID()
+	if keyID == "" {
+		return fmt.Errorf("token has no key ID (kid)")
+	}
+	key, err := k.LookupKey(ctx, keyID)
+	if err != nil {
+		return err
+	}
+
+	algs, err := jws.AlgorithmsForKey(key)
+	if err != nil {
+		return fmt.Errorf("unsupported signing key %q: %w", keyID, err)
+	}
+	alg := sig.ProtectedHeaders().Algorithm()
+	if declared := key.Algorithm().String(); declared != "" && declared != alg.String() {
+		return fmt.Errorf("algorithm %s does not match signing key %q", alg, keyID)
+	}
+	for _, candidate := range algs {
+		if candidate == alg {
+			sink.Key(alg, key)
+			return nil
+		}
+	}
+	return fmt.Errorf("algorithm %s does not match signing key %q", alg, keyID)
+}
+
+// jwksErrSink logs failed background refreshes
+type jwksErrSink struct {
+	url string
+}
+
+func (s jwksErrSink) Error(err error) {
+	log.Printf("⚠️  JWKS refresh of %s failed, keeping cached keys: %v", s.url, err)
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+)
+
+// jwksServer is a local JWKS endpoint whose keys and status a test can
+// change. It counts the requests it serves.
+type jwksServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	keys     []jwk.Key
+	status   int
+	requests int
+}
+
+func newJWKSServer(t *testing.T, keys ...jwk.Key) *jwksServer {
+	t.Helper()
+	s := &jwksServer{status: http.StatusOK}
+	s.setKeys(keys...)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.requests++
+		if s.status != http.StatusOK {
+			w.WriteHeader(s.status)
+			return
+		}
+		set := jwk.NewSet()
+		for _, key := range s.keys {
+			set.AddKey(key)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// setKeys replaces the served keys with the public halves of keys
+func (s *jwksServer) setKeys(keys ...jwk.Key) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = nil
+	for _, key := range keys {
+		public, err := key.PublicKey()
+		if err != nil {
+			panic(err)
+		}
+		s.keys = append(s.keys, public)
+	}
+}
+
+// fail makes the server answer every request with status
+func (s *jwksServer) fail(status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// count returns the number of requests served so far
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// newSigningKey returns an RS256 private key with kid
+func newSigningKey(t *testing.T, kid string) jwk.Key {
+	t.Helper()
+	raw, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.Set(jwk.KeyIDKey, kid)
+	key.Set(jwk.AlgorithmKey, jwa.RS256)
+	return key
+}
+
+// newTestKeySet returns a key set for the server's JWKS, refreshed in the
+// background every hour until the test ends
+func newTestKeySet(t *testing.T, server *jwksServer) *KeySet {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	keys, err := NewKeySet(ctx, server.URL, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+// allowRefetch makes the next unknown kid refetch the set, as if the last
+// refetch were unknownKidRefetch ago
+func (k *KeySet) allowRefetch() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.lastRefetch = time.Now().Add(-unknownKidRefetch)
+}
+
+func TestKeySetCachesKeys(t *testing.T) {
+	server := newJWKSServer(t, newSigningKey(t, "key-1"))
+	keys := newTestKeySet(t, server)
+
+	if server.count() != 0 {
+		t.Fatalf("JWKS fetched %d times before the first lookup", server.count())
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := keys.LookupKey(context.Background(), "key-1"); err != nil {
+			t.Fatalf("lookup %d: %v", i, err)
+		}
+	}
+	if server.count() != 1 {
+		t.Errorf("JWKS fetched %d times for 5 lookups of a cached kid, want 1", server.count())
+	}
+}
+
+func TestKeySetRefetchesUnknownKid(t *testing.T) {
+	key1, key2, key3 := newSigningKey(t, "key-1"), newSigningKey(t, "key-2"), newSigningKey(t, "key-3")
+	server := newJWKSServer(t, key1)
+	keys := newTestKeySet(t, server)
+	ctx := context.Background()
+
+	if _, err := keys.LookupKey(ctx, "key-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The provider rotates in key-2: the unknown kid triggers a refetch
+	server.setKeys(key1, key2)
+	if _, err := keys.LookupKey(ctx, "key-2"); err != nil {
+		t.Fatalf("rotated key not found: %v", err)
+	}
+	if server.count() != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", server.count())
+	}
+
+	// Within unknownKidRefetch of that refetch, unknown kids fail without
+	// reaching the provider
+	server.setKeys(key1, key2, key3)
+	for _, kid := range []string{"key-3", "forged"} {
+		if _, err := keys.LookupKey(ctx, kid); err == nil {
+			t.Errorf("%s found within the refetch throttle", kid)
+		}
+	}
+	if server.count() != 2 {
+		t.Errorf("JWKS fetched %d times within the refetch throttle, want 2", server.count())
+	}
+
+	// Once it has passed, the next unknown kid refetches again
+	keys.allowRefetch()
+	if _, err := keys.LookupKey(ctx, "key-3"); err != nil {
+		t.Fatalf("key-3 not found after the throttle: %v", err)
+	}
+	if server.count() != 3 {
+		t.Errorf("JWKS fetched %d times, want 3", server.count())
+	}
+}
+
+func TestKeySetKeepsKeysWhenRefreshFails(t *testing.T) {
+	server := newJWKSServer(t, newSigningKey(t, "key-1"))
+	keys := newTestKeySet(t, server)
+	ctx := context.Background()
+
+	if _, err := keys.LookupKey(ctx, "key-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	server.fail(http.StatusInternalServerError)
+	keys.allowRefetch()
+	if _, err := keys.LookupKey(ctx, "key-2"); err == nil {
+		t.Fatal("unknown kid found while the JWKS endpoint fails")
+	}
+	if server.count() != 2 {
+		t.Fatalf("JWKS fetched %d times, want a failed refetch", server.count())
+	}
+
+	if _, err := keys.LookupKey(ctx, "key-1"); err != nil {
+		t.Errorf("cached key lost after a failed refresh: %v", err)
+	}
+}
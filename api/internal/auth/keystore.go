@@ -0,0 +1,379 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeebo/blake3"
+
+	"yourproject/internal/crypto"
+	"yourproject/internal/ipfsdb"
+	"yourproject/internal/models"
+)
+
+// ErrNoSigningKey is returned when a user has no key in the keystore
+var ErrNoSigningKey = errors.New("user has no signing key")
+
+// KeystoreKeyFromEnv returns the keystore encryption key from KEYSTORE_KEY.
+// Without it a fixed development key is used, so keys at rest are only
+// obfuscated.
+func KeystoreKeyFromEnv() []byte {
+	if key := os.Getenv("KEYSTORE_KEY"); key != "" {
+		return []byte(key)
+	}
+	log.Println("⚠️  KEYSTORE_KEY not set - user signing keys are sealed with a development key")
+	return []byte("penguin-dev-keystore-key")
+}
+
+// Keystore holds every user's Ed25519 signing key, sealed with AES-256-GCM,
+// in the Store. Private keys never leave it; callers ask it to sign.
+type Keystore struct {
+	db   ipfsdb.Store
+	aead cipher.AEAD
+	mu   sync.Mutex
+}
+
+// NewKeystore creates a keystore sealing keys under key
+func NewKeystore(db ipfsdb.Store, key []byte) (*Keystore, error) {
+	derived := make([]byte, 32)
+	blake3.DeriveKey("penguin keystore user signing keys v1", key, derived)
+	block, err := aes.NewCipher(derived)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Keystore{db: db, aead: aead}, nil
+}
+
+// userKeyKey returns the store key holding a user's current signing key
+func userKeyKey(userID string) string {
+	return fmt.Sprintf("/keystore/%s", userID)
+}
+
+// signingKeyKey returns the store key holding the public record of a key
+func signingKeyKey(keyID string) string {
+	return fmt.Sprintf("/keystore/kid/%s", keyID)
+}
+
+// KeyIDFor returns the key ID (JWS kid) of a base64 public key
+func KeyIDFor(publicKey string) string {
+	pub, err := crypto.ParseEd25519PublicKey(publicKey)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(pub)
+}
+
+// get returns the current key of a user
+func (k *Keystore) get(userID string) (*models.UserKey, bool) {
+	val, ok := k.db.Get(userKeyKey(userID))
+	if !ok {
+		return nil, false
+	}
+	rec, ok := val.(*models.UserKey)
+	return rec, ok
+}
+
+// KeyRecord returns the public record of a signing key, current or not
+func (k *Keystore) KeyRecord(keyID string) (*models.SigningKeyRecord, bool) {
+	val, ok := k.db.Get(signingKeyKey(keyID))
+	if !ok {
+		return nil, false
+	}
+	rec, ok := val.(*models.SigningKeyRecord)
+	return rec, ok
+}
+
+// Keys returns every signing key userID has held, oldest first
+func (k *Keystore) Keys(userID string) []*models.SigningKeyRecord {
+	keys := []*models.SigningKeyRecord{}
+	for _, key := range k.db.ListKeys() {
+		if !strings.HasPrefix(key, signingKeyKey("")) {
+			continue
+		}
+		if rec, ok := k.KeyRecord(strings.TrimPrefix(key, signingKeyKey(""))); ok && rec.UserID == userID {
+			keys = append(keys, rec)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].CreatedAt.Before(keys[j].CreatedAt) })
+	return keys
+}
+
+// CheckKey returns the record of the key behind publicKey if it belongs to
+// userID and has not been revoked. Rotated keys still pass, so what they
+// signed before the rotation stays valid.
+func (k *Keystore) CheckKey(userID, publicKey string) (*models.SigningKeyRecord, error) {
+	rec, ok := k.KeyRecord(KeyIDFor(publicKey))
+	if !ok {
+		return nil, fmt.Errorf("signing key is not registered")
+	}
+	if rec.UserID != userID {
+		return rec, fmt.Errorf("signing key belongs to another user")
+	}
+	if rec.Status == models.KeyStatusRevoked {
+		if rec.RevocationReason != "" {
+			return rec, fmt.Errorf("signing key was revoked: %s", rec.RevocationReason)
+		}
+		return rec, fmt.Errorf("signing key was revoked")
+	}
+	return rec, nil
+}
+
+// newKeyLocked generates, seals and stores a new current key for userID,
+// together with its public record. Caller must hold k.mu.
+func (k *Keystore) newKeyLocked(userID string) (*models.UserKey, error) {
+	keyPair, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	seed := ed25519.PrivateKey(keyPair.PrivateKey).Seed()
+
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	now := time.Now()
+	rec := &models.UserKey{
+		UserID:        userID,
+		KeyID:         base64.RawURLEncoding.EncodeToString(keyPair.PublicKey),
+		PublicKey:     base64.StdEncoding.EncodeToString(keyPair.PublicKey),
+		SealedPrivate: base64.StdEncoding.EncodeToString(k.aead.Seal(nonce, nonce, seed, []byte(userID))),
+		CreatedAt:     now,
+	}
+
+	// The public record goes first so a saved current key always has one
+	if err := k.db.Save(signingKeyKey(rec.KeyID), &models.SigningKeyRecord{
+		KeyID:     rec.KeyID,
+		UserID:    userID,
+		PublicKey: rec.PublicKey,
+		Status:    models.KeyStatusActive,
+		CreatedAt: now,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to save signing key record: %w", err)
+	}
+	if err := k.db.Save(userKeyKey(userID), rec); err != nil {
+		return nil, fmt.Errorf("failed to save signing key: %w", err)
+	}
+	return rec, nil
+}
+
+// currentLocked returns the current key of userID, creating one when there
+// is none and registering the public record of keys created before key
+// records existed. Caller must hold k.mu.
+func (k *Keystore) currentLocked(userID string) (*models.UserKey, error) {
+	rec, ok := k.get(userID)
+	if !ok {
+		return k.newKeyLocked(userID)
+	}
+	if rec.KeyID == "" {
+		updated, err := ipfsdb.UpdateRecord(k.db, userKeyKey(userID), func(r *models.UserKey) bool {
+			r.KeyID = KeyIDFor(r.PublicKey)
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to save signing key: %w", err)
+		}
+		rec = updated
+	}
+	if _, ok := k.KeyRecord(rec.KeyID); !ok {
+		if err := k.db.Save(signingKeyKey(rec.KeyID), &models.SigningKeyRecord{
+			KeyID:     rec.KeyID,
+			UserID:    userID,
+			PublicKey: rec.PublicKey,
+			Status:    models.KeyStatusActive,
+			CreatedAt: rec.CreatedAt,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to save signing key record: %w", err)
+		}
+	}
+	return rec, nil
+}
+
+// CreateKey generates and stores a signing key for userID and returns its
+// base64 public key. An existing key is kept and returned.
+func (k *Keystore) CreateKey(userID string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	rec, err := k.currentLocked(userID)
+	if err != nil {
+		return "", err
+	}
+	return rec.PublicKey, nil
+}
+
+// EnsureKey makes sure user has a signing key and that its PublicKey
+// publishes it, saving the user when it changes. It returns the user as
+// stored. Users provisioned before the keystore existed get their key here.
+func (k *Keystore) EnsureKey(user *models.User) (*models.User, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	rec, err := k.currentLocked(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return k.publishLocked(user.ID, rec.PublicKey)
+}
+
+// publishLocked sets the PublicKey of the stored user userID to publicKey
+// and returns the user as stored. The user is read again from the store, so
+// changes saved since the caller loaded it are kept. Caller must hold k.mu.
+func (k *Keystore) publishLocked(userID, publicKey string) (*models.User, error) {
+	user, err := ipfsdb.UpdateRecord(k.db, userID, func(u *models.User) bool {
+		if u.PublicKey == publicKey {
+			return false
+		}
+		u.PublicKey = publicKey
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save user public key: %w", err)
+	}
+	return user, nil
+}
+
+// Rotate replaces user's current signing key with a new one and publishes
+// it. The old key is kept as rotated, so what it signed stays verifiable.
+func (k *Keystore) Rotate(user *models.User) (*models.SigningKeyRecord, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.rotateLocked(user)
+}
+
+// rotateLocked rotates user's key. Caller must hold k.mu.
+func (k *Keystore) rotateLocked(user *models.User) (*models.SigningKeyRecord, error) {
+	old, err := k.currentLocked(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	next, err := k.newKeyLocked(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = ipfsdb.UpdateRecord(k.db, signingKeyKey(old.KeyID), func(rec *models.SigningKeyRecord) bool {
+		if rec.Status != models.KeyStatusActive {
+			return false
+		}
+		rec.Status = models.KeyStatusRotated
+		rec.RotatedAt = &now
+		rec.ReplacedBy = next.KeyID
+		return true
+	})
+	if err != nil && !errors.Is(err, ipfsdb.ErrNotFound) {
+		return nil, fmt.Errorf("failed to save signing key record: %w", err)
+	}
+	if _, err := k.publishLocked(user.ID, next.PublicKey); err != nil {
+		return nil, err
+	}
+
+	rec, _ := k.KeyRecord(next.KeyID)
+	return rec, nil
+}
+
+// Revoke marks one of user's keys as revoked; signatures made with it no
+// longer count. Revoking the current key rotates to a new one first. The
+// lock is held throughout, so concurrent rotations and revocations cannot
+// leave two active keys or publish a revoked one.
+func (k *Keystore) Revoke(user *models.User, keyID, reason string) (*models.SigningKeyRecord, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	rec, ok := k.KeyRecord(keyID)
+	if !ok || rec.UserID != user.ID {
+		return nil, ErrNoSigningKey
+	}
+	if rec.Status == models.KeyStatusRevoked {
+		return rec, nil
+	}
+	if current, ok := k.get(user.ID); ok && current.KeyID == keyID {
+		if _, err := k.rotateLocked(user); err != nil {
+			return nil, err
+		}
+		rec, _ = k.KeyRecord(keyID)
+	}
+
+	now := time.Now()
+	revoked, err := ipfsdb.UpdateRecord(k.db, signingKeyKey(keyID), func(rec *models.SigningKeyRecord) bool {
+		rec.Status = models.KeyStatusRevoked
+		rec.RevokedAt = &now
+		rec.RevocationReason = reason
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save signing key record: %w", err)
+	}
+	return revoked, nil
+}
+
+// PublicKey returns the base64 public key of userID
+func (k *Keystore) PublicKey(userID string) (string, error) {
+	rec, ok := k.get(userID)
+	if !ok {
+		return "", ErrNoSigningKey
+	}
+	return rec.PublicKey, nil
+}
+
+// current returns the current key of userID together with its unsealed
+// private key, read once under k.mu so a concurrent rotation cannot pair
+// the private key of one key with the public key of another
+func (k *Keystore) current(userID string) (*models.UserKey, ed25519.PrivateKey, error) {
+	k.mu.Lock()
+	rec, ok := k.get(userID)
+	k.mu.Unlock()
+	if !ok {
+		return nil, nil, ErrNoSigningKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(rec.SealedPrivate)
+	if err != nil || len(sealed) < k.aead.NonceSize() {
+		return nil, nil, fmt.Errorf("corrupt signing key for user %s", userID)
+	}
+	seed, err := k.aead.Open(nil, sealed[:k.aead.NonceSize()], sealed[k.aead.NonceSize():], []byte(userID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unseal signing key for user %s (wrong KEYSTORE_KEY?)", userID)
+	}
+	return rec, ed25519.NewKeyFromSeed(seed), nil
+}
+
+// Sign returns userID's base64 detached Ed25519 signature over the message
+// built by message, together with the base64 public key of the signing key.
+// message is given that public key, so what it embeds is always the key
+// that made the signature.
+func (k *Keystore) Sign(userID string, message func(publicKey string) ([]byte, error)) (signature, publicKey string, err error) {
+	rec, priv, err := k.current(userID)
+	if err != nil {
+		return "", "", err
+	}
+	msg, err := message(rec.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+	return crypto.SignEd25519(priv, msg), rec.PublicKey, nil
+}
+
+// Signer returns a JWS signer holding userID's current key
+func (k *Keystore) Signer(userID string) (*crypto.Signer, error) {
+	_, priv, err := k.current(userID)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.NewSignerFromKey(priv), nil
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"sync"
+	"testing"
+
+	"yourproject/internal/crypto"
+	"yourproject/internal/ipfsdb"
+	"yourproject/internal/models"
+)
+
+func newTestKeystore(t *testing.T) (*Keystore, *ipfsdb.IPFSDB) {
+	t.Helper()
+	db := ipfsdb.New()
+	keys, err := NewKeystore(db, []byte("test keystore key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys, db
+}
+
+func TestSignEmbedsSigningKey(t *testing.T) {
+	keys, db := newTestKeystore(t)
+	user := &models.User{ID: "3c9b1f4e-2a7d-4e8b-9f60-5d1c2b3a4e5f"}
+	if err := db.Save(user.ID, user); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.EnsureKey(user); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rotations race the signatures; each signature must still come from the
+	// key its message embeds
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if _, err := keys.Rotate(user); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		var message []byte
+		signature, publicKey, err := keys.Sign(user.ID, func(publicKey string) ([]byte, error) {
+			message = []byte("artwork signed by " + publicKey)
+			return message, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(message) != "artwork signed by "+publicKey {
+			t.Fatalf("message embeds another key than the returned %s", publicKey)
+		}
+		if err := crypto.VerifyEd25519(publicKey, message, signature); err != nil {
+			t.Fatalf("signature %d does not verify with the embedded key: %v", i, err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	if _, _, err := keys.Sign("nobody", func(string) ([]byte, error) { return nil, nil }); err != ErrNoSigningKey {
+		t.Errorf("signing without a key: %v, want ErrNoSigningKey", err)
+	}
+}
+
+func TestPublishKeepsStoredUser(t *testing.T) {
+	keys, db := newTestKeystore(t)
+	stale := &models.User{ID: "8e2d4c6a-1b3f-4a5d-8c7e-9f0a1b2c3d4e", UserType: RoleArtist}
+	if err := db.Save(stale.ID, stale); err != nil {
+		t.Fatal(err)
+	}
+
+	// Other requests change the user after this one loaded it
+	if _, err := ipfsdb.UpdateRecord(db, stale.ID, func(u *models.User) bool {
+		u.DisplayName = "Ada"
+		u.WalletAddress = "0x00000000000000000000000000000000000a1a1a"
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := keys.EnsureKey(stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.DisplayName != "Ada" || user.WalletAddress == "" || user.PublicKey == "" {
+		t.Errorf("EnsureKey stored %+v, want the newer profile with a public key", user)
+	}
+
+	if _, err := ipfsdb.UpdateRecord(db, stale.ID, func(u *models.User) bool {
+		u.UserType = RoleAdmin
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := keys.Rotate(stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	val, _ := db.Get(stale.ID)
+	stored := val.(*models.User)
+	if stored.UserType != RoleAdmin || stored.DisplayName != "Ada" || stored.PublicKey != rec.PublicKey {
+		t.Errorf("Rotate stored %+v, want the admin Ada publishing %s", stored, rec.PublicKey)
+	}
+	if stale.PublicKey != "" || stale.DisplayName != "" {
+		t.Errorf("caller's user changed: %+v", stale)
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+)
+
+// microsoftIssuer is the issuer of Microsoft identity platform v2.0 tokens;
+// {tid} is the tenant that issued the token
+const microsoftIssuer = "https://login.microsoftonline.com/{tid}/v2.0"
+
+// multiTenantAliases are the AZURE_TENANT_ID values that accept users from
+// more than one tenant
+var multiTenantAliases = map[string]bool{"common": true, "organizations": true, "consumers": true}
+
+// MicrosoftJWKSURL returns the Microsoft identity platform key set for a
+// tenant ("common" for multi-tenant)
+func MicrosoftJWKSURL(tenantID string) string {
+	if tenantID == "" {
+		tenantID = "common"
+	}
+	return fmt.Sprintf("https://login.microsoftonline.com/%s/discovery/v2.0/keys", tenantID)
+}
+
+// MicrosoftProviderFromEnv returns the Azure AD provider, or nil when
+// AZURE_CLIENT_ID (comma-separated audiences) is not set. It reads
+// AZURE_ALLOWED_TENANTS, AZURE_ISSUER and the JWKS settings; a
+// single-tenant AZURE_TENANT_ID (a tenant ID, not common, organizations or
+// consumers) limits tenants to that one when no allowlist is given. Users
+// are identified by their oid, and users stored before identities were
+// keyed by issuer belong to this provider.
+func MicrosoftProviderFromEnv() *ProviderConfig {
+	audiences := splitList(os.Getenv("AZURE_CLIENT_ID"))
+	if len(audiences) == 0 {
+		return nil
+	}
+
+	config := &ProviderConfig{
+		Name:             "microsoft",
+		Issuer:           os.Getenv("AZURE_ISSUER"),
+		JWKSURL:          JWKSURLFromEnv(),
+		Audiences:        audiences,
+		AllowedTenants:   splitList(os.Getenv("AZURE_ALLOWED_TENANTS")),
+		SubjectClaim:     "oid",
+		ClaimLegacyUsers: true,
+	}
+	if config.Issuer == "" {
+		config.Issuer = microsoftIssuer
+	}
+	if tenantID := os.Getenv("AZURE_TENANT_ID"); tenantID != "" && !multiTenantAliases[tenantID] && len(config.AllowedTenants) == 0 {
+		config.AllowedTenants = []string{tenantID}
+	}
+	return config
+}
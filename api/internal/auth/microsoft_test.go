@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	testClientID = "6f1c2e0a-5b7d-4e8f-9a1b-2c3d4e5f6a7b"
+	testTenant   = "72f988bf-86f1-41af-91ab-2d7cd011db47"
+	otherTenant  = "9188040d-6c67-4c5b-b112-36a304b66dad"
+)
+
+// signToken signs claims with key as an RS256 JWT
+func signToken(t *testing.T, key jwk.Key, claims map[string]interface{}) string {
+	t.Helper()
+	token := jwt.New()
+	for name, value := range claims {
+		if err := token.Set(name, value); err != nil {
+			t.Fatalf("failed to set %s: %v", name, err)
+		}
+	}
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256, key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(signed)
+}
+
+// microsoftClaims returns the claims of a valid token of testTenant, with
+// the claims in overrides replaced and those set to nil removed
+func microsoftClaims(overrides map[string]interface{}) map[string]interface{} {
+	now := time.Now()
+	claims := map[string]interface{}{
+		"iss": "https://login.microsoftonline.com/" + testTenant + "/v2.0",
+		"aud": testClientID,
+		"tid": testTenant,
+		"oid": "00000000-0000-0000-66f3-3332eca7ea81",
+		"sub": "AAAAAAAAAAAAAAAAAAAAAIkzqFVrSaSaFHy782bbtaQ",
+		"iat": now.Add(-time.Minute),
+		"nbf": now.Add(-time.Minute),
+		"exp": now.Add(time.Hour),
+	}
+	for name, value := range overrides {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+	return claims
+}
+
+func TestMicrosoftTokenValidation(t *testing.T) {
+	key := newSigningKey(t, "microsoft-key")
+	server := newJWKSServer(t, key)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	authn := NewAuthenticator(ctx, []ProviderConfig{{
+		Name:           "microsoft",
+		Issuer:         microsoftIssuer,
+		JWKSURL:        server.URL,
+		Audiences:      []string{testClientID},
+		AllowedTenants: []string{testTenant},
+		SubjectClaim:   "oid",
+		ClockSkew:      time.Minute,
+	}}, time.Hour)
+
+	now := time.Now()
+	tests := []struct {
+		name    string
+		key     jwk.Key
+		claims  map[string]interface{}
+		wantErr string // substring of the error; "" accepts
+	}{
+		{
+			name:   "valid token",
+			claims: microsoftClaims(nil),
+		},
+		{
+			name:   "expired within clock skew",
+			claims: microsoftClaims(map[string]interface{}{"exp": now.Add(-30 * time.Second)}),
+		},
+		{
+			name:    "wrong audience",
+			claims:  microsoftClaims(map[string]interface{}{"aud": "00000003-0000-0000-c000-000000000000"}),
+			wantErr: "audience",
+		},
+		{
+			name: "issuer of another tenant than tid",
+			claims: microsoftClaims(map[string]interface{}{
+				"iss": "https://login.microsoftonline.com/" + otherTenant + "/v2.0",
+			}),
+			wantErr: "issuer",
+		},
+		{
+			name: "tenant not allowed",
+			claims: microsoftClaims(map[string]interface{}{
+				"iss": "https://login.microsoftonline.com/" + otherTenant + "/v2.0",
+				"tid": otherTenant,
+			}),
+			wantErr: "not allowed",
+		},
+		{
+			name:    "missing tid",
+			claims:  microsoftClaims(map[string]interface{}{"tid": nil}),
+			wantErr: "tid",
+		},
+		{
+			name:    "expired beyond clock skew",
+			claims:  microsoftClaims(map[string]interface{}{"exp": now.Add(-2 * time.Minute)}),
+			wantErr: "exp",
+		},
+		{
+			name:    "missing exp",
+			claims:  microsoftClaims(map[string]interface{}{"exp": nil}),
+			wantErr: "exp",
+		},
+		{
+			name:    "v1 issuer",
+			claims:  microsoftClaims(map[string]interface{}{"iss": "https://sts.windows.net/" + testTenant + "/"}),
+			wantErr: "untrusted token issuer",
+		},
+		{
+			name:    "signed with a key not in the JWKS",
+			key:     newSigningKey(t, "microsoft-key"),
+			claims:  microsoftClaims(nil),
+			wantErr: "verify",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signingKey := tt.key
+			if signingKey == nil {
+				signingKey = key
+			}
+			userInfo, err := authn.Validate(signToken(t, signingKey, tt.claims))
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Fatalf("token accepted, want an error containing %q", tt.wantErr)
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %q, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("token rejected: %v", err)
+			}
+			if userInfo.Subject != tt.claims["oid"] || userInfo.Issuer != tt.claims["iss"] || userInfo.Provider != "microsoft" {
+				t.Errorf("user info = %+v", userInfo)
+			}
+		})
+	}
+}
+
+func TestMicrosoftProviderFromEnv(t *testing.T) {
+	t.Setenv("AZURE_CLIENT_ID", "")
+	if config := MicrosoftProviderFromEnv(); config != nil {
+		t.Errorf("provider configured without AZURE_CLIENT_ID: %+v", config)
+	}
+
+	t.Setenv("AZURE_CLIENT_ID", testClientID+", api://penguin")
+	t.Setenv("AZURE_TENANT_ID", testTenant)
+	t.Setenv("AZURE_ALLOWED_TENANTS", "")
+	t.Setenv("AZURE_ISSUER", "")
+	config := MicrosoftProviderFromEnv()
+	if config == nil {
+		t.Fatal("no provider with AZURE_CLIENT_ID set")
+	}
+	if config.Issuer != microsoftIssuer {
+		t.Errorf("issuer %s, want %s", config.Issuer, microsoftIssuer)
+	}
+	if len(config.Audiences) != 2 || config.Audiences[1] != "api://penguin" {
+		t.Errorf("audiences %v", config.Audiences)
+	}
+	if len(config.AllowedTenants) != 1 || config.AllowedTenants[0] != testTenant {
+		t.Errorf("a single-tenant AZURE_TENANT_ID allows tenants %v", config.AllowedTenants)
+	}
+
+	t.Setenv("AZURE_TENANT_ID", "common")
+	if config := MicrosoftProviderFromEnv(); len(config.AllowedTenants) != 0 {
+		t.Errorf("AZURE_TENANT_ID=common allows only tenants %v", config.AllowedTenants)
+	}
+}
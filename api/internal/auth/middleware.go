@@ -1,16 +1,12 @@
 package auth
 
 import (
-	"context"
 	"fmt"
 	"log"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/labstack/echo/v4"
-	"github.com/lestrrat-go/jwx/v2/jwk"
-	"github.com/lestrrat-go/jwx/v2/jwt"
 
 	"yourproject/internal/ipfsdb"
 	"yourproject/internal/models"
@@ -18,79 +14,21 @@ import (
 
 // UserInfo contains the authenticated user information extracted from the JWT
 type UserInfo struct {
-	UserID   string
+	UserID   string // same as Subject, kept for backward compatibility
+	Issuer   string
+	Subject  string
+	Provider string // name of the identity provider that issued the token
 	Email    string
 	Name     string
 	Username string
-}
-
-// ValidateMicrosoftJWT validates a Microsoft JWT token and extracts user information
-func ValidateMicrosoftJWT(tokenString string) (*UserInfo, error) {
-	// Get the tenant ID from environment (optional, can use "common" for multi-tenant)
-	tenantID := os.Getenv("AZURE_TENANT_ID")
-	if tenantID == "" {
-		tenantID = "common"
-	}
-
-	// Microsoft's JWKS endpoint
-	jwksURL := fmt.Sprintf("https://login.microsoftonline.com/%s/discovery/v2.0/keys", tenantID)
-
-	// Fetch the JWKS (JSON Web Key Set) from Microsoft
-	keySet, err := jwk.Fetch(context.Background(), jwksURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
-	}
 
-	// Parse and validate the token
-	// Note: We validate signature with JWKS but allow flexible issuer validation
-	// since Microsoft tokens can have different issuer formats
-	token, err := jwt.Parse(
-		[]byte(tokenString),
-		jwt.WithKeySet(keySet),
-		jwt.WithValidate(true),
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse/validate token: %w", err)
-	}
-
-	// Validate issuer manually (more flexible)
-	issuer, _ := token.Get("iss")
-	issuerStr := fmt.Sprintf("%v", issuer)
-	if !strings.Contains(issuerStr, "login.microsoftonline.com") {
-		return nil, fmt.Errorf("invalid token issuer: %s", issuerStr)
-	}
-
-	// Extract user information from token claims
-	userInfo := &UserInfo{}
-
-	// Get user ID (oid or sub claim)
-	if oid, ok := token.Get("oid"); ok {
-		userInfo.UserID = fmt.Sprintf("%v", oid)
-	} else if sub, ok := token.Get("sub"); ok {
-		userInfo.UserID = fmt.Sprintf("%v", sub)
-	}
-
-	// Get email
-	if email, ok := token.Get("email"); ok {
-		userInfo.Email = fmt.Sprintf("%v", email)
-	}
-
-	// Get preferred username
-	if preferredUsername, ok := token.Get("preferred_username"); ok {
-		userInfo.Username = fmt.Sprintf("%v", preferredUsername)
-	}
-
-	// Get name
-	if name, ok := token.Get("name"); ok {
-		userInfo.Name = fmt.Sprintf("%v", name)
-	}
-
-	return userInfo, nil
+	claimLegacyUsers bool
 }
 
-// JWTAuthMiddleware is an Echo middleware that validates Microsoft JWT tokens,
-// automatically provisions users on first login, and attaches the User model to context
-func JWTAuthMiddleware(db *ipfsdb.IPFSDB) echo.MiddlewareFunc {
+// JWTAuthMiddleware is an Echo middleware that validates JWT tokens from the configured
+// identity providers, automatically provisions users on first login, and attaches the
+// User model to context
+func JWTAuthMiddleware(db ipfsdb.Store, keys *Keystore, authn *Authenticator) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// Get the Authorization header
@@ -112,28 +50,34 @@ func JWTAuthMiddleware(db *ipfsdb.IPFSDB) echo.MiddlewareFunc {
 			tokenString := parts[1]
 
 			// Validate the token
-			userInfo, err := ValidateMicrosoftJWT(tokenString)
+			userInfo, err := authn.Validate(tokenString)
 			if err != nil {
 				return c.JSON(http.StatusUnauthorized, map[string]string{
 					"error": fmt.Sprintf("invalid token: %v", err),
 				})
 			}
 
-			// Extract AuthenticatorID (Microsoft user ID)
-			authenticatorID := userInfo.UserID
-			if authenticatorID == "" {
+			// Users are identified by issuer and subject, so identities from
+			// different providers cannot collide
+			if userInfo.Subject == "" {
 				return c.JSON(http.StatusUnauthorized, map[string]string{
 					"error": "token missing user identifier",
 				})
 			}
 
 			// Check if user exists in database
-			user, found := db.FindUserByAuthenticatorID(authenticatorID)
+			user, found, err := FindUser(db, userInfo)
+			if err != nil {
+				c.Logger().Errorf("failed to look up user: %v", err)
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "failed to look up user account",
+				})
+			}
 
 			if !found {
 				// User doesn't exist - automatically provision them
-				log.Printf("🆕 Provisioning new user with AuthenticatorID: %s", authenticatorID)
-				newUser, err := ProvisionNewUser(db, authenticatorID, userInfo)
+				log.Printf("🆕 Provisioning new user for %s (%s)", userInfo.Subject, userInfo.Issuer)
+				newUser, err := ProvisionNewUser(db, keys, userInfo)
 				if err != nil {
 					c.Logger().Errorf("failed to provision new user: %v", err)
 					return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -141,7 +85,13 @@ func JWTAuthMiddleware(db *ipfsdb.IPFSDB) echo.MiddlewareFunc {
 					})
 				}
 				user = newUser
-				log.Printf("✅ User provisioned successfully: ID=%s, AuthenticatorID=%s", user.ID, user.AuthenticatorID)
+				log.Printf("✅ User provisioned successfully: ID=%s, Issuer=%s, Subject=%s", user.ID, user.Issuer, user.Subject)
+			} else if user, err = keys.EnsureKey(user); err != nil {
+				// Users provisioned before the keystore get their signing key now
+				c.Logger().Errorf("failed to provision signing key for user %s: %v", user.ID, err)
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "failed to provision signing key",
+				})
 			}
 
 			// Store both UserInfo (from JWT) and User model (from DB) in context
@@ -156,7 +106,7 @@ func JWTAuthMiddleware(db *ipfsdb.IPFSDB) echo.MiddlewareFunc {
 
 // OptionalJWTAuthMiddleware is similar to JWTAuthMiddleware but doesn't require authentication
 // It validates the token if present and sets user info in context
-func OptionalJWTAuthMiddleware() echo.MiddlewareFunc {
+func OptionalJWTAuthMiddleware(authn *Authenticator) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			// Get the Authorization header
@@ -176,7 +126,7 @@ func OptionalJWTAuthMiddleware() echo.MiddlewareFunc {
 			tokenString := parts[1]
 
 			// Try to validate the token
-			userInfo, err := ValidateMicrosoftJWT(tokenString)
+			userInfo, err := authn.Validate(tokenString)
 			if err != nil {
 				// Token invalid, but continue anyway (optional auth)
 				return next(c)
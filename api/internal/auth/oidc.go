@@ -0,0 +1,358 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+const (
+	// tenantPlaceholder stands for the token's tid claim in an issuer
+	tenantPlaceholder = "{tid}"
+	// defaultClockSkew is how far exp, nbf and iat may be off
+	defaultClockSkew = time.Minute
+	// discoveryRetry is the least time between attempts to fetch a discovery
+	// document that could not be fetched
+	discoveryRetry = 30 * time.Second
+	// discoveryTimeout bounds a discovery document fetch
+	discoveryTimeout = 10 * time.Second
+)
+
+// ProviderConfig is an OpenID Connect provider whose tokens are accepted.
+// Issuer may contain {tid} for multi-tenant providers (Azure AD); the
+// token's tid claim is substituted and must be an allowed tenant.
+type ProviderConfig struct {
+	Name           string   `json:"name"`
+	Issuer         string   `json:"issuer"`
+	DiscoveryURL   string   `json:"discovery_url,omitempty"` // defaults to <issuer>/.well-known/openid-configuration
+	JWKSURL        string   `json:"jwks_uri,omitempty"`      // skips discovery when set
+	Audiences      []string `json:"audiences"`               // accepted aud values: our client IDs
+	AllowedTenants []string `json:"allowed_tenants,omitempty"`
+	SubjectClaim   string   `json:"subject_claim,omitempty"` // claim identifying the user; "sub" by default
+
+	ClockSkew time.Duration `json:"-"` // leeway for exp, nbf and iat
+	// ClaimLegacyUsers lets users stored before identities were keyed by
+	// issuer (bare AuthenticatorID) be claimed by this provider
+	ClaimLegacyUsers bool `json:"-"`
+}
+
+// ProvidersFromEnv returns the Microsoft provider configured by AZURE_*
+// and the providers in OIDC_PROVIDERS, a JSON array of ProviderConfig given
+// inline or as a file path. JWT_CLOCK_SKEW applies to all of them.
+func ProvidersFromEnv() ([]ProviderConfig, error) {
+	configs := []ProviderConfig{}
+	if microsoft := MicrosoftProviderFromEnv(); microsoft != nil {
+		configs = append(configs, *microsoft)
+	}
+
+	if raw := strings.TrimSpace(os.Getenv("OIDC_PROVIDERS")); raw != "" {
+		data := []byte(raw)
+		if !strings.HasPrefix(raw, "[") {
+			file, err := os.ReadFile(raw)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read OIDC_PROVIDERS: %w", err)
+			}
+			data = file
+		}
+		var providers []ProviderConfig
+		if err := json.Unmarshal(data, &providers); err != nil {
+			return nil, fmt.Errorf("invalid OIDC_PROVIDERS: %w", err)
+		}
+		configs = append(configs, providers...)
+	}
+
+	skew := defaultClockSkew
+	if s := os.Getenv("JWT_CLOCK_SKEW"); s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid JWT_CLOCK_SKEW %q", s)
+		}
+		skew = d
+	}
+
+	if len(configs) == 0 {
+		return nil, errors.New("no identity provider configured: set AZURE_CLIENT_ID or OIDC_PROVIDERS")
+	}
+	issuers := map[string]bool{}
+	for i := range configs {
+		c := &configs[i]
+		c.ClockSkew = skew
+		if c.Issuer == "" {
+			return nil, fmt.Errorf("identity provider %d has no issuer", i)
+		}
+		if len(c.Audiences) == 0 {
+			return nil, fmt.Errorf("identity provider %s must list the audiences (client IDs) tokens are accepted for", c.Issuer)
+		}
+		if issuers[c.Issuer] {
+			return nil, fmt.Errorf("identity provider %s is configured twice", c.Issuer)
+		}
+		issuers[c.Issuer] = true
+		if c.Name == "" {
+			if u, err := url.Parse(c.Issuer); err == nil && u.Host != "" {
+				c.Name = u.Host
+			} else {
+				c.Name = c.Issuer
+			}
+		}
+	}
+	return configs, nil
+}
+
+// discoveryDocument holds the OpenID Provider Metadata fields we use
+type discoveryDocument struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+// Provider validates the tokens of one OpenID Connect issuer. Its key set
+// comes from jwks_uri in the discovery document, fetched on first use.
+type Provider struct {
+	config  ProviderConfig
+	ctx     context.Context
+	refresh time.Duration
+
+	mu          sync.Mutex
+	keys        *KeySet
+	lastAttempt time.Time
+}
+
+// NewProvider creates a provider whose key set is refreshed in the
+// background every refresh until ctx is done
+func NewProvider(ctx context.Context, config ProviderConfig, refresh time.Duration) *Provider {
+	return &Provider{config: config, ctx: ctx, refresh: refresh}
+}
+
+// Name returns the provider's display name
+func (p *Provider) Name() string { return p.config.Name }
+
+// Config returns the provider's configuration
+func (p *Provider) Config() ProviderConfig { return p.config }
+
+// Discover fetches the discovery document, unless a JWKS URL is configured,
+// and sets up the key set. Failed attempts are retried at most every
+// discoveryRetry.
+func (p *Provider) Discover(ctx context.Context) (*KeySet, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys != nil {
+		return p.keys, nil
+	}
+	if time.Since(p.lastAttempt) < discoveryRetry {
+		return nil, fmt.Errorf("discovery for %s failed recently, retrying later", p.config.Issuer)
+	}
+	p.lastAttempt = time.Now()
+
+	jwksURL := p.config.JWKSURL
+	if jwksURL == "" {
+		doc, err := p.fetchDiscovery(ctx)
+		if err != nil {
+			return nil, err
+		}
+		jwksURL = doc.JWKSURI
+	}
+
+	keys, err := NewKeySet(p.ctx, jwksURL, p.refresh)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	return keys, nil
+}
+
+// fetchDiscovery fetches and checks the provider's discovery document
+func (p *Provider) fetchDiscovery(ctx context.Context) (*discoveryDocument, error) {
+	discoveryURL := p.config.DiscoveryURL
+	if discoveryURL == "" {
+		discoveryURL = strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, discoveryTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid discovery URL %s: %w", discoveryURL, err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document %s: %w", discoveryURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch discovery document %s: %s", discoveryURL, resp.Status)
+	}
+
+	var doc discoveryDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid discovery document %s: %w", discoveryURL, err)
+	}
+	// Azure AD's multi-tenant documents spell the placeholder {tenantid}
+	if issuer := strings.ReplaceAll(doc.Issuer, "{tenantid}", tenantPlaceholder); issuer != p.config.Issuer {
+		return nil, fmt.Errorf("discovery document %s is for issuer %s, not %s", discoveryURL, doc.Issuer, p.config.Issuer)
+	}
+	if doc.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document %s has no jwks_uri", discoveryURL)
+	}
+	return &doc, nil
+}
+
+// Matches reports whether iss can be a token issuer of this provider
+func (p *Provider) Matches(iss string) bool {
+	prefix, suffix, templated := strings.Cut(p.config.Issuer, tenantPlaceholder)
+	if !templated {
+		return iss == p.config.Issuer
+	}
+	if !strings.HasPrefix(iss, prefix) || !strings.HasSuffix(iss, suffix) || len(iss) <= len(prefix)+len(suffix) {
+		return false
+	}
+	return !strings.Contains(iss[len(prefix):len(iss)-len(suffix)], "/")
+}
+
+// Validate validates a token of this provider and extracts user
+// information. The token must be signed by a key in the provider's key set,
+// be within its validity window, be issued to one of our audiences (by an
+// allowed tenant) and carry exactly the provider's issuer.
+func (p *Provider) Validate(ctx context.Context, tokenString string) (*UserInfo, error) {
+	keys, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse(
+		[]byte(tokenString),
+		jwt.WithKeyProvider(keys),
+		jwt.WithValidate(true),
+		jwt.WithAcceptableSkew(p.config.ClockSkew),
+		jwt.WithRequiredClaim(jwt.ExpirationKey),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse/validate token: %w", err)
+	}
+
+	if !containsAny(p.config.Audiences, token.Audience()) {
+		return nil, fmt.Errorf("token audience %v is not accepted", token.Audience())
+	}
+
+	expected := p.config.Issuer
+	if strings.Contains(expected, tenantPlaceholder) {
+		tid := claimString(token, "tid")
+		if tid == "" {
+			return nil, errors.New("token missing tenant (tid)")
+		}
+		expected = strings.ReplaceAll(expected, tenantPlaceholder, tid)
+	}
+	if tid := claimString(token, "tid"); len(p.config.AllowedTenants) > 0 && !containsAny(p.config.AllowedTenants, []string{tid}) {
+		return nil, fmt.Errorf("tenant %s is not allowed", tid)
+	}
+	if token.Issuer() != expected {
+		return nil, fmt.Errorf("invalid token issuer: %s", token.Issuer())
+	}
+
+	// Extract user information from token claims
+	userInfo := &UserInfo{
+		Issuer:           token.Issuer(),
+		Provider:         p.config.Name,
+		Email:            claimString(token, "email"),
+		Username:         claimString(token, "preferred_username"),
+		Name:             claimString(token, "name"),
+		claimLegacyUsers: p.config.ClaimLegacyUsers,
+	}
+	if p.config.SubjectClaim != "" {
+		userInfo.Subject = claimString(token, p.config.SubjectClaim)
+	}
+	if userInfo.Subject == "" {
+		userInfo.Subject = token.Subject()
+	}
+	userInfo.UserID = userInfo.Subject
+
+	return userInfo, nil
+}
+
+// Authenticator validates tokens from any configured provider, picking the
+// provider by the token's iss claim
+type Authenticator struct {
+	providers []*Provider
+}
+
+// NewAuthenticator creates providers for configs, refreshing their key
+// sets every refresh until ctx is done
+func NewAuthenticator(ctx context.Context, configs []ProviderConfig, refresh time.Duration) *Authenticator {
+	a := &Authenticator{}
+	for _, config := range configs {
+		a.providers = append(a.providers, NewProvider(ctx, config, refresh))
+	}
+	return a
+}
+
+// Providers returns the configured providers
+func (a *Authenticator) Providers() []*Provider { return a.providers }
+
+// Discover fetches every provider's discovery document, logging those that
+// are not reachable yet; they are retried when their first token arrives
+func (a *Authenticator) Discover(ctx context.Context) {
+	for _, p := range a.providers {
+		keys, err := p.Discover(ctx)
+		if err != nil {
+			log.Printf("⚠️  Identity provider %s: %v", p.Name(), err)
+			continue
+		}
+		log.Printf("🔑 Identity provider %s: JWKS %s", p.Name(), keys.URL())
+	}
+}
+
+// Validate validates a token from any configured provider
+func (a *Authenticator) Validate(tokenString string) (*UserInfo, error) {
+	// The issuer only selects the provider; nothing is trusted before the
+	// provider has verified the signature
+	unverified, err := jwt.ParseInsecure([]byte(tokenString))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+	for _, p := range a.providers {
+		if p.Matches(unverified.Issuer()) {
+			return p.Validate(context.Background(), tokenString)
+		}
+	}
+	return nil, fmt.Errorf("untrusted token issuer: %s", unverified.Issuer())
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	list := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// claimString returns a string claim, or "" when the token does not have it
+func claimString(token jwt.Token, name string) string {
+	if value, ok := token.Get(name); ok {
+		return fmt.Sprintf("%v", value)
+	}
+	return ""
+}
+
+// containsAny reports whether any of values is in allowed
+func containsAny(allowed, values []string) bool {
+	for _, value := range values {
+		for _, a := range allowed {
+			if value == a {
+				return true
+			}
+		}
+	}
+	return false
+}
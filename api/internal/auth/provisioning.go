@@ -1,33 +1,38 @@
 package auth
 
 import (
-	"encoding/base64"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 
-	"yourproject/internal/crypto"
 	"yourproject/internal/ipfsdb"
 	"yourproject/internal/models"
 )
 
-// ProvisionNewUser creates and saves a complete User record for a new authenticator ID.
+// ProvisionNewUser creates and saves a complete User record for a new login identity.
 // This function is called automatically when a user logs in for the first time.
-func ProvisionNewUser(db *ipfsdb.IPFSDB, authID string, userInfo *UserInfo) (*models.User, error) {
+// The user's Ed25519 signing key is generated in keys; only its public half
+// is published on the User.
+func ProvisionNewUser(db ipfsdb.Store, keys *Keystore, userInfo *UserInfo) (*models.User, error) {
+	userID := uuid.New().String()
+
 	// Generate Ed25519 key pair for decentralized authorization
-	keyPair, err := crypto.GenerateKeyPair()
+	publicKey, err := keys.CreateKey(userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate crypto keys: %w", err)
 	}
 
 	newUser := &models.User{
-		ID:              uuid.New().String(),
-		WalletAddress:   "", // User will need to set this later via an update endpoint
-		PublicKey:       base64.StdEncoding.EncodeToString(keyPair.PublicKey),
-		UserType:        "artist", // Default user type
-		CreatedAt:       time.Now(),
-		AuthenticatorID: authID,
+		ID:            userID,
+		WalletAddress: "", // Set when the user links a wallet (POST /me/wallet)
+		PublicKey:     publicKey,
+		UserType:      DefaultRoleFromEnv(),
+		CreatedAt:     time.Now(),
+		Issuer:        userInfo.Issuer,
+		Subject:       userInfo.Subject,
+		DisplayName:   userInfo.Name,
 	}
 
 	// Save to DB using the user's ID as the key
@@ -38,3 +43,37 @@ func ProvisionNewUser(db *ipfsdb.IPFSDB, authID string, userInfo *UserInfo) (*mo
 	return newUser, nil
 }
 
+// FindUser returns the user with the identity in userInfo. When the identity's
+// provider may claim legacy users, a user stored before identities were keyed
+// by issuer, with the subject as bare AuthenticatorID, is claimed and saved
+// with its issuer and subject.
+func FindUser(db ipfsdb.Store, userInfo *UserInfo) (*models.User, bool, error) {
+	if user, found := db.FindUserByIdentity(userInfo.Issuer, userInfo.Subject); found {
+		return user, true, nil
+	}
+	if !userInfo.claimLegacyUsers {
+		return nil, false, nil
+	}
+
+	user, found := db.FindUserByAuthenticatorID(userInfo.Subject)
+	if !found || user.Issuer != "" {
+		return nil, false, nil
+	}
+	claimed, err := ipfsdb.UpdateRecord(db, user.ID, func(u *models.User) bool {
+		if u.Issuer != "" {
+			return false
+		}
+		u.Issuer = userInfo.Issuer
+		u.Subject = userInfo.Subject
+		return true
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save user identity: %w", err)
+	}
+	if claimed.Issuer != userInfo.Issuer || claimed.Subject != userInfo.Subject {
+		// Claimed by another identity in the meantime
+		return nil, false, nil
+	}
+	log.Printf("🪪 Legacy user %s now keyed by %s (%s)", claimed.ID, claimed.Subject, claimed.Issuer)
+	return claimed, true, nil
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/labstack/echo/v4"
+
+	"yourproject/internal/ipfsdb"
+	"yourproject/internal/models"
+)
+
+// Roles a user can hold, stored in models.User.UserType
+const (
+	RoleAdmirer = "admirer"
+	RoleArtist  = "artist"
+	RoleAdmin   = "admin"
+)
+
+// Permission is something a role allows
+type Permission string
+
+const (
+	PermVerify       Permission = "verify"      // verify artworks, nodes and uploads
+	PermReport       Permission = "report"      // report suspected copies of an artwork
+	PermGenerate     Permission = "generate"    // generate artworks
+	PermImport       Permission = "import"      // import existing artworks
+	PermRegister     Permission = "register"    // create nodes, artifacts and manifests
+	PermModerate     Permission = "moderate"    // act on any crawler finding or report, change roles
+	PermViewCrawlers Permission = "crawler:all" // view crawler data of every artist
+)
+
+// rolePermissions lists what each role may do. Artists can do everything
+// admirers can, admins everything artists can.
+var rolePermissions = map[string][]Permission{
+	RoleAdmirer: {PermVerify, PermReport},
+	RoleArtist:  {PermVerify, PermReport, PermGenerate, PermImport, PermRegister},
+	RoleAdmin:   {PermVerify, PermReport, PermGenerate, PermImport, PermRegister, PermModerate, PermViewCrawlers},
+}
+
+// ValidRole reports whether role is a known role
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolesWith returns the roles that allow perm, for RequireRole
+func RolesWith(perm Permission) []string {
+	roles := []string{}
+	for _, role := range []string{RoleAdmirer, RoleArtist, RoleAdmin} {
+		for _, p := range rolePermissions[role] {
+			if p == perm {
+				roles = append(roles, role)
+				break
+			}
+		}
+	}
+	return roles
+}
+
+// Can reports whether user's role allows perm
+func Can(user *models.User, perm Permission) bool {
+	if user == nil {
+		return false
+	}
+	for _, p := range rolePermissions[user.UserType] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireRole is an Echo middleware that only lets users holding one of
+// roles through. It must run after JWTAuthMiddleware.
+func RequireRole(roles ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, ok := GetDBUserFromContext(c)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, map[string]string{
+					"error": "user not authenticated",
+				})
+			}
+			for _, role := range roles {
+				if user.UserType == role {
+					return next(c)
+				}
+			}
+			return c.JSON(http.StatusForbidden, map[string]string{
+				"error": fmt.Sprintf("role %q is not allowed to do this", user.UserType),
+			})
+		}
+	}
+}
+
+// DefaultRoleFromEnv returns DEFAULT_USER_ROLE, the role of newly
+// provisioned users, defaulting to artist
+func DefaultRoleFromEnv() string {
+	role := os.Getenv("DEFAULT_USER_ROLE")
+	if role == "" {
+		return RoleArtist
+	}
+	if !ValidRole(role) || role == RoleAdmin {
+		log.Printf("⚠️  Invalid DEFAULT_USER_ROLE %q, using %s", role, RoleArtist)
+		return RoleArtist
+	}
+	return role
+}
+
+// PromoteAdminsFromEnv makes the users listed in ADMIN_USER_IDS
+// (comma-separated user IDs, see GET /me) admins. Users that have not logged
+// in yet are skipped.
+func PromoteAdminsFromEnv(db ipfsdb.Store) error {
+	for _, id := range splitList(os.Getenv("ADMIN_USER_IDS")) {
+		promoted := false
+		_, err := ipfsdb.UpdateRecord(db, id, func(user *models.User) bool {
+			promoted = user.UserType != RoleAdmin
+			user.UserType = RoleAdmin
+			return promoted
+		})
+		if errors.Is(err, ipfsdb.ErrNotFound) {
+			log.Printf("⚠️  ADMIN_USER_IDS: no user %s yet", id)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to promote %s to admin: %w", id, err)
+		}
+		if promoted {
+			log.Printf("🛡️  User %s is now an admin", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,423 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+
+	"yourproject/internal/ipfsdb"
+	"yourproject/internal/models"
+)
+
+// Wallets are linked with Sign-In with Ethereum (EIP-4361): the server issues
+// a single-use nonce, the user signs a message carrying it with their wallet
+// (personal_sign, EIP-191) and the server recovers the signing address from
+// the signature. Only externally owned accounts can link; contract wallets
+// (EIP-1271) are not supported.
+
+const (
+	siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+	siweVersion      = "1"
+	siweStatement    = "Link this wallet to your Penguin account."
+
+	// walletNonceTTL is how long an issued nonce can be used
+	walletNonceTTL = 10 * time.Minute
+	// siweClockSkew is how far in the future a message's issued-at may lie
+	siweClockSkew = time.Minute
+)
+
+var (
+	// ErrInvalidSIWEMessage is returned for text that is not an EIP-4361 message
+	ErrInvalidSIWEMessage = errors.New("invalid EIP-4361 message")
+	// ErrInvalidWalletNonce is returned for unknown, expired or foreign nonces
+	ErrInvalidWalletNonce = errors.New("invalid or expired nonce")
+	// ErrWalletSignature is returned when a signature does not recover to the
+	// message's address
+	ErrWalletSignature = errors.New("signature does not match wallet address")
+)
+
+// SIWEConfig is what wallet-linking messages are bound to
+type SIWEConfig struct {
+	Domain string // RFC 3986 authority users sign in to; the request host when empty
+	URI    string // URI the message must carry; derived from the request when empty
+}
+
+// SIWEConfigFromEnv reads SIWE_DOMAIN and SIWE_URI
+func SIWEConfigFromEnv() SIWEConfig {
+	return SIWEConfig{
+		Domain: os.Getenv("SIWE_DOMAIN"),
+		URI:    os.Getenv("SIWE_URI"),
+	}
+}
+
+// SIWEMessage is a parsed EIP-4361 message
+type SIWEMessage struct {
+	Scheme         string     `json:"scheme,omitempty"`
+	Domain         string     `json:"domain"`
+	Address        string     `json:"address"`
+	Statement      string     `json:"statement,omitempty"`
+	URI            string     `json:"uri"`
+	Version        string     `json:"version"`
+	ChainID        int64      `json:"chain_id"`
+	Nonce          string     `json:"nonce"`
+	IssuedAt       time.Time  `json:"issued_at"`
+	ExpirationTime *time.Time `json:"expiration_time,omitempty"`
+	NotBefore      *time.Time `json:"not_before,omitempty"`
+	RequestID      string     `json:"request_id,omitempty"`
+	Resources      []string   `json:"resources,omitempty"`
+}
+
+// String returns the message text a wallet signs
+func (m *SIWEMessage) String() string {
+	var b strings.Builder
+	if m.Scheme != "" {
+		b.WriteString(m.Scheme + "://")
+	}
+	b.WriteString(m.Domain + siweHeaderSuffix + "\n")
+	b.WriteString(m.Address + "\n\n")
+	if m.Statement != "" {
+		b.WriteString(m.Statement + "\n")
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "URI: %s\n", m.URI)
+	fmt.Fprintf(&b, "Version: %s\n", m.Version)
+	fmt.Fprintf(&b, "Chain ID: %d\n", m.ChainID)
+	fmt.Fprintf(&b, "Nonce: %s\n", m.Nonce)
+	fmt.Fprintf(&b, "Issued At: %s", m.IssuedAt.UTC().Format(time.RFC3339))
+	if m.ExpirationTime != nil {
+		fmt.Fprintf(&b, "\nExpiration Time: %s", m.ExpirationTime.UTC().Format(time.RFC3339))
+	}
+	if m.NotBefore != nil {
+		fmt.Fprintf(&b, "\nNot Before: %s", m.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if m.RequestID != "" {
+		fmt.Fprintf(&b, "\nRequest ID: %s", m.RequestID)
+	}
+	if len(m.Resources) > 0 {
+		b.WriteString("\nResources:")
+		for _, r := range m.Resources {
+			b.WriteString("\n- " + r)
+		}
+	}
+	return b.String()
+}
+
+// ParseSIWEMessage parses the text of an EIP-4361 message. Fields must
+// appear in the order the specification gives them.
+func ParseSIWEMessage(text string) (*SIWEMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if len(lines) < 9 {
+		return nil, fmt.Errorf("%w: message is too short", ErrInvalidSIWEMessage)
+	}
+
+	m := &SIWEMessage{}
+	origin, ok := strings.CutSuffix(lines[0], siweHeaderSuffix)
+	if !ok || origin == "" {
+		return nil, fmt.Errorf("%w: missing sign-in header", ErrInvalidSIWEMessage)
+	}
+	if scheme, domain, ok := strings.Cut(origin, "://"); ok {
+		m.Scheme, m.Domain = scheme, domain
+	} else {
+		m.Domain = origin
+	}
+	m.Address = lines[1]
+	if lines[2] != "" {
+		return nil, fmt.Errorf("%w: expected a blank line after the address", ErrInvalidSIWEMessage)
+	}
+
+	rest := lines[4:]
+	if lines[3] != "" {
+		m.Statement = lines[3]
+		if lines[4] != "" {
+			return nil, fmt.Errorf("%w: expected a blank line after the statement", ErrInvalidSIWEMessage)
+		}
+		rest = lines[5:]
+	}
+
+	// field returns the value of the next line if it carries name
+	field := func(name string, required bool) (string, error) {
+		if len(rest) > 0 {
+			if value, ok := strings.CutPrefix(rest[0], name+": "); ok {
+				rest = rest[1:]
+				return value, nil
+			}
+		}
+		if required {
+			return "", fmt.Errorf("%w: missing %s", ErrInvalidSIWEMessage, name)
+		}
+		return "", nil
+	}
+	timestamp := func(name, value string) (*time.Time, error) {
+		if value == "" {
+			return nil, nil
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s is not an RFC 3339 timestamp", ErrInvalidSIWEMessage, name)
+		}
+		return &t, nil
+	}
+
+	var err error
+	if m.URI, err = field("URI", true); err != nil {
+		return nil, err
+	}
+	if m.Version, err = field("Version", true); err != nil {
+		return nil, err
+	}
+	chainID, err := field("Chain ID", true)
+	if err != nil {
+		return nil, err
+	}
+	if m.ChainID, err = strconv.ParseInt(chainID, 10, 64); err != nil || m.ChainID <= 0 {
+		return nil, fmt.Errorf("%w: invalid Chain ID", ErrInvalidSIWEMessage)
+	}
+	if m.Nonce, err = field("Nonce", true); err != nil {
+		return nil, err
+	}
+	issuedAt, err := field("Issued At", true)
+	if err != nil {
+		return nil, err
+	}
+	issued, err := timestamp("Issued At", issuedAt)
+	if err != nil {
+		return nil, err
+	}
+	m.IssuedAt = *issued
+
+	value, _ := field("Expiration Time", false)
+	if m.ExpirationTime, err = timestamp("Expiration Time", value); err != nil {
+		return nil, err
+	}
+	value, _ = field("Not Before", false)
+	if m.NotBefore, err = timestamp("Not Before", value); err != nil {
+		return nil, err
+	}
+	m.RequestID, _ = field("Request ID", false)
+
+	if len(rest) > 0 && rest[0] == "Resources:" {
+		for _, line := range rest[1:] {
+			resource, ok := strings.CutPrefix(line, "- ")
+			if !ok {
+				return nil, fmt.Errorf("%w: invalid resource line", ErrInvalidSIWEMessage)
+			}
+			m.Resources = append(m.Resources, resource)
+		}
+		rest = nil
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("%w: unexpected line %q", ErrInvalidSIWEMessage, rest[0])
+	}
+
+	if _, err := url.Parse(m.URI); err != nil || m.URI == "" {
+		return nil, fmt.Errorf("%w: invalid URI", ErrInvalidSIWEMessage)
+	}
+	return m, nil
+}
+
+// RecoverWalletAddress returns the address whose key made signature, an
+// EIP-191 personal_sign signature over message
+func RecoverWalletAddress(message, signature string) (common.Address, error) {
+	sig, err := hexutil.Decode(signature)
+	if err != nil || len(sig) != ethcrypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("%w: signature must be 65 hex-encoded bytes", ErrWalletSignature)
+	}
+	// Wallets set V to 27/28; the recovery id is 0/1
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	if sig[64] > 1 {
+		return common.Address{}, fmt.Errorf("%w: invalid recovery id", ErrWalletSignature)
+	}
+
+	pub, err := ethcrypto.SigToPub(accounts.TextHash([]byte(message)), sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("%w: %v", ErrWalletSignature, err)
+	}
+	return ethcrypto.PubkeyToAddress(*pub), nil
+}
+
+// WalletLinker issues nonces for wallet-linking messages and checks the
+// signed messages that come back
+type WalletLinker struct {
+	db     ipfsdb.Store
+	config SIWEConfig
+	mu     sync.Mutex // serializes issuing and consuming nonces
+}
+
+// NewWalletLinker creates a wallet linker binding messages to config
+func NewWalletLinker(db ipfsdb.Store, config SIWEConfig) *WalletLinker {
+	return &WalletLinker{db: db, config: config}
+}
+
+// walletNonceKey returns the store key holding an issued nonce
+func walletNonceKey(nonce string) string {
+	return fmt.Sprintf("/siwe/nonce/%s", nonce)
+}
+
+// Origin returns the domain and URI messages received on r must carry
+func (w *WalletLinker) Origin(r *http.Request) (domain, uri string) {
+	domain = w.config.Domain
+	if domain == "" {
+		domain = r.Host
+	}
+	uri = w.config.URI
+	if uri == "" {
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		uri = scheme + "://" + domain
+	}
+	return domain, uri
+}
+
+// IssueNonce stores a new single-use nonce for userID. Expired nonces are
+// removed on the way, so unused ones do not pile up in the store.
+func (w *WalletLinker) IssueNonce(userID string) (*models.WalletNonce, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	nonce := &models.WalletNonce{
+		Nonce:     hex.EncodeToString(raw),
+		UserID:    userID,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(walletNonceTTL),
+	}
+	if err := w.pruneNoncesLocked(now); err != nil {
+		return nil, err
+	}
+	if err := w.db.Save(walletNonceKey(nonce.Nonce), nonce); err != nil {
+		return nil, fmt.Errorf("failed to save nonce: %w", err)
+	}
+	return nonce, nil
+}
+
+// pruneNoncesLocked deletes the nonces that expired by now. Caller must
+// hold w.mu.
+func (w *WalletLinker) pruneNoncesLocked(now time.Time) error {
+	for _, key := range w.db.ListKeys() {
+		if !strings.HasPrefix(key, walletNonceKey("")) {
+			continue
+		}
+		val, _ := w.db.Get(key)
+		if nonce, ok := val.(*models.WalletNonce); ok && now.Before(nonce.ExpiresAt) {
+			continue
+		}
+		if err := w.db.Delete(key); err != nil {
+			return fmt.Errorf("failed to delete expired nonce: %w", err)
+		}
+	}
+	return nil
+}
+
+// consumeNonce deletes nonce if it was issued to userID, and fails unless
+// it was also still valid at now. The lookup and the delete happen under
+// w.mu, so a nonce is consumed by one request only.
+func (w *WalletLinker) consumeNonce(userID, nonce string, now time.Time) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	val, ok := w.db.Get(walletNonceKey(nonce))
+	issued, isNonce := val.(*models.WalletNonce)
+	if !ok || !isNonce || issued.UserID != userID {
+		return ErrInvalidWalletNonce
+	}
+	if err := w.db.Delete(walletNonceKey(nonce)); err != nil {
+		return fmt.Errorf("failed to consume nonce: %w", err)
+	}
+	if !now.Before(issued.ExpiresAt) {
+		return ErrInvalidWalletNonce
+	}
+	return nil
+}
+
+// Message prepares the message for nonce that the user is asked to sign.
+// address may be empty when the client fills it in.
+func (w *WalletLinker) Message(r *http.Request, nonce *models.WalletNonce, address string, chainID int64) *SIWEMessage {
+	domain, uri := w.Origin(r)
+	if common.IsHexAddress(address) {
+		address = common.HexToAddress(address).Hex()
+	}
+	if chainID <= 0 {
+		chainID = 1
+	}
+	expires := nonce.ExpiresAt
+	return &SIWEMessage{
+		Domain:         domain,
+		Address:        address,
+		Statement:      siweStatement,
+		URI:            uri,
+		Version:        siweVersion,
+		ChainID:        chainID,
+		Nonce:          nonce.Nonce,
+		IssuedAt:       nonce.IssuedAt,
+		ExpirationTime: &expires,
+	}
+}
+
+// Verify checks a signed wallet-linking message sent by userID on r and
+// returns the wallet address it proves. The nonce is consumed whether or
+// not the signature checks out.
+func (w *WalletLinker) Verify(r *http.Request, userID, message, signature string) (common.Address, *SIWEMessage, error) {
+	m, err := ParseSIWEMessage(message)
+	if err != nil {
+		return common.Address{}, nil, err
+	}
+
+	domain, uri := w.Origin(r)
+	if m.Domain != domain {
+		return common.Address{}, m, fmt.Errorf("%w: domain %q does not match %q", ErrInvalidSIWEMessage, m.Domain, domain)
+	}
+	if m.URI != uri {
+		return common.Address{}, m, fmt.Errorf("%w: URI %q does not match %q", ErrInvalidSIWEMessage, m.URI, uri)
+	}
+	if m.Version != siweVersion {
+		return common.Address{}, m, fmt.Errorf("%w: unsupported version %q", ErrInvalidSIWEMessage, m.Version)
+	}
+	if !common.IsHexAddress(m.Address) || common.HexToAddress(m.Address).Hex() != m.Address {
+		return common.Address{}, m, fmt.Errorf("%w: address must be EIP-55 checksummed", ErrInvalidSIWEMessage)
+	}
+
+	now := time.Now()
+	if m.IssuedAt.After(now.Add(siweClockSkew)) {
+		return common.Address{}, m, fmt.Errorf("%w: issued in the future", ErrInvalidSIWEMessage)
+	}
+	if m.ExpirationTime != nil && !now.Before(*m.ExpirationTime) {
+		return common.Address{}, m, fmt.Errorf("%w: message has expired", ErrInvalidSIWEMessage)
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return common.Address{}, m, fmt.Errorf("%w: message is not valid yet", ErrInvalidSIWEMessage)
+	}
+
+	if err := w.consumeNonce(userID, m.Nonce, now); err != nil {
+		return common.Address{}, m, err
+	}
+
+	address, err := RecoverWalletAddress(message, signature)
+	if err != nil {
+		return common.Address{}, m, err
+	}
+	if address.Hex() != m.Address {
+		return common.Address{}, m, fmt.Errorf("%w: signed by %s", ErrWalletSignature, address.Hex())
+	}
+	return address, m, nil
+}
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethcrypto "github.com/ethereum/go-ethereum/crypto"
+
+	"yourproject/internal/ipfsdb"
+	"yourproject/internal/models"
+)
+
+// siweExample is the example message of EIP-4361
+const siweExample = `service.invalid wants you to sign in with your Ethereum account:
+0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2
+
+I accept the ServiceOrg Terms of Service: https://service.invalid/tos
+
+URI: https://service.invalid/login
+Version: 1
+Chain ID: 1
+Nonce: 32891756
+Issued At: 2021-09-30T16:25:24Z
+Resources:
+- ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/
+- https://example.com/my-web2-claim.json`
+
+// testWalletKey is a fixed secp256k1 key; its address is
+// 0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266
+const testWalletKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+// personalSign signs message as a wallet does for personal_sign, with V set
+// to 27 or 28
+func personalSign(t *testing.T, keyHex, message string) string {
+	t.Helper()
+	key, err := ethcrypto.HexToECDSA(keyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := ethcrypto.Sign(accounts.TextHash([]byte(message)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig[64] += 27
+	return hexutil.Encode(sig)
+}
+
+func TestParseSIWEMessage(t *testing.T) {
+	m, err := ParseSIWEMessage(siweExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issued := time.Date(2021, 9, 30, 16, 25, 24, 0, time.UTC)
+	if m.Scheme != "" || m.Domain != "service.invalid" || m.Address != "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2" ||
+		m.Statement != "I accept the ServiceOrg Terms of Service: https://service.invalid/tos" ||
+		m.URI != "https://service.invalid/login" || m.Version != "1" || m.ChainID != 1 ||
+		m.Nonce != "32891756" || !m.IssuedAt.Equal(issued) || m.ExpirationTime != nil || len(m.Resources) != 2 ||
+		m.Resources[0] != "ipfs://bafybeiemxf5abjwjbikoz4mc3a3dla6ual3jsgpdr4cjr3oz3evfyavhwq/" {
+		t.Errorf("ParseSIWEMessage = %+v", m)
+	}
+	if got := m.String(); got != siweExample {
+		t.Errorf("String() does not give back the message:\n%s", got)
+	}
+
+	// Scheme, no statement and every optional field
+	full := "https://example.com:8443 wants you to sign in with your Ethereum account:\n" +
+		"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266\n\n\n" +
+		"URI: https://example.com:8443/wallet\n" +
+		"Version: 1\n" +
+		"Chain ID: 11155111\n" +
+		"Nonce: 5d41402abc4b2a76b9719d911017c592\n" +
+		"Issued At: 2025-01-01T12:00:00Z\n" +
+		"Expiration Time: 2025-01-01T12:10:00Z\n" +
+		"Not Before: 2025-01-01T11:59:00Z\n" +
+		"Request ID: link-1"
+	m, err = ParseSIWEMessage(full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Scheme != "https" || m.Domain != "example.com:8443" || m.Statement != "" || m.ChainID != 11155111 ||
+		m.ExpirationTime == nil || m.NotBefore == nil || m.RequestID != "link-1" {
+		t.Errorf("ParseSIWEMessage = %+v", m)
+	}
+	if got := m.String(); got != full {
+		t.Errorf("String() does not give back the message:\n%s", got)
+	}
+
+	for name, text := range map[string]string{
+		"empty":         "",
+		"no header":     "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n\n\nURI: a\nVersion: 1\nChain ID: 1\nNonce: 1\nIssued At: 2021-09-30T16:25:24Z",
+		"bad chain":     strings.Replace(siweExample, "Chain ID: 1", "Chain ID: one", 1),
+		"bad timestamp": strings.Replace(siweExample, "2021-09-30T16:25:24Z", "yesterday", 1),
+		"no nonce":      strings.Replace(siweExample, "Nonce: 32891756\n", "", 1),
+		"out of order":  strings.Replace(siweExample, "Version: 1\nChain ID: 1", "Chain ID: 1\nVersion: 1", 1),
+		"trailing line": siweExample + "\nSigned: yes",
+	} {
+		if _, err := ParseSIWEMessage(text); !errors.Is(err, ErrInvalidSIWEMessage) {
+			t.Errorf("%s: %v, want ErrInvalidSIWEMessage", name, err)
+		}
+	}
+}
+
+func TestRecoverWalletAddress(t *testing.T) {
+	want := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	signature := personalSign(t, testWalletKey, siweExample)
+
+	got, err := RecoverWalletAddress(siweExample, signature)
+	if err != nil || got != want {
+		t.Fatalf("RecoverWalletAddress = %s, %v; want %s", got.Hex(), err, want.Hex())
+	}
+
+	// V as the recovery id itself, as some wallets send it
+	sig := hexutil.MustDecode(signature)
+	sig[64] -= 27
+	if got, err := RecoverWalletAddress(siweExample, hexutil.Encode(sig)); err != nil || got != want {
+		t.Errorf("with V = %d: %s, %v", sig[64], got.Hex(), err)
+	}
+
+	if got, err := RecoverWalletAddress(siweExample+" ", signature); err == nil && got == want {
+		t.Error("signature recovers the signer over another message")
+	}
+	sig[64] = 29
+	for _, bad := range []string{"", "0x1234", signature[:len(signature)-2], hexutil.Encode(sig)} {
+		if _, err := RecoverWalletAddress(siweExample, bad); !errors.Is(err, ErrWalletSignature) {
+			t.Errorf("signature %q: %v, want ErrWalletSignature", bad, err)
+		}
+	}
+}
+
+func TestWalletLinkerVerify(t *testing.T) {
+	db := ipfsdb.New()
+	linker := NewWalletLinker(db, SIWEConfig{Domain: "penguin.example", URI: "https://penguin.example"})
+	r := httptest.NewRequest(http.MethodPost, "/me/wallet", nil)
+	address := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+
+	sign := func(userID string) (string, string) {
+		nonce, err := linker.IssueNonce(userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := linker.Message(r, nonce, address.Hex(), 1).String()
+		return message, personalSign(t, testWalletKey, message)
+	}
+
+	message, signature := sign("alice")
+	got, _, err := linker.Verify(r, "alice", message, signature)
+	if err != nil || got != address {
+		t.Fatalf("Verify = %s, %v", got.Hex(), err)
+	}
+	if _, _, err := linker.Verify(r, "alice", message, signature); !errors.Is(err, ErrInvalidWalletNonce) {
+		t.Errorf("replayed message: %v, want ErrInvalidWalletNonce", err)
+	}
+
+	message, signature = sign("alice")
+	if _, _, err := linker.Verify(r, "bob", message, signature); !errors.Is(err, ErrInvalidWalletNonce) {
+		t.Errorf("another user's nonce: %v, want ErrInvalidWalletNonce", err)
+	}
+
+	// Concurrent requests with one message consume its nonce once
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	linked := 0
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := linker.Verify(r, "alice", message, signature); err == nil {
+				mu.Lock()
+				linked++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if linked != 1 {
+		t.Errorf("message verified %d times, want once", linked)
+	}
+}
+
+func TestWalletNoncesExpire(t *testing.T) {
+	db := ipfsdb.New()
+	linker := NewWalletLinker(db, SIWEConfig{})
+	past := time.Now().Add(-time.Hour).UTC()
+	expired := &models.WalletNonce{Nonce: "expired", UserID: "alice", IssuedAt: past, ExpiresAt: past.Add(walletNonceTTL)}
+	if err := db.Save(walletNonceKey(expired.Nonce), expired); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := linker.consumeNonce("alice", "expired", time.Now()); !errors.Is(err, ErrInvalidWalletNonce) {
+		t.Errorf("expired nonce: %v, want ErrInvalidWalletNonce", err)
+	}
+
+	// Unused nonces are removed once they expire
+	if err := db.Save(walletNonceKey(expired.Nonce), expired); err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := linker.IssueNonce("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.Get(walletNonceKey(expired.Nonce)); ok {
+		t.Error("expired nonce still stored after issuing another")
+	}
+	if _, ok := db.Get(walletNonceKey(fresh.Nonce)); !ok {
+		t.Error("new nonce not stored")
+	}
+	if _, err := linker.IssueNonce("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := db.Get(walletNonceKey(fresh.Nonce)); !ok {
+		t.Error("unexpired nonce removed")
+	}
+}
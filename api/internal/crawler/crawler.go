@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"yourproject/internal/ipfsdb"
 	"yourproject/internal/models"
 
 	"github.com/corona10/goimagehash"
@@ -31,7 +32,7 @@ type Crawler struct {
 	cancel           context.CancelFunc
 }
 
-// ArtworkStore interface for artwork database operations
+// ArtworkStore interface for artwork database operations (satisfied by ipfsdb.Store)
 type ArtworkStore interface {
 	GetAllArtworks(ctx context.Context) ([]*models.Artwork, error)
 	GetArtworkByID(ctx context.Context, id string) (*models.Artwork, error)
@@ -96,7 +97,7 @@ func (nm *NotificationManager) GetUnreadCount(userID string) int {
 }
 
 // NewCrawler creates a new crawler instance
-func NewCrawler(store ArtworkStore, threshold float64, interval time.Duration) *Crawler {
+func NewCrawler(store ipfsdb.Store, threshold float64, interval time.Duration) *Crawler {
 	return &Crawler{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
@@ -0,0 +1,154 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+// Node signatures are EdDSA (Ed25519) JWS per RFC 7515. Detached signatures
+// use the unencoded payload option of RFC 7797 ("b64": false, listed in
+// "crit"), so verifiers hash the exact payload bytes they already hold.
+// Signing and verification go through lestrrat-go/jwx, so anything that
+// speaks JWS can check them.
+
+// JWK returns the signer's public key as a JWK with kid and alg set
+func (s *Signer) JWK() (jwk.Key, error) {
+	return PublicJWK(s.publicKey)
+}
+
+// PublicJWK returns an Ed25519 public key as a JWK, with kid set to the
+// base64url key and alg to EdDSA
+func PublicJWK(pub ed25519.PublicKey) (jwk.Key, error) {
+	key, err := jwk.FromRaw(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build JWK: %w", err)
+	}
+	if err := key.Set(jwk.KeyIDKey, base64.RawURLEncoding.EncodeToString(pub)); err != nil {
+		return nil, err
+	}
+	if err := key.Set(jwk.AlgorithmKey, jwa.EdDSA); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// signJWS signs payload, detached with "b64": false when detached is set
+func (s *Signer) signJWS(payload []byte, detached, asJSON bool) ([]byte, error) {
+	headers := jws.NewHeaders()
+	if err := headers.Set(jws.KeyIDKey, s.keyID); err != nil {
+		return nil, err
+	}
+
+	options := []jws.SignOption{}
+	if detached {
+		if err := headers.Set("b64", false); err != nil {
+			return nil, err
+		}
+		if err := headers.Set(jws.CriticalKey, []string{"b64"}); err != nil {
+			return nil, err
+		}
+		options = append(options, jws.WithDetachedPayload(payload))
+		payload = nil
+	}
+	if asJSON {
+		options = append(options, jws.WithJSON())
+	}
+	options = append(options, jws.WithKey(jwa.EdDSA, s.privateKey, jws.WithProtectedHeaders(headers)))
+
+	signed, err := jws.Sign(payload, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign JWS: %w", err)
+	}
+
+	// jwx still writes the payload member for detached JSON serialization;
+	// a detached JWS must leave it out (RFC 7515 appendix F)
+	if detached && asJSON {
+		var message map[string]json.RawMessage
+		if err := json.Unmarshal(signed, &message); err != nil {
+			return nil, fmt.Errorf("failed to decode JWS JSON: %w", err)
+		}
+		delete(message, "payload")
+		return json.Marshal(message)
+	}
+	return signed, nil
+}
+
+// JWS returns payload signed in JWS compact serialization
+func (s *Signer) JWS(payload []byte) (string, error) {
+	signed, err := s.signJWS(payload, false, false)
+	return string(signed), err
+}
+
+// JWSDetached returns a detached JWS in compact serialization
+// ("<header>..<signature>") over the unencoded payload (RFC 7797)
+func (s *Signer) JWSDetached(payload []byte) (string, error) {
+	signed, err := s.signJWS(payload, true, false)
+	return string(signed), err
+}
+
+// JWSJSON returns payload signed in JWS JSON serialization. With detached
+// set the payload is left out and signed unencoded (RFC 7797).
+func (s *Signer) JWSJSON(payload []byte, detached bool) ([]byte, error) {
+	return s.signJWS(payload, detached, true)
+}
+
+// VerifyDetached checks a detached JWS over payload against the signer's
+// own key
+func (s *Signer) VerifyDetached(signature string, payload []byte) bool {
+	_, err := VerifyJWS([]byte(signature), payload, s.publicKey)
+	return err == nil
+}
+
+// VerifyJWS verifies a compact or JSON serialized EdDSA JWS and returns its
+// payload. detachedPayload must be set for detached signatures and nil
+// otherwise. key is an ed25519.PublicKey or a jwk.Key.
+func VerifyJWS(signature, detachedPayload []byte, key interface{}) ([]byte, error) {
+	switch k := key.(type) {
+	case ed25519.PublicKey:
+		if len(k) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key")
+		}
+	case jwk.Key:
+		if k.KeyType() != jwa.OKP {
+			return nil, fmt.Errorf("unsupported JWK key type %s, expected OKP (Ed25519)", k.KeyType())
+		}
+	default:
+		return nil, fmt.Errorf("unsupported verification key type %T", key)
+	}
+
+	options := []jws.VerifyOption{jws.WithKey(jwa.EdDSA, key)}
+	if detachedPayload != nil {
+		options = append(options, jws.WithDetachedPayload(detachedPayload))
+	}
+	payload, err := jws.Verify(signature, options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	return payload, nil
+}
+
+// VerifyJWSWithJWK is VerifyJWS with the key given as a JWK JSON document
+func VerifyJWSWithJWK(signature, detachedPayload, jwkJSON []byte) ([]byte, error) {
+	key, err := jwk.ParseKey(jwkJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK: %w", err)
+	}
+	return VerifyJWS(signature, detachedPayload, key)
+}
+
+// ParseEd25519PublicKey decodes a base64 (standard or URL-safe, padded or
+// not) Ed25519 public key
+func ParseEd25519PublicKey(encoded string) (ed25519.PublicKey, error) {
+	for _, enc := range []*base64.Encoding{base64.RawURLEncoding, base64.URLEncoding, base64.StdEncoding, base64.RawStdEncoding} {
+		if raw, err := enc.DecodeString(encoded); err == nil && len(raw) == ed25519.PublicKeySize {
+			return ed25519.PublicKey(raw), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid Ed25519 public key")
+}
@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jws"
+)
+
+func TestJWSDetached(t *testing.T) {
+	signer, err := NewSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte(`{"cid":"bafyreigh2akiscaildc","links":["a.b","c/d"],"n":1}`)
+	tampered := []byte(`{"cid":"bafyreigh2akiscaildc","links":["a.b","c/d"],"n":2}`)
+
+	signature, err := signer.JWSDetached(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(signature, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		t.Fatalf("JWSDetached = %q, want <header>..<signature>", signature)
+	}
+
+	// Plain jwx, knowing nothing of this package
+	if _, err := jws.Verify([]byte(signature), jws.WithKey(jwa.EdDSA, signer.publicKey), jws.WithDetachedPayload(payload)); err != nil {
+		t.Errorf("jws.Verify: %v", err)
+	}
+	if _, err := jws.Verify([]byte(signature), jws.WithKey(jwa.EdDSA, signer.publicKey), jws.WithDetachedPayload(tampered)); err == nil {
+		t.Error("jws.Verify accepted a tampered payload")
+	}
+
+	// By hand: the RFC 7797 signing input is the encoded header, a dot and
+	// the payload bytes as they are
+	rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var header struct {
+		Alg  string   `json:"alg"`
+		Kid  string   `json:"kid"`
+		B64  *bool    `json:"b64"`
+		Crit []string `json:"crit"`
+	}
+	if err := json.Unmarshal(rawHeader, &header); err != nil {
+		t.Fatal(err)
+	}
+	if header.Alg != "EdDSA" || header.Kid != signer.KeyID() || header.B64 == nil || *header.B64 || len(header.Crit) != 1 || header.Crit[0] != "b64" {
+		t.Errorf("header %s, want alg EdDSA, the signer's kid, b64 false and crit [b64]", rawHeader)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ed25519.Verify(signer.publicKey, append([]byte(parts[0]+"."), payload...), sig) {
+		t.Error("signature does not cover b64url(header) || '.' || payload")
+	}
+	if ed25519.Verify(signer.publicKey, append([]byte(parts[0]+"."), tampered...), sig) {
+		t.Error("signature covers a tampered payload")
+	}
+
+	// Against the published JWK
+	key, err := signer.JWK()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwkJSON, err := json.Marshal(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyJWSWithJWK([]byte(signature), payload, jwkJSON); err != nil {
+		t.Errorf("VerifyJWSWithJWK: %v", err)
+	}
+	if _, err := VerifyJWSWithJWK([]byte(signature), tampered, jwkJSON); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("VerifyJWSWithJWK of a tampered payload: %v, want ErrBadSignature", err)
+	}
+
+	if !signer.VerifyDetached(signature, payload) || signer.VerifyDetached(signature, tampered) {
+		t.Error("VerifyDetached does not match the payload")
+	}
+	other, err := NewSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.VerifyDetached(signature, payload) {
+		t.Error("signature verifies with another key")
+	}
+}
+
+func TestJWSJSON(t *testing.T) {
+	signer, err := NewSigner()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload := []byte(`{"cid":"bafyreigh2akiscaildc"}`)
+
+	// Detached JSON serialization leaves the payload member out
+	detached, err := signer.JWSJSON(payload, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var message map[string]json.RawMessage
+	if err := json.Unmarshal(detached, &message); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := message["payload"]; ok {
+		t.Errorf("detached JWS JSON has a payload member: %s", detached)
+	}
+	if _, err := VerifyJWS(detached, payload, signer.publicKey); err != nil {
+		t.Errorf("VerifyJWS of detached JSON: %v", err)
+	}
+	if _, err := VerifyJWS(detached, []byte(`{"cid":"bafyother"}`), signer.publicKey); err == nil {
+		t.Error("detached JSON verified a tampered payload")
+	}
+
+	// Attached, the payload comes back from verification
+	attached, err := signer.JWSJSON(payload, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := VerifyJWS(attached, nil, signer.publicKey)
+	if err != nil || string(got) != string(payload) {
+		t.Errorf("VerifyJWS of attached JSON = %q, %v", got, err)
+	}
+	compact, err := signer.JWS(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := jws.Verify([]byte(compact), jws.WithKey(jwa.EdDSA, signer.publicKey)); err != nil || string(got) != string(payload) {
+		t.Errorf("jws.Verify of compact JWS = %q, %v", got, err)
+	}
+}
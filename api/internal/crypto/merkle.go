@@ -0,0 +1,126 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/zeebo/blake3"
+)
+
+// Domain separation prefixes, so a leaf can never be passed off as an
+// inner node (RFC 6962 style)
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// MerkleLeaf hashes leaf data as BLAKE3(0x00 || data)
+func MerkleLeaf(data []byte) [32]byte {
+	h := blake3.New()
+	h.Write([]byte{merkleLeafPrefix})
+	h.Write(data)
+	var out [32]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// merkleNode hashes two children as BLAKE3(0x01 || left || right)
+func merkleNode(left, right [32]byte) [32]byte {
+	h := blake3.New()
+	h.Write([]byte{merkleNodePrefix})
+	h.Write(left[:])
+	h.Write(right[:])
+	var out [32]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+// MerkleStep is one sibling on the path from a leaf to the root. Position
+// says which side the sibling sits on: "left" means hash(sibling, current).
+type MerkleStep struct {
+	Hash     string `json:"hash"`
+	Position string `json:"position"`
+}
+
+// MerkleTree is a binary BLAKE3 Merkle tree. A node without a sibling is
+// carried up to the next level unchanged rather than paired with itself.
+type MerkleTree struct {
+	levels [][][32]byte // levels[0] holds the leaves, the last level the root
+}
+
+// NewMerkleTree builds a tree over already hashed leaves (see MerkleLeaf)
+func NewMerkleTree(leaves [][32]byte) (*MerkleTree, error) {
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("merkle tree needs at least one leaf")
+	}
+
+	level := append([][32]byte(nil), leaves...)
+	t := &MerkleTree{levels: [][][32]byte{level}}
+	for len(level) > 1 {
+		next := make([][32]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, merkleNode(level[i], level[i+1]))
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+	return t, nil
+}
+
+// Root returns the tree root
+func (t *MerkleTree) Root() [32]byte {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// Len returns the number of leaves
+func (t *MerkleTree) Len() int {
+	return len(t.levels[0])
+}
+
+// Proof returns the inclusion proof for the leaf at index
+func (t *MerkleTree) Proof(index int) ([]MerkleStep, error) {
+	if index < 0 || index >= t.Len() {
+		return nil, fmt.Errorf("leaf index %d out of range", index)
+	}
+
+	proof := []MerkleStep{}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			position := "right"
+			if sibling < index {
+				position = "left"
+			}
+			proof = append(proof, MerkleStep{Hash: hex.EncodeToString(level[sibling][:]), Position: position})
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof recomputes the root from a leaf hash and its proof
+func VerifyMerkleProof(leaf [32]byte, proof []MerkleStep, root [32]byte) bool {
+	current := leaf
+	for _, step := range proof {
+		raw, err := hex.DecodeString(step.Hash)
+		if err != nil || len(raw) != 32 {
+			return false
+		}
+		var sibling [32]byte
+		copy(sibling[:], raw)
+
+		switch step.Position {
+		case "left":
+			current = merkleNode(sibling, current)
+		case "right":
+			current = merkleNode(current, sibling)
+		default:
+			return false
+		}
+	}
+	return current == root
+}
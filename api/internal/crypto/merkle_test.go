@@ -0,0 +1,104 @@
+package crypto
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/zeebo/blake3"
+)
+
+// merkleLeaves returns n leaf hashes
+func merkleLeaves(n int) [][32]byte {
+	leaves := make([][32]byte, n)
+	for i := range leaves {
+		leaves[i] = MerkleLeaf([]byte(fmt.Sprintf("bafyleaf%d", i)))
+	}
+	return leaves
+}
+
+func TestMerkleTreeLayout(t *testing.T) {
+	// Recomputed from the documented hashing alone, as an offline verifier
+	// would: BLAKE3(0x00 || data) for leaves, BLAKE3(0x01 || left || right)
+	// for nodes, and an odd node carried up unchanged
+	leaf := func(data string) [32]byte {
+		return blake3.Sum256(append([]byte{0x00}, data...))
+	}
+	node := func(left, right [32]byte) [32]byte {
+		return blake3.Sum256(append(append([]byte{0x01}, left[:]...), right[:]...))
+	}
+	a, b, c := leaf("a"), leaf("b"), leaf("c")
+
+	if MerkleLeaf([]byte("a")) != a {
+		t.Fatal("MerkleLeaf is not BLAKE3(0x00 || data)")
+	}
+	for _, tt := range []struct {
+		leaves [][32]byte
+		root   [32]byte
+	}{
+		{[][32]byte{a}, a},
+		{[][32]byte{a, b}, node(a, b)},
+		{[][32]byte{a, b, c}, node(node(a, b), c)},
+	} {
+		tree, err := NewMerkleTree(tt.leaves)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tree.Root() != tt.root {
+			t.Errorf("root of %d leaves does not match the documented layout", len(tt.leaves))
+		}
+	}
+
+	if _, err := NewMerkleTree(nil); err == nil {
+		t.Error("tree without leaves built")
+	}
+}
+
+func TestMerkleProofs(t *testing.T) {
+	for n := 1; n <= 17; n++ {
+		leaves := merkleLeaves(n)
+		tree, err := NewMerkleTree(leaves)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root := tree.Root()
+
+		for i, leaf := range leaves {
+			proof, err := tree.Proof(i)
+			if err != nil {
+				t.Fatalf("%d leaves: Proof(%d): %v", n, i, err)
+			}
+			if !VerifyMerkleProof(leaf, proof, root) {
+				t.Errorf("%d leaves: proof of leaf %d does not verify", n, i)
+			}
+
+			// Another leaf, or the raw data instead of its leaf hash, does
+			// not verify with this proof
+			wrong := MerkleLeaf([]byte("bafynotinthetree"))
+			if n > 1 {
+				wrong = leaves[(i+1)%n]
+			}
+			if VerifyMerkleProof(wrong, proof, root) {
+				t.Errorf("%d leaves: proof of leaf %d verifies another leaf", n, i)
+			}
+			if VerifyMerkleProof(MerkleLeaf([]byte(fmt.Sprintf("bafyleaf%d", i+100))), proof, root) {
+				t.Errorf("%d leaves: proof of leaf %d verifies a leaf outside the tree", n, i)
+			}
+
+			if len(proof) > 0 {
+				flipped := append([]MerkleStep(nil), proof...)
+				if flipped[0].Position == "left" {
+					flipped[0].Position = "right"
+				} else {
+					flipped[0].Position = "left"
+				}
+				if VerifyMerkleProof(leaf, flipped, root) {
+					t.Errorf("%d leaves: proof of leaf %d verifies with a sibling on the wrong side", n, i)
+				}
+			}
+		}
+
+		if _, err := tree.Proof(n); err == nil {
+			t.Errorf("%d leaves: proof of leaf %d built", n, n)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+
+	"github.com/zeebo/blake3"
+)
+
+// The noise pattern is a fragile, per-artwork layer written into the least
+// significant bit of every opaque pixel's colour channels. It is seeded by a
+// random per-artwork secret, which is stored sealed with the platform
+// watermark key next to the artwork, so verification can regenerate exactly
+// the pattern that was written. Any edit to the pixels (re-encoding,
+// resizing, retouching) breaks the match, complementing the robust watermark
+// that survives those edits.
+const (
+	NoiseSeedSize = 32
+
+	// NoiseMatchThreshold is the fraction of pattern bits that must match for
+	// the noise pattern to be considered intact. Unrelated images match about
+	// half of the bits.
+	NoiseMatchThreshold = 0.99
+)
+
+// NoisePattern represents a unique noise pattern for watermarking. Pattern
+// holds one byte per RGBA channel, of which only the low bit is used.
+type NoisePattern struct {
+	Pattern   []byte
+	Signature string
+	Width     int
+	Height    int
+}
+
+// NewNoiseSeed returns a random per-artwork noise seed
+func NewNoiseSeed() ([]byte, error) {
+	seed := make([]byte, NoiseSeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, fmt.Errorf("failed to generate noise seed: %w", err)
+	}
+	return seed, nil
+}
+
+// GenerateNoisePattern derives the noise pattern for an image of the given
+// size from a seed. Non-image content uses 0x0, which yields an empty
+// pattern but still a signature committing to the seed.
+func GenerateNoisePattern(seed []byte, width, height int) (*NoisePattern, error) {
+	if len(seed) != NoiseSeedSize {
+		return nil, fmt.Errorf("noise seed must be %d bytes, got %d", NoiseSeedSize, len(seed))
+	}
+	if width < 0 || height < 0 {
+		return nil, fmt.Errorf("invalid noise pattern size %dx%d", width, height)
+	}
+
+	var dims [16]byte
+	binary.BigEndian.PutUint64(dims[:8], uint64(width))
+	binary.BigEndian.PutUint64(dims[8:], uint64(height))
+
+	h, _ := blake3.NewKeyed(seed)
+	h.Write([]byte("penguin-noise-pattern\x00"))
+	h.Write(dims[:])
+	pattern := make([]byte, width*height*4)
+	io.ReadFull(h.Digest(), pattern)
+
+	// The signature is public (it is registered on-chain), so it is a keyed
+	// hash that commits to the seed and size without revealing either
+	sig, _ := blake3.NewKeyed(seed)
+	sig.Write([]byte("penguin-noise-signature\x00"))
+	sig.Write(dims[:])
+
+	return &NoisePattern{
+		Pattern:   pattern,
+		Signature: hex.EncodeToString(sig.Sum(nil)),
+		Width:     width,
+		Height:    height,
+	}, nil
+}
+
+// ApplyNoisePattern writes pattern into the low bit of the colour channels of
+// every opaque pixel of img. Translucent pixels are left alone because their
+// stored values change when converted for encoding.
+func ApplyNoisePattern(img image.Image, pattern *NoisePattern) (*image.RGBA, error) {
+	bounds := img.Bounds()
+	if bounds.Dx() != pattern.Width || bounds.Dy() != pattern.Height {
+		return nil, fmt.Errorf("noise pattern is %dx%d, image is %dx%d", pattern.Width, pattern.Height, bounds.Dx(), bounds.Dy())
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, pattern.Width, pattern.Height))
+	draw.Draw(out, out.Bounds(), img, bounds.Min, draw.Src)
+	for i := 0; i < len(out.Pix); i += 4 {
+		if out.Pix[i+3] != 0xff {
+			continue
+		}
+		for c := 0; c < 3; c++ {
+			out.Pix[i+c] = out.Pix[i+c]&^1 | pattern.Pattern[i+c]&1
+		}
+	}
+	return out, nil
+}
+
+// MarkImage applies the robust watermark carrying artworkID, then the noise
+// pattern derived from seed. The noise pattern goes on last so it is written
+// intact.
+func MarkImage(img image.Image, key []byte, artworkID string, seed []byte) (*image.RGBA, *NoisePattern, error) {
+	bounds := img.Bounds()
+	pattern, err := GenerateNoisePattern(seed, bounds.Dx(), bounds.Dy())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate noise pattern: %w", err)
+	}
+
+	marked, err := ApplyWatermark(img, key, artworkID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to apply watermark: %w", err)
+	}
+	if marked, err = ApplyNoisePattern(marked, pattern); err != nil {
+		return nil, nil, fmt.Errorf("failed to apply noise pattern: %w", err)
+	}
+	return marked, pattern, nil
+}
+
+// DetectWatermark reports whether img still carries the expected noise
+// pattern, together with the fraction of matching bits in [0, 1]. threshold
+// is a fraction in the same range, normally NoiseMatchThreshold.
+func DetectWatermark(img image.Image, expectedPattern *NoisePattern, threshold float64) (bool, float64) {
+	if expectedPattern == nil {
+		return false, 0
+	}
+	bounds := img.Bounds()
+	if bounds.Dx() != expectedPattern.Width || bounds.Dy() != expectedPattern.Height {
+		return false, 0
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	matches, total := 0, 0
+	for i := 0; i < len(rgba.Pix); i += 4 {
+		if rgba.Pix[i+3] != 0xff {
+			continue
+		}
+		for c := 0; c < 3; c++ {
+			if rgba.Pix[i+c]&1 == expectedPattern.Pattern[i+c]&1 {
+				matches++
+			}
+			total++
+		}
+	}
+	if total == 0 {
+		return false, 0
+	}
+
+	confidence := float64(matches) / float64(total)
+	return confidence >= threshold, confidence
+}
+
+// noiseSealKey derives the AES key protecting noise seeds from the platform
+// watermark key
+func noiseSealKey(key []byte) []byte {
+	out := make([]byte, 32)
+	blake3.DeriveKey("penguin noise seed sealing key v1", key, out)
+	return out
+}
+
+// SealNoiseSeed encrypts seed with AES-256-GCM under a key derived from the
+// platform watermark key, bound to artworkID, and returns it base64 encoded
+func SealNoiseSeed(key []byte, artworkID string, seed []byte) (string, error) {
+	block, err := aes.NewCipher(noiseSealKey(key))
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, seed, []byte(artworkID))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenNoiseSeed decrypts a seed sealed by SealNoiseSeed for artworkID
+func OpenNoiseSeed(key []byte, artworkID, sealed string) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sealed noise seed: %w", err)
+	}
+	block, err := aes.NewCipher(noiseSealKey(key))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return nil, errors.New("sealed noise seed is too short")
+	}
+
+	seed, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], []byte(artworkID))
+	if err != nil {
+		return nil, errors.New("failed to unseal noise seed (wrong key or artwork)")
+	}
+	return seed, nil
+}
@@ -0,0 +1,36 @@
+package crypto
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/corona10/goimagehash"
+)
+
+// PerceptualHashBits is the size of a perceptual hash, and so the largest
+// possible distance between two of them
+const PerceptualHashBits = 64
+
+// PerceptualHash returns the 64-bit DCT perceptual hash of img in its string
+// form ("p:<hex>"), suitable for storing next to an artwork
+func PerceptualHash(img image.Image) (string, error) {
+	hash, err := goimagehash.PerceptionHash(img)
+	if err != nil {
+		return "", fmt.Errorf("failed to compute perceptual hash: %w", err)
+	}
+	return hash.ToString(), nil
+}
+
+// PerceptualDistance returns the Hamming distance between two hashes from
+// PerceptualHash
+func PerceptualDistance(a, b string) (int, error) {
+	ha, err := goimagehash.ImageHashFromString(a)
+	if err != nil {
+		return 0, fmt.Errorf("invalid perceptual hash %q: %w", a, err)
+	}
+	hb, err := goimagehash.ImageHashFromString(b)
+	if err != nil {
+		return 0, fmt.Errorf("invalid perceptual hash %q: %w", b, err)
+	}
+	return ha.Distance(hb)
+}
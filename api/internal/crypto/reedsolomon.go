@@ -0,0 +1,195 @@
+package crypto
+
+import "errors"
+
+// errTooManyErrors is returned when a Reed-Solomon codeword holds more byte
+// errors than its parity can correct
+var errTooManyErrors = errors.New("reed-solomon: too many errors")
+
+// GF(2^8) tables for the primitive polynomial x^8+x^4+x^3+x^2+1 (0x11d)
+var gfExp, gfLog = func() ([512]byte, [256]byte) {
+	var exp [512]byte
+	var log [256]byte
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	for i := 255; i < 512; i++ {
+		exp[i] = exp[i-255]
+	}
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// gfPow returns alpha^n
+func gfPow(n int) byte {
+	n %= 255
+	if n < 0 {
+		n += 255
+	}
+	return gfExp[n]
+}
+
+// rsGenerator returns the generator polynomial (x-a^0)...(x-a^(nsym-1)),
+// highest degree first
+func rsGenerator(nsym int) []byte {
+	g := []byte{1}
+	for i := 0; i < nsym; i++ {
+		next := make([]byte, len(g)+1)
+		for j, c := range g {
+			next[j] ^= c
+			next[j+1] ^= gfMul(c, gfPow(i))
+		}
+		g = next
+	}
+	return g
+}
+
+// rsEncode appends nsym parity bytes to data
+func rsEncode(data []byte, nsym int) []byte {
+	gen := rsGenerator(nsym)
+	out := make([]byte, len(data)+nsym)
+	copy(out, data)
+	for i := 0; i < len(data); i++ {
+		coef := out[i]
+		if coef == 0 {
+			continue
+		}
+		for j := 1; j < len(gen); j++ {
+			out[i+j] ^= gfMul(gen[j], coef)
+		}
+	}
+	copy(out, data)
+	return out
+}
+
+// rsDecode corrects up to nsym/2 byte errors in codeword and returns the data
+// part together with the number of corrected bytes
+func rsDecode(codeword []byte, nsym int) ([]byte, int, error) {
+	n := len(codeword)
+	msg := append([]byte(nil), codeword...)
+
+	// Syndromes S_i = C(a^i)
+	synd := make([]byte, nsym)
+	clean := true
+	for i := 0; i < nsym; i++ {
+		var s byte
+		for _, c := range msg {
+			s = gfMul(s, gfPow(i)) ^ c
+		}
+		synd[i] = s
+		if s != 0 {
+			clean = false
+		}
+	}
+	if clean {
+		return msg[:n-nsym], 0, nil
+	}
+
+	// Berlekamp-Massey: error locator sigma, lowest degree first
+	sigma := []byte{1}
+	prev := []byte{1}
+	l, m := 0, 1
+	var b byte = 1
+	for k := 0; k < nsym; k++ {
+		d := synd[k]
+		for i := 1; i <= l && i < len(sigma); i++ {
+			d ^= gfMul(sigma[i], synd[k-i])
+		}
+		if d == 0 {
+			m++
+			continue
+		}
+		coef := gfDiv(d, b)
+		next := make([]byte, max(len(sigma), len(prev)+m))
+		copy(next, sigma)
+		for i, c := range prev {
+			next[i+m] ^= gfMul(coef, c)
+		}
+		if 2*l <= k {
+			prev = sigma
+			l = k + 1 - l
+			b = d
+			m = 1
+		} else {
+			m++
+		}
+		sigma = next
+	}
+	for len(sigma) > 1 && sigma[len(sigma)-1] == 0 {
+		sigma = sigma[:len(sigma)-1]
+	}
+	if l > nsym/2 || len(sigma)-1 != l {
+		return nil, 0, errTooManyErrors
+	}
+
+	// Chien search: position p (from the end) is in error when sigma(a^-p) = 0
+	positions := []int{}
+	for p := 0; p < n; p++ {
+		var v byte
+		xinv := gfPow(-p)
+		for i := len(sigma) - 1; i >= 0; i-- {
+			v = gfMul(v, xinv) ^ sigma[i]
+		}
+		if v == 0 {
+			positions = append(positions, p)
+		}
+	}
+	if len(positions) != l {
+		return nil, 0, errTooManyErrors
+	}
+
+	// Forney: omega = S(x)*sigma(x) mod x^nsym
+	omega := make([]byte, nsym)
+	for i := 0; i < nsym; i++ {
+		for j := 0; j <= i && j < len(sigma); j++ {
+			omega[i] ^= gfMul(sigma[j], synd[i-j])
+		}
+	}
+	for _, p := range positions {
+		xinv := gfPow(-p)
+		var num byte
+		for i := len(omega) - 1; i >= 0; i-- {
+			num = gfMul(num, xinv) ^ omega[i]
+		}
+		// sigma'(x) keeps only the odd-degree terms
+		var den byte
+		for i := 1; i < len(sigma); i += 2 {
+			den ^= gfMul(sigma[i], gfPow(-p*(i-1)))
+		}
+		if den == 0 {
+			return nil, 0, errTooManyErrors
+		}
+		// With first consecutive root a^0 the magnitude is X * omega/sigma'
+		msg[n-1-p] ^= gfMul(gfPow(p), gfDiv(num, den))
+	}
+
+	for i := 0; i < nsym; i++ {
+		var s byte
+		for _, c := range msg {
+			s = gfMul(s, gfPow(i)) ^ c
+		}
+		if s != 0 {
+			return nil, 0, errTooManyErrors
+		}
+	}
+	return msg[:n-nsym], l, nil
+}
@@ -0,0 +1,34 @@
+package crypto
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrBadSignature is returned when a detached signature does not verify
+var ErrBadSignature = errors.New("signature does not verify")
+
+// SignEd25519 signs message with an Ed25519 private key and returns the
+// base64 encoded signature
+func SignEd25519(privateKey ed25519.PrivateKey, message []byte) string {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, message))
+}
+
+// VerifyEd25519 checks a base64 Ed25519 signature over message against a
+// base64 public key, as published on models.User
+func VerifyEd25519(publicKey string, message []byte, signature string) error {
+	pub, err := base64.StdEncoding.DecodeString(publicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid Ed25519 public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid Ed25519 signature encoding")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pub), message, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
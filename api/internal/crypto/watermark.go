@@ -0,0 +1,525 @@
+package crypto
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/zeebo/blake3"
+)
+
+// The robust watermark spreads a Reed-Solomon protected payload over a grid
+// of cells that is tiled across the whole image. Each cell carries one ±1
+// chip as a smooth, zero-mean cos·cos bump added to luminance, which sits in
+// the low/mid frequencies JPEG keeps. Tiling makes any crop that still holds
+// a tile's worth of pixels decodable, and the extractor searches cell size
+// and grid phase to undo scaling and cropping. A keyed layout, keyed chip
+// signs and a keyed tag mean only holders of the watermark key can read or
+// forge it.
+const (
+	wmGrid         = 24              // cells per tile side
+	wmCells        = wmGrid * wmGrid // cells per tile
+	wmPilots       = 64              // known cells used to find the grid
+	wmDataBytes    = 16 + wmTagBytes // artwork UUID + tag
+	wmTagBytes     = 8               // keyed BLAKE3 tag over the artwork ID
+	wmParityBytes  = 8               // corrects up to 4 byte errors
+	wmCodeBits     = (wmDataBytes + wmParityBytes) * 8
+	wmRepeat       = (wmCells - wmPilots) / wmCodeBits // cells per code bit within a tile
+	wmMinCellSize  = 4                                 // cell size bounds in pixels
+	wmMaxCellSize  = 16                                // largest size searched, to allow for upscaling
+	wmEmbedCell    = 12                                // cell size written
+	wmMinImageSize = 480                               // shortest side that survives halving and a 60% crop
+	wmStrength     = 4.0                               // peak luminance change, in 8-bit levels
+	wmMaxAnalysis  = 512                               // extraction looks at most at a centred window this large
+)
+
+// ErrNoWatermark is returned when no valid watermark could be decoded
+var ErrNoWatermark = errors.New("no watermark found")
+
+// ErrImageTooSmall is returned by ApplyWatermark for images whose shorter
+// side is under wmMinImageSize pixels
+var ErrImageTooSmall = fmt.Errorf("image too small to watermark (shorter side must be at least %d pixels)", wmMinImageSize)
+
+// WatermarkPayload is the data carried by the robust watermark
+type WatermarkPayload struct {
+	ArtworkID string `json:"artwork_id"`
+	Signature string `json:"signature"` // hex keyed BLAKE3 tag over the artwork ID
+}
+
+// WatermarkKeyFromEnv returns the platform watermark key from WATERMARK_KEY.
+// Without it a fixed development key is used, which anyone can read.
+func WatermarkKeyFromEnv() []byte {
+	if key := os.Getenv("WATERMARK_KEY"); key != "" {
+		return []byte(key)
+	}
+	return []byte("penguin-dev-watermark-key")
+}
+
+// watermarkLayout is the keyed assignment of tile cells to pilots and code bits
+type watermarkLayout struct {
+	pilotCells []int     // cell index of each pilot
+	pilotSigns []float64 // expected chip of each pilot
+	bitCells   [][]int   // cells carrying each code bit
+	cellSigns  []float64 // keyed sign scrambling per cell
+}
+
+// newWatermarkLayout derives the cell layout from key
+func newWatermarkLayout(key []byte) *watermarkLayout {
+	h := blake3.New()
+	h.Write([]byte("penguin-watermark-layout\x00"))
+	h.Write(key)
+	stream := h.Digest()
+
+	next := func() uint32 {
+		var buf [4]byte
+		io.ReadFull(stream, buf[:])
+		return binary.LittleEndian.Uint32(buf[:])
+	}
+
+	perm := make([]int, wmCells)
+	for i := range perm {
+		perm[i] = i
+	}
+	for i := len(perm) - 1; i > 0; i-- {
+		j := int(next() % uint32(i+1))
+		perm[i], perm[j] = perm[j], perm[i]
+	}
+
+	l := &watermarkLayout{
+		pilotCells: perm[:wmPilots],
+		pilotSigns: make([]float64, wmPilots),
+		bitCells:   make([][]int, wmCodeBits),
+		cellSigns:  make([]float64, wmCells),
+	}
+	for i := range l.pilotSigns {
+		l.pilotSigns[i] = chip(next()&1 == 1)
+	}
+	for i := range l.cellSigns {
+		l.cellSigns[i] = chip(next()&1 == 1)
+	}
+	for k, cell := range perm[wmPilots : wmPilots+wmCodeBits*wmRepeat] {
+		l.bitCells[k%wmCodeBits] = append(l.bitCells[k%wmCodeBits], cell)
+	}
+	return l
+}
+
+// chip maps a bit to ±1
+func chip(bit bool) float64 {
+	if bit {
+		return 1
+	}
+	return -1
+}
+
+// watermarkTag is the keyed BLAKE3 tag binding an artwork ID to the key
+func watermarkTag(key []byte, id uuid.UUID) []byte {
+	k := blake3.Sum256(append([]byte("penguin-watermark-tag\x00"), key...))
+	h, _ := blake3.NewKeyed(k[:])
+	h.Write(id[:])
+	return h.Sum(nil)[:wmTagBytes]
+}
+
+// ApplyWatermark embeds artworkID (a UUID) and its keyed tag into img. The
+// mark survives JPEG re-encoding down to quality 50, scaling from 0.5× up
+// and crops keeping 60% of each side; read it back with ExtractWatermark
+// and the same key. Both sides of img must be at least 480 pixels, or it
+// returns ErrImageTooSmall: smaller images halve to below what
+// ExtractWatermark can search, and their crops no longer hold a tile.
+func ApplyWatermark(img image.Image, key []byte, artworkID string) (*image.RGBA, error) {
+	id, err := uuid.Parse(artworkID)
+	if err != nil {
+		return nil, fmt.Errorf("artwork ID must be a UUID: %w", err)
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if min(width, height) < wmMinImageSize {
+		return nil, fmt.Errorf("%w, got %dx%d", ErrImageTooSmall, width, height)
+	}
+	const cell = wmEmbedCell
+
+	data := append(id[:], watermarkTag(key, id)...)
+	code := rsEncode(data, wmParityBytes)
+
+	layout := newWatermarkLayout(key)
+	chips := make([]float64, wmCells)
+	for i, c := range layout.pilotCells {
+		chips[c] = layout.pilotSigns[i]
+	}
+	for bit, cells := range layout.bitCells {
+		v := chip(code[bit/8]&(0x80>>(bit%8)) != 0)
+		for _, c := range cells {
+			chips[c] = v * layout.cellSigns[c]
+		}
+	}
+
+	// Separable cell bump: w(x, y) = wave[x%cell] * wave[y%cell]
+	wave := make([]float64, cell)
+	for i := range wave {
+		wave[i] = math.Cos(2 * math.Pi * (float64(i) + 0.5) / float64(cell))
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		cy := (y / cell) % wmGrid
+		wy := wave[y%cell]
+		for x := 0; x < width; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			delta := wmStrength * wy * wave[x%cell] * chips[cy*wmGrid+(x/cell)%wmGrid]
+			out.SetRGBA(x, y, color.RGBA{
+				R: addLevel(r, a, delta),
+				G: addLevel(g, a, delta),
+				B: addLevel(b, a, delta),
+				A: uint8(a >> 8),
+			})
+		}
+	}
+	return out, nil
+}
+
+// addLevel adds delta to a premultiplied 16-bit channel and clamps it to the
+// valid range for its alpha
+func addLevel(c, a uint32, delta float64) uint8 {
+	v := math.Round(float64(c>>8) + delta*float64(a)/0xffff)
+	return uint8(math.Max(0, math.Min(float64(a>>8), v)))
+}
+
+// wmCandidate is one grid hypothesis found during extraction
+type wmCandidate struct {
+	phaseX float64 // grid phase in pixels
+	phaseY float64
+	shiftX int // cell offset of the tile origin
+	shiftY int
+	score  float64 // normalised pilot correlation
+	fold   []float64
+}
+
+// ExtractWatermark searches img for a watermark written with key and returns
+// its payload and a confidence in [0, 1]. It returns ErrNoWatermark, with the
+// best confidence seen, when nothing decodes.
+func ExtractWatermark(img image.Image, key []byte) (*WatermarkPayload, float64, error) {
+	layout := newWatermarkLayout(key)
+	best := 0.0
+
+	// Full resolution first; upscaled or very large images only show their
+	// tile period in the window once box-downsampled
+	short := min(img.Bounds().Dx(), img.Bounds().Dy())
+	for k := 1; k <= 4 && short/k >= wmGrid*wmMinCellSize*2; k *= 2 {
+		payload, confidence, err := extractAt(img, k, layout, key)
+		if err == nil {
+			return payload, confidence, nil
+		}
+		best = math.Max(best, confidence)
+	}
+	return nil, best, ErrNoWatermark
+}
+
+// extractAt runs the grid search on img downsampled by k
+func extractAt(img image.Image, k int, layout *watermarkLayout, key []byte) (*WatermarkPayload, float64, error) {
+	luma, width, height := analysisLuma(img, k)
+	if min(width, height) < wmGrid*3 {
+		return nil, 0, ErrNoWatermark
+	}
+	best := 0.0
+
+	// Candidate cell sizes: every size ApplyWatermark picks, for unscaled
+	// images, plus the sizes implied by the tile period for scaled ones. The
+	// tile repeats every wmGrid cells, so strong autocorrelation lags suggest
+	// its period along each axis; JPEG block edges add peaks of their own.
+	type gridSize struct{ cellX, cellY, score float64 }
+	sizes := []gridSize{}
+	for cell := wmMinCellSize; cell <= wmMaxCellSize && cell*wmGrid <= min(width, height); cell++ {
+		sizes = append(sizes, gridSize{cellX: float64(cell), cellY: float64(cell)})
+	}
+	residual := highPass(luma, width, height)
+	periodsY := tilePeriods(residual, width, height, true)
+	for _, tx := range tilePeriods(residual, width, height, false) {
+		for _, ty := range periodsY {
+			if math.Abs(math.Log(tx/ty)) <= 0.2 {
+				sizes = append(sizes, gridSize{cellX: tx / wmGrid, cellY: ty / wmGrid})
+			}
+		}
+	}
+
+	// Rank the sizes with a coarse phase search and only decode the best
+	for i := range sizes {
+		sizes[i].score = searchGrid(luma, width, height, sizes[i].cellX, sizes[i].cellY, 4, false, layout)[0].score
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i].score > sizes[j].score })
+	for _, size := range sizes[:min(4, len(sizes))] {
+		for _, cand := range searchGrid(luma, width, height, size.cellX, size.cellY, 8, true, layout) {
+			if payload, confidence, err := decodeCandidate(cand, layout, key); err == nil {
+				return payload, confidence, nil
+			}
+			best = math.Max(best, pilotConfidence(cand.score))
+		}
+	}
+
+	return nil, best, ErrNoWatermark
+}
+
+// tilePeriods returns the likely tile periods, in pixels, along the x axis
+// of a high-passed image, or along the y axis when vertical is set
+func tilePeriods(residual []float64, width, height int, vertical bool) []float64 {
+	n := width
+	if vertical {
+		n = height
+	}
+	minLag := wmGrid * 3
+	maxLag := min(wmGrid*wmMaxCellSize, n*4/5)
+	if maxLag <= minLag+2 {
+		return nil
+	}
+
+	corr := make([]float64, maxLag+2)
+	for lag := minLag - 1; lag <= maxLag+1; lag++ {
+		dx, dy := lag, 0
+		if vertical {
+			dx, dy = 0, lag
+		}
+		var sum float64
+		for y := 0; y+dy < height; y++ {
+			a := residual[y*width : y*width+width-dx]
+			b := residual[(y+dy)*width+dx : (y+dy)*width+width]
+			for i, v := range a {
+				sum += v * b[i]
+			}
+		}
+		corr[lag] = sum / float64((width-dx)*(height-dy))
+	}
+
+	type peak struct{ lag, value float64 }
+	peaks := []peak{}
+	for lag := minLag; lag <= maxLag; lag++ {
+		c := corr[lag]
+		if c <= 0 || c < corr[lag-1] || c < corr[lag+1] {
+			continue
+		}
+		// Parabolic interpolation for a sub-pixel period
+		offset := 0.0
+		if den := corr[lag-1] - 2*c + corr[lag+1]; den != 0 {
+			offset = 0.5 * (corr[lag-1] - corr[lag+1]) / den
+		}
+		peaks = append(peaks, peak{float64(lag) + offset, c})
+	}
+	sort.Slice(peaks, func(i, j int) bool { return peaks[i].value > peaks[j].value })
+
+	periods := []float64{}
+	for _, p := range peaks[:min(4, len(peaks))] {
+		periods = append(periods, p.lag)
+	}
+	return periods
+}
+
+// highPass removes the smooth image content (a 5×5 box blur), leaving the
+// watermark bumps and fine texture
+func highPass(luma []float64, width, height int) []float64 {
+	const radius = 2
+	blur := func(src []float64, n, stride, count, step int) []float64 {
+		out := make([]float64, len(src))
+		for line := 0; line < count; line++ {
+			base := line * step
+			for i := 0; i < n; i++ {
+				lo, hi := max(0, i-radius), min(n-1, i+radius)
+				var sum float64
+				for j := lo; j <= hi; j++ {
+					sum += src[base+j*stride]
+				}
+				out[base+i*stride] = sum / float64(hi-lo+1)
+			}
+		}
+		return out
+	}
+	smooth := blur(blur(luma, width, 1, height, width), height, width, width, 1)
+
+	out := make([]float64, len(luma))
+	for i, v := range luma {
+		out[i] = v - smooth[i]
+	}
+	return out
+}
+
+// analysisLuma returns the luminance of img box-downsampled by k, cut to a
+// centred window of at most wmMaxAnalysis pixels on each side
+func analysisLuma(img image.Image, k int) ([]float64, int, int) {
+	bounds := img.Bounds()
+	width, height := min(bounds.Dx()/k, wmMaxAnalysis), min(bounds.Dy()/k, wmMaxAnalysis)
+	x0 := bounds.Min.X + (bounds.Dx()-width*k)/2
+	y0 := bounds.Min.Y + (bounds.Dy()-height*k)/2
+
+	luma := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var sum float64
+			for dy := 0; dy < k; dy++ {
+				for dx := 0; dx < k; dx++ {
+					r, g, b, _ := img.At(x0+x*k+dx, y0+y*k+dy).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+				}
+			}
+			luma[y*width+x] = sum / float64(k*k) / 257
+		}
+	}
+	return luma, width, height
+}
+
+// searchGrid folds the image into one tile for a steps×steps grid of phases
+// at the given cell size and returns the best-aligned hypotheses, strongest
+// first. With refine set, the phase around the best hypothesis is searched
+// again at a quarter of the step.
+func searchGrid(luma []float64, width, height int, cellX, cellY float64, steps int, refine bool, layout *watermarkLayout) []*wmCandidate {
+	row := make([]float64, wmGrid)
+	try := func(phaseX, phaseY float64) *wmCandidate {
+		rowCell, rowWave := gridAxis(height, cellY, phaseY)
+		colCell, colWave := gridAxis(width, cellX, phaseX)
+
+		fold := make([]float64, wmCells)
+		for y := 0; y < height; y++ {
+			for i := range row {
+				row[i] = 0
+			}
+			line := luma[y*width : (y+1)*width]
+			for x, v := range line {
+				row[colCell[x]] += v * colWave[x]
+			}
+			base := rowCell[y] * wmGrid
+			for i, v := range row {
+				fold[base+i] += v * rowWave[y]
+			}
+		}
+
+		shiftX, shiftY, score := alignPilots(fold, layout)
+		return &wmCandidate{phaseX: phaseX, phaseY: phaseY, shiftX: shiftX, shiftY: shiftY, score: score, fold: fold}
+	}
+
+	stepX, stepY := cellX/float64(steps), cellY/float64(steps)
+	candidates := []*wmCandidate{}
+	for iy := 0; iy < steps; iy++ {
+		for ix := 0; ix < steps; ix++ {
+			candidates = append(candidates, try(float64(ix)*stepX, float64(iy)*stepY))
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	if refine {
+		best := candidates[0]
+		for dy := -2; dy <= 2; dy++ {
+			for dx := -2; dx <= 2; dx++ {
+				if dx == 0 && dy == 0 {
+					continue
+				}
+				candidates = append(candidates, try(best.phaseX+float64(dx)*stepX/4, best.phaseY+float64(dy)*stepY/4))
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	}
+
+	return candidates[:min(2, len(candidates))]
+}
+
+// gridAxis precomputes the cell index and bump weight of every coordinate
+// along one axis
+func gridAxis(n int, cell, phase float64) ([]int, []float64) {
+	cells := make([]int, n)
+	wave := make([]float64, n)
+	for i := 0; i < n; i++ {
+		pos := (float64(i) + 0.5 + phase) / cell
+		c := math.Floor(pos)
+		cells[i] = ((int(c) % wmGrid) + wmGrid) % wmGrid
+		wave[i] = math.Cos(2 * math.Pi * (pos - c))
+	}
+	return cells, wave
+}
+
+// alignPilots finds the cyclic cell shift that best matches the pilots and
+// returns it with its normalised correlation
+func alignPilots(fold []float64, layout *watermarkLayout) (int, int, float64) {
+	bestX, bestY, best := 0, 0, math.Inf(-1)
+	for sy := 0; sy < wmGrid; sy++ {
+		for sx := 0; sx < wmGrid; sx++ {
+			var corr, energy float64
+			for i, c := range layout.pilotCells {
+				v := fold[foldIndex(c, sx, sy)]
+				corr += v * layout.pilotSigns[i]
+				energy += v * v
+			}
+			if energy == 0 {
+				continue
+			}
+			if score := corr / math.Sqrt(energy); score > best {
+				bestX, bestY, best = sx, sy, score
+			}
+		}
+	}
+	return bestX, bestY, best
+}
+
+// foldIndex maps a tile cell to its position in a fold whose tile origin is
+// shifted by (sx, sy) cells
+func foldIndex(cell, sx, sy int) int {
+	x := (cell%wmGrid + sx) % wmGrid
+	y := (cell/wmGrid + sy) % wmGrid
+	return y*wmGrid + x
+}
+
+// decodeCandidate reads the code bits under one grid hypothesis, corrects
+// them and checks the tag
+func decodeCandidate(cand *wmCandidate, layout *watermarkLayout, key []byte) (*WatermarkPayload, float64, error) {
+	soft := make([]float64, wmCodeBits)
+	code := make([]byte, wmDataBytes+wmParityBytes)
+	for bit, cells := range layout.bitCells {
+		for _, c := range cells {
+			soft[bit] += cand.fold[foldIndex(c, cand.shiftX, cand.shiftY)] * layout.cellSigns[c]
+		}
+		if soft[bit] > 0 {
+			code[bit/8] |= 0x80 >> (bit % 8)
+		}
+	}
+
+	data, _, err := rsDecode(code, wmParityBytes)
+	if err != nil {
+		return nil, 0, err
+	}
+	id, err := uuid.FromBytes(data[:16])
+	if err != nil {
+		return nil, 0, err
+	}
+	tag := watermarkTag(key, id)
+	if string(tag) != string(data[16:]) {
+		return nil, 0, fmt.Errorf("watermark tag mismatch")
+	}
+
+	// Confidence is how strongly the soft bits agree with the corrected
+	// codeword, blended with how clearly the pilots locked on
+	corrected := rsEncode(data, wmParityBytes)
+	var agree, total float64
+	for bit, s := range soft {
+		agree += s * chip(corrected[bit/8]&(0x80>>(bit%8)) != 0)
+		total += math.Abs(s)
+	}
+	confidence := 0.0
+	if total > 0 {
+		confidence = math.Max(0, agree/total)
+	}
+	confidence = 0.5*confidence + 0.5*pilotConfidence(cand.score)
+
+	return &WatermarkPayload{
+		ArtworkID: id.String(),
+		Signature: fmt.Sprintf("%x", tag),
+	}, confidence, nil
+}
+
+// pilotConfidence maps a normalised pilot correlation onto [0, 1]. A perfect
+// lock scores sqrt(wmPilots); the best of many random alignments scores
+// about 3, which maps to 0.
+func pilotConfidence(score float64) float64 {
+	const chance = 3.0
+	return math.Max(0, math.Min(1, (score-chance)/(math.Sqrt(wmPilots)-chance)))
+}
@@ -0,0 +1,160 @@
+package crypto
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"testing"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// testArtwork draws a smooth, textured image like the artworks the
+// watermark is tuned for
+func testArtwork(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(128 + 80*math.Sin(float64(x)/23)*math.Cos(float64(y)/31)),
+				G: uint8(40 + 150*y/height),
+				B: uint8(200 - 120*x/width),
+				A: 0xff,
+			})
+		}
+	}
+	return img
+}
+
+// reencodeJPEG returns img after a JPEG round trip at quality
+func reencodeJPEG(t *testing.T, img image.Image, quality int) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		t.Fatal(err)
+	}
+	out, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// rescale returns img resized by factor with Catmull-Rom resampling
+func rescale(img image.Image, factor float64) image.Image {
+	bounds := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, int(float64(bounds.Dx())*factor), int(float64(bounds.Dy())*factor)))
+	xdraw.CatmullRom.Scale(out, out.Bounds(), img, bounds, xdraw.Src, nil)
+	return out
+}
+
+// crop returns the part of img starting at the given fractions of its size
+// and spanning keep of each side
+func crop(img *image.RGBA, fromX, fromY, keep float64) image.Image {
+	bounds := img.Bounds()
+	x0, y0 := int(float64(bounds.Dx())*fromX), int(float64(bounds.Dy())*fromY)
+	return img.SubImage(image.Rect(x0, y0, x0+int(float64(bounds.Dx())*keep), y0+int(float64(bounds.Dy())*keep)))
+}
+
+func TestWatermarkSurvivesTransforms(t *testing.T) {
+	key := []byte("test watermark key, 32 bytes....")
+	const artworkID = "5f0c6a3e-9b7d-4c21-8e4f-2a1b3c4d5e6f"
+
+	// The smallest accepted size is the hardest case for every transform
+	for _, size := range []image.Point{{480, 480}, {800, 600}} {
+		marked, err := ApplyWatermark(testArtwork(size.X, size.Y), key, artworkID)
+		if err != nil {
+			t.Fatalf("ApplyWatermark %v: %v", size, err)
+		}
+
+		transforms := []struct {
+			name string
+			img  image.Image
+		}{
+			{"jpeg q75", reencodeJPEG(t, marked, 75)},
+			{"jpeg q50", reencodeJPEG(t, marked, 50)},
+			{"scaled 0.5x", rescale(marked, 0.5)},
+			{"scaled 0.75x", rescale(marked, 0.75)},
+			{"scaled 1.5x", rescale(marked, 1.5)},
+			{"60% crop", crop(marked, 0.15, 0.2, 0.6)},
+			{"60% crop from the corner", crop(marked, 0, 0, 0.6)},
+			{"scaled 0.75x, jpeg q75", reencodeJPEG(t, rescale(marked, 0.75), 75)},
+		}
+		for _, tt := range transforms {
+			t.Run(fmt.Sprintf("%dx%d %s", size.X, size.Y, tt.name), func(t *testing.T) {
+				payload, confidence, err := ExtractWatermark(tt.img, key)
+				if err != nil {
+					t.Fatalf("ExtractWatermark: %v (confidence %.2f)", err, confidence)
+				}
+				if payload.ArtworkID != artworkID {
+					t.Errorf("watermark names %s, want %s", payload.ArtworkID, artworkID)
+				}
+				if confidence < 0.8 {
+					t.Errorf("confidence %.2f, want at least 0.8", confidence)
+				}
+			})
+		}
+	}
+}
+
+func TestApplyWatermarkMinimumSize(t *testing.T) {
+	key := []byte("test watermark key, 32 bytes....")
+	const artworkID = "5f0c6a3e-9b7d-4c21-8e4f-2a1b3c4d5e6f"
+
+	for _, size := range []image.Point{{479, 640}, {640, 479}, {320, 240}} {
+		if _, err := ApplyWatermark(testArtwork(size.X, size.Y), key, artworkID); !errors.Is(err, ErrImageTooSmall) {
+			t.Errorf("%dx%d: %v, want ErrImageTooSmall", size.X, size.Y, err)
+		}
+	}
+	if _, err := ApplyWatermark(testArtwork(480, 480), key, artworkID); err != nil {
+		t.Errorf("480x480: %v", err)
+	}
+}
+
+func TestWatermarkKeyBinding(t *testing.T) {
+	key := []byte("test watermark key, 32 bytes....")
+	wrongKey := []byte("another watermark key, 32 bytes.")
+	const artworkID = "5f0c6a3e-9b7d-4c21-8e4f-2a1b3c4d5e6f"
+
+	artwork := testArtwork(512, 480)
+	seed, err := NewNoiseSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := SealNoiseSeed(key, artworkID, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	marked, _, err := MarkImage(artwork, key, artworkID, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither layer can be read with another key, and the sealed seed is
+	// bound to its artwork
+	if payload, _, err := ExtractWatermark(marked, wrongKey); err == nil {
+		t.Errorf("watermark read with the wrong key: %s", payload.ArtworkID)
+	}
+	if _, err := OpenNoiseSeed(wrongKey, artworkID, sealed); err == nil {
+		t.Errorf("noise seed unsealed with the wrong key")
+	}
+	if _, err := OpenNoiseSeed(key, "00000000-0000-4000-8000-000000000000", sealed); err == nil {
+		t.Errorf("noise seed unsealed for another artwork")
+	}
+
+	// The unmarked image carries neither layer
+	pattern, err := GenerateNoisePattern(seed, 512, 480)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload, _, err := ExtractWatermark(artwork, key); err == nil {
+		t.Errorf("unmarked image yields watermark %s", payload.ArtworkID)
+	}
+	if ok, match := DetectWatermark(artwork, pattern, NoiseMatchThreshold); ok {
+		t.Errorf("noise pattern detected in unmarked image (%.4f of bits match)", match)
+	}
+}
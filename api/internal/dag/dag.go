@@ -0,0 +1,392 @@
+// Package dag stores nodes, artifacts and manifests as content-addressed
+// IPLD blocks. Blocks are DAG-CBOR (or DAG-JSON) encoded and identified by
+// CIDv1 with a BLAKE3 (or SHA2-256) multihash, so any IPLD implementation
+// can recompute a block's CID from its bytes. Values link to each other by
+// CID and Resolve walks those links.
+package dag
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/codec/dagcbor"
+	"github.com/ipld/go-ipld-prime/codec/dagjson"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	"github.com/ipld/go-ipld-prime/node/basicnode"
+	"github.com/multiformats/go-multihash"
+	"github.com/zeebo/blake3"
+
+	"yourproject/internal/ipfsdb"
+)
+
+// Block codecs and multihash functions, by their multicodec names
+const (
+	CodecDagCBOR = "dag-cbor"
+	CodecDagJSON = "dag-json"
+	CodecRaw     = "raw"
+	HashBlake3   = "blake3"
+	HashSHA256   = "sha2-256"
+)
+
+var (
+	// ErrNotFound is returned for a CID whose block is not in the store
+	ErrNotFound = errors.New("block not found")
+
+	// ErrNoPath is returned when a path does not exist in a block
+	ErrNoPath = errors.New("path not found")
+
+	// ErrCorrupt is returned when a stored block does not hash to its CID
+	ErrCorrupt = errors.New("block does not match its CID")
+
+	// ErrInvalid is returned by Put for a value that has no IPLD form, such
+	// as an integer outside the int64 range
+	ErrInvalid = errors.New("value cannot be stored as a block")
+)
+
+var codecs = map[string]uint64{CodecDagCBOR: cid.DagCBOR, CodecDagJSON: cid.DagJSON, CodecRaw: cid.Raw}
+var hashes = map[string]uint64{HashBlake3: multihash.BLAKE3, HashSHA256: multihash.SHA2_256}
+
+// ConfigFromEnv returns the block codec and multihash function from
+// DAG_CODEC and DAG_HASH, defaulting to DAG-CBOR and BLAKE3
+func ConfigFromEnv() (codec, hash string) {
+	codec, hash = os.Getenv("DAG_CODEC"), os.Getenv("DAG_HASH")
+	if codec == "" {
+		codec = CodecDagCBOR
+	}
+	if hash == "" {
+		hash = HashBlake3
+	}
+	return codec, hash
+}
+
+// Store keeps blocks in the Store under /dag/<cid>
+type Store struct {
+	db     ipfsdb.Store
+	codec  uint64
+	mhType uint64
+}
+
+// New creates a block store writing new blocks with the given codec and
+// multihash function. Blocks of any supported codec and hash can be read.
+func New(db ipfsdb.Store, codec, hash string) (*Store, error) {
+	c, ok := codecs[codec]
+	if !ok || c == cid.Raw {
+		return nil, fmt.Errorf("unsupported DAG codec %q (want %s or %s)", codec, CodecDagCBOR, CodecDagJSON)
+	}
+	h, ok := hashes[hash]
+	if !ok {
+		return nil, fmt.Errorf("unsupported DAG hash %q (want %s or %s)", hash, HashBlake3, HashSHA256)
+	}
+	return &Store{db: db, codec: c, mhType: h}, nil
+}
+
+// blockKey returns the store key of a block
+func blockKey(c cid.Cid) string {
+	return "/dag/" + c.String()
+}
+
+// ParseCID parses a CID, optionally given as an /ipfs/<cid> path
+func ParseCID(s string) (cid.Cid, error) {
+	return cid.Decode(strings.TrimPrefix(s, "/ipfs/"))
+}
+
+// CodecName returns the multicodec name of a CID's codec
+func CodecName(c cid.Cid) string {
+	for name, code := range codecs {
+		if code == c.Type() {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%x", c.Type())
+}
+
+// sum returns the CIDv1 of data under codec, hashed with mhType
+func sum(codec, mhType uint64, data []byte) (cid.Cid, error) {
+	var digest []byte
+	switch mhType {
+	case multihash.BLAKE3:
+		d := blake3.Sum256(data)
+		digest = d[:]
+	case multihash.SHA2_256:
+		d := sha256.Sum256(data)
+		digest = d[:]
+	default:
+		return cid.Undef, fmt.Errorf("unsupported multihash 0x%x", mhType)
+	}
+	mh, err := multihash.Encode(digest, mhType)
+	if err != nil {
+		return cid.Undef, err
+	}
+	return cid.NewCidV1(codec, mh), nil
+}
+
+// Digest returns the hex digest carried in a CID's multihash
+func Digest(c cid.Cid) string {
+	decoded, err := multihash.Decode(c.Hash())
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%x", decoded.Digest)
+}
+
+// Put encodes v as a block and stores it. v is anything encoding/json can
+// encode; cid.Cid values (which encode as {"/": "<cid>"}) become links.
+// Values with no IPLD form fail with ErrInvalid.
+func (s *Store) Put(v interface{}) (cid.Cid, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return cid.Undef, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	nb := basicnode.Prototype.Any.NewBuilder()
+	if err := dagjson.Decode(nb, bytes.NewReader(raw)); err != nil {
+		return cid.Undef, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return s.PutNode(nb.Build())
+}
+
+// PutNode encodes an IPLD node as a block and stores it
+func (s *Store) PutNode(n datamodel.Node) (cid.Cid, error) {
+	var buf bytes.Buffer
+	var err error
+	if s.codec == cid.DagJSON {
+		err = dagjson.Encode(n, &buf)
+	} else {
+		err = dagcbor.Encode(n, &buf)
+	}
+	if err != nil {
+		return cid.Undef, fmt.Errorf("failed to encode block: %w", err)
+	}
+	return s.put(s.codec, buf.Bytes())
+}
+
+// PutRaw stores data as a raw block, as IPFS does for file content
+func (s *Store) PutRaw(data []byte) (cid.Cid, error) {
+	return s.put(cid.Raw, data)
+}
+
+// put stores encoded block bytes under their CID
+func (s *Store) put(codec uint64, data []byte) (cid.Cid, error) {
+	c, err := sum(codec, s.mhType, data)
+	if err != nil {
+		return cid.Undef, err
+	}
+	if err := s.db.Save(blockKey(c), data); err != nil {
+		return cid.Undef, fmt.Errorf("failed to store block %s: %w", c, err)
+	}
+	return c, nil
+}
+
+// Has reports whether the block of c is stored
+func (s *Store) Has(c cid.Cid) bool {
+	_, ok := s.db.Get(blockKey(c))
+	return ok
+}
+
+// Block returns the bytes of a block after checking they hash to c
+func (s *Store) Block(c cid.Cid) ([]byte, error) {
+	val, ok := s.db.Get(blockKey(c))
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, c)
+	}
+	data, ok := val.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrCorrupt, c)
+	}
+	decoded, err := multihash.Decode(c.Hash())
+	if err != nil {
+		return nil, err
+	}
+	if check, err := sum(c.Type(), decoded.Code, data); err != nil || !check.Equals(c) {
+		return nil, fmt.Errorf("%w: %s", ErrCorrupt, c)
+	}
+	return data, nil
+}
+
+// Get loads and decodes the block of c. Raw blocks decode to a bytes node.
+func (s *Store) Get(c cid.Cid) (datamodel.Node, error) {
+	data, err := s.Block(c)
+	if err != nil {
+		return nil, err
+	}
+
+	nb := basicnode.Prototype.Any.NewBuilder()
+	switch c.Type() {
+	case cid.DagCBOR:
+		err = dagcbor.Decode(nb, bytes.NewReader(data))
+	case cid.DagJSON:
+		err = dagjson.Decode(nb, bytes.NewReader(data))
+	case cid.Raw:
+		return basicnode.NewBytes(data), nil
+	default:
+		return nil, fmt.Errorf("unsupported codec %s of block %s", CodecName(c), c)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode block %s: %w", c, err)
+	}
+	return nb.Build(), nil
+}
+
+// Resolve walks path (map keys and list indexes) from the block of root,
+// following links into other blocks on the way. It returns the node at the
+// end of the path and the CID of the block holding it; whole reports whether
+// that node is the entire block, as when the path is empty or ends on a
+// link, which is followed too.
+func (s *Store) Resolve(root cid.Cid, path []string) (n datamodel.Node, at cid.Cid, whole bool, err error) {
+	at, whole = root, true
+	if n, err = s.Get(at); err != nil {
+		return nil, cid.Undef, false, err
+	}
+
+	for i, seg := range path {
+		switch n.Kind() {
+		case datamodel.Kind_Map:
+			n, err = n.LookupByString(seg)
+		case datamodel.Kind_List:
+			idx, perr := strconv.ParseInt(seg, 10, 64)
+			if perr != nil {
+				return nil, cid.Undef, false, fmt.Errorf("path segment %q is not a list index", seg)
+			}
+			n, err = n.LookupByIndex(idx)
+		default:
+			return nil, cid.Undef, false, fmt.Errorf("cannot walk into %s at %q", n.Kind(), strings.Join(path[:i+1], "/"))
+		}
+		if err != nil {
+			return nil, cid.Undef, false, fmt.Errorf("%w: no %q in %s", ErrNoPath, strings.Join(path[:i+1], "/"), at)
+		}
+
+		whole = n.Kind() == datamodel.Kind_Link
+		if whole {
+			if at, n, err = s.follow(n); err != nil {
+				return nil, cid.Undef, false, err
+			}
+		}
+	}
+	return n, at, whole, nil
+}
+
+// follow loads the block a link node points to
+func (s *Store) follow(n datamodel.Node) (cid.Cid, datamodel.Node, error) {
+	link, err := n.AsLink()
+	if err != nil {
+		return cid.Undef, nil, err
+	}
+	cl, ok := link.(cidlink.Link)
+	if !ok {
+		return cid.Undef, nil, fmt.Errorf("unsupported link %s", link)
+	}
+	target, err := s.Get(cl.Cid)
+	return cl.Cid, target, err
+}
+
+// Resolved is a block rendered for JSON output, with the blocks it links to
+// rendered in Links down to the requested depth
+type Resolved struct {
+	CID   string               `json:"cid"`
+	Codec string               `json:"codec"`
+	Hash  string               `json:"hash"`
+	Size  int                  `json:"size"`
+	Data  interface{}          `json:"data,omitempty"`
+	Links map[string]*Resolved `json:"links,omitempty"`
+	Error string               `json:"error,omitempty"`
+}
+
+// Render returns the block of c with links followed depth levels deep.
+// Blocks that cannot be loaded are reported with an error, not skipped.
+func (s *Store) Render(c cid.Cid, depth int) *Resolved {
+	out := &Resolved{CID: c.String(), Codec: CodecName(c)}
+	if decoded, err := multihash.Decode(c.Hash()); err == nil {
+		out.Hash = decoded.Name
+	}
+
+	data, err := s.Block(c)
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	out.Size = len(data)
+	if c.Type() == cid.Raw {
+		return out
+	}
+
+	n, err := s.Get(c)
+	if err != nil {
+		out.Error = err.Error()
+		return out
+	}
+	links := []cid.Cid{}
+	out.Data = ToJSON(n, &links)
+	if depth > 0 && len(links) > 0 {
+		out.Links = map[string]*Resolved{}
+		for _, link := range links {
+			if _, seen := out.Links[link.String()]; !seen {
+				out.Links[link.String()] = s.Render(link, depth-1)
+			}
+		}
+	}
+	return out
+}
+
+// ToJSON converts an IPLD node to its DAG-JSON form as plain Go values:
+// links become {"/": "<cid>"} and bytes {"/": {"bytes": "<base64>"}}. CIDs
+// of the links found are appended to links when it is not nil.
+func ToJSON(n datamodel.Node, links *[]cid.Cid) interface{} {
+	switch n.Kind() {
+	case datamodel.Kind_Map:
+		out := map[string]interface{}{}
+		it := n.MapIterator()
+		for !it.Done() {
+			k, v, err := it.Next()
+			if err != nil {
+				break
+			}
+			key, _ := k.AsString()
+			out[key] = ToJSON(v, links)
+		}
+		return out
+	case datamodel.Kind_List:
+		out := []interface{}{}
+		it := n.ListIterator()
+		for !it.Done() {
+			_, v, err := it.Next()
+			if err != nil {
+				break
+			}
+			out = append(out, ToJSON(v, links))
+		}
+		return out
+	case datamodel.Kind_String:
+		s, _ := n.AsString()
+		return s
+	case datamodel.Kind_Int:
+		i, _ := n.AsInt()
+		return i
+	case datamodel.Kind_Float:
+		f, _ := n.AsFloat()
+		return f
+	case datamodel.Kind_Bool:
+		b, _ := n.AsBool()
+		return b
+	case datamodel.Kind_Bytes:
+		b, _ := n.AsBytes()
+		return map[string]interface{}{"/": map[string]string{"bytes": base64.RawStdEncoding.EncodeToString(b)}}
+	case datamodel.Kind_Link:
+		link, _ := n.AsLink()
+		if cl, ok := link.(cidlink.Link); ok {
+			if links != nil {
+				*links = append(*links, cl.Cid)
+			}
+			return map[string]string{"/": cl.Cid.String()}
+		}
+		return map[string]string{"/": link.String()}
+	}
+	return nil
+}
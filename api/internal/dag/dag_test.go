@@ -0,0 +1,189 @@
+package dag
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime/datamodel"
+	"github.com/multiformats/go-multihash"
+	"github.com/zeebo/blake3"
+
+	"yourproject/internal/ipfsdb"
+)
+
+func TestStore(t *testing.T) {
+	for _, codec := range []string{CodecDagCBOR, CodecDagJSON} {
+		for _, hash := range []string{HashBlake3, HashSHA256} {
+			t.Run(codec+"/"+hash, func(t *testing.T) {
+				testStore(t, codec, hash)
+			})
+		}
+	}
+}
+
+func testStore(t *testing.T, codec, hash string) {
+	db := ipfsdb.New()
+	s, err := New(db, codec, hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := s.PutRaw([]byte("artwork bytes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := s.Put(map[string]interface{}{
+		"type":    "node",
+		"content": content,
+		"body":    map[string]interface{}{"prompt": "dawn", "seed": 42, "tags": []string{"a", "b"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := s.Put(map[string]interface{}{"type": "manifest", "nodes": []cid.Cid{node}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The CID names the codec and hash, and its digest is the hash of the
+	// stored bytes
+	wantHash := map[string]uint64{HashBlake3: multihash.BLAKE3, HashSHA256: multihash.SHA2_256}[hash]
+	for _, c := range []cid.Cid{content, node, manifest} {
+		if c.Version() != 1 {
+			t.Errorf("%s is CIDv%d", c, c.Version())
+		}
+		decoded, err := multihash.Decode(c.Hash())
+		if err != nil || decoded.Code != wantHash {
+			t.Errorf("%s: multihash %+v, %v; want %s", c, decoded, err, hash)
+		}
+		block, err := s.Block(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var digest []byte
+		if hash == HashBlake3 {
+			d := blake3.Sum256(block)
+			digest = d[:]
+		} else {
+			d := sha256.Sum256(block)
+			digest = d[:]
+		}
+		if Digest(c) != fmt.Sprintf("%x", digest) {
+			t.Errorf("%s: digest %s, block hashes to %x", c, Digest(c), digest)
+		}
+	}
+	if CodecName(content) != CodecRaw || CodecName(node) != codec || CodecName(manifest) != codec {
+		t.Errorf("codecs %s, %s, %s; want raw, %s, %s", CodecName(content), CodecName(node), CodecName(manifest), codec, codec)
+	}
+
+	// Equal values make the same block
+	again, err := s.Put(map[string]interface{}{"type": "manifest", "nodes": []cid.Cid{node}})
+	if err != nil || !again.Equals(manifest) {
+		t.Errorf("same manifest stored as %s, first as %s", again, manifest)
+	}
+
+	// Get decodes the block, links included
+	n, err := s.Get(node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	links := []cid.Cid{}
+	got := ToJSON(n, &links)
+	body := got.(map[string]interface{})["body"].(map[string]interface{})
+	if body["prompt"] != "dawn" || body["seed"] != int64(42) || len(links) != 1 || !links[0].Equals(content) {
+		t.Errorf("Get(node) = %v with links %v", got, links)
+	}
+	raw, err := s.Get(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := raw.AsBytes(); string(b) != "artwork bytes" {
+		t.Errorf("Get(raw) = %q", b)
+	}
+
+	// Resolve walks across blocks
+	for _, tt := range []struct {
+		path  []string
+		at    cid.Cid
+		whole bool
+		kind  datamodel.Kind
+	}{
+		{nil, manifest, true, datamodel.Kind_Map},
+		{[]string{"nodes", "0"}, node, true, datamodel.Kind_Map},
+		{[]string{"nodes", "0", "body", "tags", "1"}, node, false, datamodel.Kind_String},
+		{[]string{"nodes", "0", "content"}, content, true, datamodel.Kind_Bytes},
+	} {
+		n, at, whole, err := s.Resolve(manifest, tt.path)
+		if err != nil {
+			t.Errorf("Resolve(%v): %v", tt.path, err)
+			continue
+		}
+		if !at.Equals(tt.at) || whole != tt.whole || n.Kind() != tt.kind {
+			t.Errorf("Resolve(%v) = %s in %s (whole %v), want %s in %s (whole %v)", tt.path, n.Kind(), at, whole, tt.kind, tt.at, tt.whole)
+		}
+	}
+	if _, _, _, err := s.Resolve(manifest, []string{"nodes", "0", "missing"}); !errors.Is(err, ErrNoPath) {
+		t.Errorf("Resolve of a missing path: %v, want ErrNoPath", err)
+	}
+	if _, _, _, err := s.Resolve(manifest, []string{"nodes", "one"}); err == nil {
+		t.Error("Resolve walked a list with a non-index segment")
+	}
+
+	// Render follows links down to the depth asked for
+	shallow := s.Render(manifest, 0)
+	if shallow.Error != "" || shallow.Codec != codec || shallow.Hash != hash || len(shallow.Links) != 0 {
+		t.Errorf("Render(manifest, 0) = %+v", shallow)
+	}
+	deep := s.Render(manifest, 2)
+	rendered := deep.Links[node.String()]
+	if rendered == nil || rendered.Links[content.String()] == nil || rendered.Links[content.String()].Codec != CodecRaw {
+		t.Fatalf("Render(manifest, 2) = %+v", deep)
+	}
+	if size := rendered.Links[content.String()].Size; size != len("artwork bytes") {
+		t.Errorf("raw block size %d", size)
+	}
+
+	// Missing and altered blocks are reported
+	missing, err := sum(cid.DagCBOR, wantHash, []byte("missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(missing); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of a missing block: %v, want ErrNotFound", err)
+	}
+	if err := db.Save(blockKey(content), []byte("altered bytes")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Block(content); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Block of an altered block: %v, want ErrCorrupt", err)
+	}
+	if r := s.Render(manifest, 2); r.Links[node.String()].Links[content.String()].Error == "" {
+		t.Error("Render did not report the altered block")
+	}
+}
+
+func TestPutInvalid(t *testing.T) {
+	s, err := New(ipfsdb.New(), CodecDagCBOR, HashBlake3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []interface{}{
+		map[string]interface{}{"n": 1e20},
+		map[string]interface{}{"n": uint64(1) << 63},
+		map[string]interface{}{"f": func() {}},
+	} {
+		if _, err := s.Put(v); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Put(%v): %v, want ErrInvalid", v, err)
+		}
+	}
+
+	if _, err := New(ipfsdb.New(), CodecRaw, HashBlake3); err == nil {
+		t.Error("store writing raw blocks created")
+	}
+	if _, err := New(ipfsdb.New(), CodecDagCBOR, "md5"); err == nil {
+		t.Error("store hashing with md5 created")
+	}
+}
@@ -0,0 +1,419 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// maxContractBatch is the largest batch ProofOfArt.batchRegisterArtworks accepts
+const maxContractBatch = 50
+
+// maxSubmitAttempts is how often a queued artwork is retried after the batch
+// transaction could not be sent
+const maxSubmitAttempts = 3
+
+// AnchorStatus is the on-chain registration state of one artwork
+type AnchorStatus string
+
+const (
+	AnchorQueued    AnchorStatus = "queued"    // waiting for the next batch
+	AnchorSubmitted AnchorStatus = "submitted" // batch broadcast, not yet in a block
+	AnchorMined     AnchorStatus = "mined"     // batch included in a block
+	AnchorConfirmed AnchorStatus = "confirmed" // batch reached the confirmation depth
+	AnchorFailed    AnchorStatus = "failed"    // not registered, see Error
+)
+
+// ArtworkAnchor tracks one artwork through the registration batch queue
+type ArtworkAnchor struct {
+	ArtworkID      string       `json:"artwork_id"`
+	ContentHash    string       `json:"content_hash"`
+	IPFSHash       string       `json:"ipfs_hash"`
+	NoiseSignature string       `json:"noise_signature"`
+	ChainID        uint64       `json:"chain_id"`
+	Status         AnchorStatus `json:"status"`
+	TxID           string       `json:"tx_id,omitempty"`   // TxManager submission ID of the batch
+	TxHash         string       `json:"tx_hash,omitempty"` // latest hash the batch was broadcast under
+	BatchSize      int          `json:"batch_size,omitempty"`
+	BlockNumber    uint64       `json:"block_number,omitempty"`
+	Attempts       int          `json:"attempts,omitempty"`
+	Error          string       `json:"error,omitempty"`
+	QueuedAt       time.Time    `json:"queued_at"`
+	SubmittedAt    time.Time    `json:"submitted_at,omitempty"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+// Done reports whether the anchor will not change any more
+func (a *ArtworkAnchor) Done() bool {
+	return a.Status == AnchorConfirmed || a.Status == AnchorFailed
+}
+
+// BatcherConfig sets when queued artworks are flushed
+type BatcherConfig struct {
+	MaxSize       int           // flush as soon as this many artworks are queued (max 50)
+	FlushInterval time.Duration // flush whatever is queued at least this often
+	PollInterval  time.Duration // how often submitted batches are checked
+}
+
+// DefaultBatcherConfig returns the defaults used when no env overrides are set
+func DefaultBatcherConfig() BatcherConfig {
+	return BatcherConfig{
+		MaxSize:       20,
+		FlushInterval: 30 * time.Second,
+		PollInterval:  5 * time.Second,
+	}
+}
+
+// ArtworkBatcher collects artwork registrations and submits them together
+// through ProofOfArt.batchRegisterArtworks
+type ArtworkBatcher struct {
+	registry *ArtworkRegistry
+	store    TxStore
+	cfg      BatcherConfig
+	chainID  uint64
+	prefix   string
+	flushCh  chan struct{}
+	flushMu  sync.Mutex // serializes flushes
+
+	mu       sync.RWMutex
+	anchors  map[string]*ArtworkAnchor // artwork ID -> anchor
+	queue    []string                  // queued artwork IDs, oldest first
+	onUpdate []func(ArtworkAnchor)
+}
+
+// NewArtworkBatcher creates a batcher for registry and reloads anchors from
+// store. Artworks that were still queued are queued again.
+func NewArtworkBatcher(registry *ArtworkRegistry, store TxStore, cfg BatcherConfig) *ArtworkBatcher {
+	if cfg.MaxSize <= 0 || cfg.MaxSize > maxContractBatch {
+		cfg.MaxSize = maxContractBatch
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = DefaultBatcherConfig().FlushInterval
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultBatcherConfig().PollInterval
+	}
+
+	chainID := registry.txm.ChainID().Uint64()
+	b := &ArtworkBatcher{
+		registry: registry,
+		store:    store,
+		cfg:      cfg,
+		chainID:  chainID,
+		prefix:   fmt.Sprintf("/anchor/%d/", chainID),
+		flushCh:  make(chan struct{}, 1),
+		anchors:  make(map[string]*ArtworkAnchor),
+	}
+
+	queued := []*ArtworkAnchor{}
+	for _, key := range store.ListKeys() {
+		if !strings.HasPrefix(key, b.prefix) {
+			continue
+		}
+		val, _ := store.Get(key)
+		if a, ok := val.(*ArtworkAnchor); ok {
+			c := *a
+			b.anchors[c.ArtworkID] = &c
+			if c.Status == AnchorQueued {
+				queued = append(queued, &c)
+			}
+		}
+	}
+	sort.Slice(queued, func(i, j int) bool { return queued[i].QueuedAt.Before(queued[j].QueuedAt) })
+	for _, a := range queued {
+		b.queue = append(b.queue, a.ArtworkID)
+	}
+
+	return b
+}
+
+// OnUpdate registers fn to be called after every anchor status change
+func (b *ArtworkBatcher) OnUpdate(fn func(ArtworkAnchor)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onUpdate = append(b.onUpdate, fn)
+}
+
+// Enqueue adds an artwork to the next batch. Enqueuing an artwork that is
+// already tracked returns its current anchor.
+func (b *ArtworkBatcher) Enqueue(artworkID, contentHash, ipfsHash, noiseSignature string) (*ArtworkAnchor, error) {
+	b.mu.Lock()
+	if a, ok := b.anchors[artworkID]; ok {
+		c := *a
+		b.mu.Unlock()
+		return &c, nil
+	}
+
+	now := time.Now()
+	a := &ArtworkAnchor{
+		ArtworkID:      artworkID,
+		ContentHash:    contentHash,
+		IPFSHash:       ipfsHash,
+		NoiseSignature: noiseSignature,
+		ChainID:        b.chainID,
+		Status:         AnchorQueued,
+		QueuedAt:       now,
+		UpdatedAt:      now,
+	}
+	if err := b.persistLocked(a); err != nil {
+		b.mu.Unlock()
+		return nil, err
+	}
+	b.anchors[artworkID] = a
+	b.queue = append(b.queue, artworkID)
+	full := len(b.queue) >= b.cfg.MaxSize
+	c := *a
+	b.mu.Unlock()
+
+	log.Printf("📥 Artwork %s queued for batch registration (%s)", artworkID, b.registry.ContractAddress().Hex())
+	if full {
+		select {
+		case b.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return &c, nil
+}
+
+// Status returns the anchor for an artwork
+func (b *ArtworkBatcher) Status(artworkID string) (*ArtworkAnchor, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	a, ok := b.anchors[artworkID]
+	if !ok {
+		return nil, false
+	}
+	c := *a
+	return &c, true
+}
+
+// Run flushes on size and time thresholds and follows submitted batches
+// until ctx is cancelled
+func (b *ArtworkBatcher) Run(ctx context.Context) error {
+	flushTicker := time.NewTicker(b.cfg.FlushInterval)
+	defer flushTicker.Stop()
+	pollTicker := time.NewTicker(b.cfg.PollInterval)
+	defer pollTicker.Stop()
+
+	// Pick up state that changed while the server was down
+	b.Poll()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.flushCh:
+			b.flushAll(ctx)
+		case <-flushTicker.C:
+			b.flushAll(ctx)
+		case <-pollTicker.C:
+			b.Poll()
+		}
+	}
+}
+
+// flushAll submits batches until the queue is empty or a submission fails
+func (b *ArtworkBatcher) flushAll(ctx context.Context) {
+	for {
+		n, err := b.Flush(ctx)
+		if err != nil {
+			log.Printf("⚠️  Artwork batch failed: %v", err)
+			return
+		}
+		if n == 0 {
+			return
+		}
+	}
+}
+
+// Flush submits up to MaxSize queued artworks in one batchRegisterArtworks
+// transaction and returns how many were taken from the queue
+func (b *ArtworkBatcher) Flush(ctx context.Context) (int, error) {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	n := len(b.queue)
+	if n > b.cfg.MaxSize {
+		n = b.cfg.MaxSize
+	}
+	ids := append([]string(nil), b.queue[:n]...)
+	b.queue = b.queue[n:]
+	batch := make([]*ArtworkAnchor, 0, n)
+	for _, id := range ids {
+		batch = append(batch, b.anchors[id])
+	}
+	b.mu.Unlock()
+	if n == 0 {
+		return 0, nil
+	}
+
+	// One invalid entry reverts the whole batch, so drop them up front
+	batch = b.precheck(ctx, batch)
+	if len(batch) == 0 {
+		return n, nil
+	}
+
+	artworkIDs := make([]string, len(batch))
+	contentHashes := make([]string, len(batch))
+	ipfsHashes := make([]string, len(batch))
+	noiseSignatures := make([]string, len(batch))
+	for i, a := range batch {
+		artworkIDs[i] = a.ArtworkID
+		contentHashes[i] = a.ContentHash
+		ipfsHashes[i] = a.IPFSHash
+		noiseSignatures[i] = a.NoiseSignature
+	}
+
+	rec, err := b.registry.BatchRegisterArtworks(ctx, artworkIDs, contentHashes, ipfsHashes, noiseSignatures)
+	if err != nil {
+		b.requeue(batch, err)
+		return n, err
+	}
+
+	now := time.Now()
+	for _, a := range batch {
+		b.update(a.ArtworkID, func(a *ArtworkAnchor) {
+			a.Status = AnchorSubmitted
+			a.TxID = rec.ID
+			a.TxHash = rec.Hash
+			a.BatchSize = len(batch)
+			a.Attempts++
+			a.Error = ""
+			a.SubmittedAt = now
+		})
+	}
+	log.Printf("📦 Submitted batch of %d artworks: %s", len(batch), rec.Hash)
+	return n, nil
+}
+
+// precheck fails anchors that the contract would reject: IDs or content
+// hashes that are already registered, or repeated within the batch
+func (b *ArtworkBatcher) precheck(ctx context.Context, batch []*ArtworkAnchor) []*ArtworkAnchor {
+	opts := &bind.CallOpts{Context: ctx}
+	seen := map[string]bool{}
+	valid := make([]*ArtworkAnchor, 0, len(batch))
+
+	for _, a := range batch {
+		var reason string
+		if seen[a.ContentHash] {
+			reason = "content hash repeated in batch"
+		} else if proof, err := b.registry.contract.ArtProofs(opts, a.ArtworkID); err == nil && proof.Exists {
+			reason = "artwork already registered"
+		} else if registered, err := b.registry.contract.IsHashRegistered(opts, a.ContentHash); err == nil && registered {
+			reason = "content hash already registered"
+		}
+
+		if reason != "" {
+			b.update(a.ArtworkID, func(a *ArtworkAnchor) {
+				a.Status = AnchorFailed
+				a.Error = reason
+			})
+			continue
+		}
+		seen[a.ContentHash] = true
+		valid = append(valid, a)
+	}
+	return valid
+}
+
+// requeue puts a batch back at the front of the queue after a failed send,
+// failing artworks that ran out of attempts
+func (b *ArtworkBatcher) requeue(batch []*ArtworkAnchor, sendErr error) {
+	retry := []string{}
+	for _, a := range batch {
+		b.update(a.ArtworkID, func(a *ArtworkAnchor) {
+			a.Attempts++
+			a.Error = sendErr.Error()
+			if a.Attempts >= maxSubmitAttempts {
+				a.Status = AnchorFailed
+			}
+		})
+		if a, ok := b.Status(a.ArtworkID); ok && a.Status == AnchorQueued {
+			retry = append(retry, a.ArtworkID)
+		}
+	}
+
+	b.mu.Lock()
+	b.queue = append(retry, b.queue...)
+	b.mu.Unlock()
+}
+
+// Poll copies the TxManager state of every submitted batch onto its anchors
+func (b *ArtworkBatcher) Poll() {
+	b.mu.RLock()
+	active := map[string][]string{} // batch tx ID -> artwork IDs
+	for _, a := range b.anchors {
+		if a.TxID != "" && !a.Done() {
+			active[a.TxID] = append(active[a.TxID], a.ArtworkID)
+		}
+	}
+	b.mu.RUnlock()
+
+	for txID, ids := range active {
+		rec, ok := b.registry.txm.Status(txID)
+		if !ok {
+			continue
+		}
+		for _, id := range ids {
+			b.update(id, func(a *ArtworkAnchor) {
+				a.TxHash = rec.Hash
+				a.BlockNumber = rec.BlockNumber
+				switch rec.Status {
+				case TxPending, TxReorged:
+					a.Status = AnchorSubmitted
+					a.BlockNumber = 0
+				case TxMined:
+					a.Status = AnchorMined
+					if rec.Final {
+						a.Status = AnchorConfirmed
+					}
+				case TxFailed:
+					a.Status = AnchorFailed
+					a.Error = "batch transaction reverted"
+				}
+			})
+		}
+	}
+}
+
+// update applies fn to an anchor and, if anything changed, persists it and
+// notifies OnUpdate listeners
+func (b *ArtworkBatcher) update(artworkID string, fn func(a *ArtworkAnchor)) {
+	b.mu.Lock()
+	a, ok := b.anchors[artworkID]
+	if !ok {
+		b.mu.Unlock()
+		return
+	}
+	next := *a
+	fn(&next)
+	if next == *a {
+		b.mu.Unlock()
+		return
+	}
+	next.UpdatedAt = time.Now()
+	if err := b.persistLocked(&next); err != nil {
+		log.Printf("⚠️  Failed to persist anchor for %s: %v", artworkID, err)
+	}
+	b.anchors[artworkID] = &next
+	listeners := append([]func(ArtworkAnchor){}, b.onUpdate...)
+	b.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(next)
+	}
+}
+
+// persistLocked writes an anchor to the store. Caller must hold b.mu.
+func (b *ArtworkBatcher) persistLocked(a *ArtworkAnchor) error {
+	c := *a
+	return b.store.Save(b.prefix+a.ArtworkID, &c)
+}
@@ -0,0 +1,130 @@
+package eth
+
+import (
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// EventIterator walks the logs of one contract event returned by a FilterX
+// call, decoding each as it goes. It behaves like the iterators abigen
+// generates.
+type EventIterator[T any] struct {
+	Event *T // event containing the contract specifics and raw log
+
+	parse func(types.Log) (*T, error)
+	logs  chan types.Log
+	sub   ethereum.Subscription
+	done  bool
+	fail  error
+}
+
+// Next advances the iterator to the subsequent event, returning whether there
+// are any more events found. In case of a retrieval or parsing error, false is
+// returned and Error() can be queried for the exact failure.
+func (it *EventIterator[T]) Next() bool {
+	if it.fail != nil {
+		return false
+	}
+	if it.done {
+		select {
+		case log := <-it.logs:
+			return it.decode(log)
+		default:
+			return false
+		}
+	}
+	select {
+	case log := <-it.logs:
+		return it.decode(log)
+	case err := <-it.sub.Err():
+		it.done = true
+		it.fail = err
+		return it.Next()
+	}
+}
+
+// decode parses log into it.Event
+func (it *EventIterator[T]) decode(log types.Log) bool {
+	ev, err := it.parse(log)
+	if err != nil {
+		it.fail = err
+		return false
+	}
+	it.Event = ev
+	return true
+}
+
+// Error returns any retrieval or parsing error occurred during filtering
+func (it *EventIterator[T]) Error() error {
+	return it.fail
+}
+
+// Close terminates the iteration process, releasing any pending underlying
+// resources
+func (it *EventIterator[T]) Close() error {
+	it.sub.Unsubscribe()
+	return nil
+}
+
+// filterEvent runs FilterLogs for one event and wraps the result in an
+// EventIterator
+func filterEvent[T any](contract *bind.BoundContract, opts *bind.FilterOpts, name string, parse func(types.Log) (*T, error), query ...[]interface{}) (*EventIterator[T], error) {
+	logs, sub, err := contract.FilterLogs(opts, name, query...)
+	if err != nil {
+		return nil, err
+	}
+	return &EventIterator[T]{parse: parse, logs: logs, sub: sub}, nil
+}
+
+// watchEvent subscribes to one event and delivers decoded events to sink
+// until the subscription is cancelled
+func watchEvent[T any](contract *bind.BoundContract, opts *bind.WatchOpts, name string, sink chan<- *T, parse func(types.Log) (*T, error), query ...[]interface{}) (event.Subscription, error) {
+	logs, sub, err := contract.WatchLogs(opts, name, query...)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				ev, err := parse(log)
+				if err != nil {
+					return err
+				}
+				select {
+				case sink <- ev:
+				case err := <-sub.Err():
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// addressRule converts an indexed address filter into a topic query
+func addressRule(addrs []common.Address) []interface{} {
+	rule := make([]interface{}, 0, len(addrs))
+	for _, a := range addrs {
+		rule = append(rule, a)
+	}
+	return rule
+}
+
+// stringRule converts an indexed string filter into a topic query
+func stringRule(values []string) []interface{} {
+	rule := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		rule = append(rule, v)
+	}
+	return rule
+}
@@ -0,0 +1,479 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// ChainConfig describes one chain the server can anchor to
+type ChainConfig struct {
+	Name              string  `json:"name"`
+	ChainID           uint64  `json:"chain_id,omitempty"`             // checked against the RPC when set
+	RPCURL            string  `json:"rpc_url"`                        // ${VAR} references are expanded from the environment
+	PrivateKeyEnv     string  `json:"private_key_env,omitempty"`      // env var holding the signing key, defaults to PRIVATE_KEY
+	ProvenanceAddress string  `json:"provenance_address,omitempty"`   // ImageProvenance contract
+	ProofOfArtAddress string  `json:"proof_of_art_address,omitempty"` // ProofOfArt contract
+	ExplorerTxURL     string  `json:"explorer_tx_url,omitempty"`      // e.g. https://sepolia.etherscan.io/tx/{hash}
+	Confirmations     *uint64 `json:"confirmations,omitempty"`        // overrides TX_CONFIRMATIONS
+	StartBlock        uint64  `json:"start_block,omitempty"`          // first block the indexer scans
+	ManifestAnchoring string  `json:"manifest_anchoring,omitempty"`   // "direct" or "merkle", overrides MANIFEST_ANCHORING
+}
+
+// ChainsConfig is the format of the CHAINS_CONFIG file
+type ChainsConfig struct {
+	Default string        `json:"default"`
+	Chains  []ChainConfig `json:"chains"`
+}
+
+// knownChains supplies a name and explorer for common chain IDs when the
+// config does not set them
+var knownChains = map[uint64]struct{ name, explorer string }{
+	1:        {"mainnet", "https://etherscan.io/tx/{hash}"},
+	11155111: {"sepolia", "https://sepolia.etherscan.io/tx/{hash}"},
+	10:       {"optimism", "https://optimistic.etherscan.io/tx/{hash}"},
+	11155420: {"optimism-sepolia", "https://sepolia-optimism.etherscan.io/tx/{hash}"},
+	8453:     {"base", "https://basescan.org/tx/{hash}"},
+	84532:    {"base-sepolia", "https://sepolia.basescan.org/tx/{hash}"},
+	42161:    {"arbitrum", "https://arbiscan.io/tx/{hash}"},
+	421614:   {"arbitrum-sepolia", "https://sepolia.arbiscan.io/tx/{hash}"},
+	137:      {"polygon", "https://polygonscan.com/tx/{hash}"},
+	80002:    {"polygon-amoy", "https://amoy.polygonscan.com/tx/{hash}"},
+}
+
+// Chain bundles the connection, signer, contract bindings and indexer for
+// one configured chain
+type Chain struct {
+	Name          string
+	ChainID       *big.Int
+	ExplorerTxURL string
+	TxManager     *TxManager          // nil when no signing key is configured
+	Artworks      *ArtworkRegistry    // nil when ProofOfArt is not configured
+	Provenance    *ProvenanceRegistry // nil when ImageProvenance is not configured
+	Indexer       *Indexer            // nil when no contract is configured
+	Batcher       *ArtworkBatcher     // nil when Artworks is nil
+	Manifests     *ManifestBatcher    // nil unless manifests are anchored in Merkle batches
+
+	client *ethclient.Client
+}
+
+// TxURL returns the block explorer link for a transaction hash on this
+// chain, or "" when no explorer is configured
+func (c *Chain) TxURL(hash string) string {
+	if c.ExplorerTxURL == "" || hash == "" {
+		return ""
+	}
+	if strings.Contains(c.ExplorerTxURL, "{hash}") {
+		return strings.ReplaceAll(c.ExplorerTxURL, "{hash}", hash)
+	}
+	return strings.TrimSuffix(c.ExplorerTxURL, "/") + "/" + hash
+}
+
+// Close releases the chain's RPC connection
+func (c *Chain) Close() {
+	if c.client != nil {
+		c.client.Close()
+	}
+}
+
+// OpenChain dials cfg.RPCURL and builds the chain's transaction manager,
+// contract bindings, batchers and indexer. Parts whose key or address is not
+// configured are left nil.
+func OpenChain(ctx context.Context, cfg ChainConfig, store IndexStore, txCfg TxManagerConfig, ixCfg IndexerConfig, batchCfg BatcherConfig, manifestCfg ManifestBatcherConfig) (*Chain, error) {
+	rpcURL := os.ExpandEnv(cfg.RPCURL)
+	if rpcURL == "" {
+		return nil, fmt.Errorf("rpc_url is required")
+	}
+
+	client, err := ethclient.DialContext(ctx, rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ethereum client: %w", err)
+	}
+
+	chain, err := openChain(ctx, client, cfg, store, txCfg, ixCfg, batchCfg, manifestCfg)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return chain, nil
+}
+
+// openChain does the work of OpenChain on an established connection
+func openChain(ctx context.Context, client *ethclient.Client, cfg ChainConfig, store IndexStore, txCfg TxManagerConfig, ixCfg IndexerConfig, batchCfg BatcherConfig, manifestCfg ManifestBatcherConfig) (*Chain, error) {
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chain ID: %w", err)
+	}
+	if cfg.ChainID != 0 && cfg.ChainID != chainID.Uint64() {
+		return nil, fmt.Errorf("rpc_url serves chain %s, expected %d", chainID, cfg.ChainID)
+	}
+
+	chain := &Chain{
+		Name:          cfg.Name,
+		ChainID:       chainID,
+		ExplorerTxURL: cfg.ExplorerTxURL,
+		client:        client,
+	}
+	if known, ok := knownChains[chainID.Uint64()]; ok {
+		if chain.Name == "" {
+			chain.Name = known.name
+		}
+		if chain.ExplorerTxURL == "" {
+			chain.ExplorerTxURL = known.explorer
+		}
+	}
+	if chain.Name == "" {
+		chain.Name = "chain-" + chainID.String()
+	}
+
+	var provenance, proofOfArt common.Address
+	if cfg.ProvenanceAddress != "" {
+		if !common.IsHexAddress(cfg.ProvenanceAddress) {
+			return nil, fmt.Errorf("invalid provenance_address: %s", cfg.ProvenanceAddress)
+		}
+		provenance = common.HexToAddress(cfg.ProvenanceAddress)
+	}
+	if cfg.ProofOfArtAddress != "" {
+		if !common.IsHexAddress(cfg.ProofOfArtAddress) {
+			return nil, fmt.Errorf("invalid proof_of_art_address: %s", cfg.ProofOfArtAddress)
+		}
+		proofOfArt = common.HexToAddress(cfg.ProofOfArtAddress)
+	}
+
+	keyEnv := cfg.PrivateKeyEnv
+	if keyEnv == "" {
+		keyEnv = "PRIVATE_KEY"
+	}
+	if privateKeyHex := os.Getenv(keyEnv); privateKeyHex != "" {
+		privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(privateKeyHex, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key in %s: %w", keyEnv, err)
+		}
+		if cfg.Confirmations != nil {
+			txCfg.Confirmations = *cfg.Confirmations
+		}
+		chain.TxManager = NewTxManager(client, privateKey, chainID, store, txCfg)
+
+		if proofOfArt != (common.Address{}) {
+			if chain.Artworks, err = NewArtworkRegistry(chain.TxManager, proofOfArt); err != nil {
+				return nil, err
+			}
+			chain.Batcher = NewArtworkBatcher(chain.Artworks, store, batchCfg)
+		}
+		if provenance != (common.Address{}) {
+			if chain.Provenance, err = NewProvenanceRegistry(chain.TxManager, provenance); err != nil {
+				return nil, err
+			}
+			switch cfg.ManifestAnchoring {
+			case "merkle":
+				manifestCfg.Enabled = true
+			case "direct":
+				manifestCfg.Enabled = false
+			case "":
+			default:
+				return nil, fmt.Errorf("invalid manifest_anchoring %q, expected direct or merkle", cfg.ManifestAnchoring)
+			}
+			if manifestCfg.Enabled {
+				chain.Manifests = NewManifestBatcher(chain.Provenance, store, manifestCfg)
+			}
+		}
+	}
+
+	if provenance != (common.Address{}) || proofOfArt != (common.Address{}) {
+		ixCfg.ChainID = chainID
+		ixCfg.Provenance = provenance
+		ixCfg.ProofOfArt = proofOfArt
+		if cfg.StartBlock != 0 {
+			ixCfg.StartBlock = cfg.StartBlock
+		}
+		if chain.Indexer, err = NewIndexer(client, store, ixCfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return chain, nil
+}
+
+// ChainRegistry holds every configured chain by name
+type ChainRegistry struct {
+	chains      map[string]*Chain
+	names       []string
+	defaultName string
+}
+
+// NewChainRegistry groups chains under their names. defaultName selects the
+// chain used when a request does not name one; "" picks the first chain.
+func NewChainRegistry(defaultName string, chains ...*Chain) (*ChainRegistry, error) {
+	r := &ChainRegistry{chains: make(map[string]*Chain)}
+	for _, chain := range chains {
+		if _, dup := r.chains[chain.Name]; dup {
+			return nil, fmt.Errorf("duplicate chain name %q", chain.Name)
+		}
+		r.chains[chain.Name] = chain
+		r.names = append(r.names, chain.Name)
+	}
+
+	if defaultName == "" && len(r.names) > 0 {
+		defaultName = r.names[0]
+	}
+	if defaultName != "" {
+		if _, ok := r.chains[defaultName]; !ok {
+			return nil, fmt.Errorf("default chain %q is not configured", defaultName)
+		}
+	}
+	r.defaultName = defaultName
+	return r, nil
+}
+
+// LoadChainRegistry opens the chains listed in the CHAINS_CONFIG JSON file.
+// Without CHAINS_CONFIG a single chain is built from RPC_URL, PRIVATE_KEY,
+// CONTRACT_ADDRESS, PROOF_OF_ART_ADDRESS, CHAIN_NAME and EXPLORER_TX_URL;
+// with neither the registry is empty. Chains that fail to connect are logged
+// and skipped.
+func LoadChainRegistry(ctx context.Context, store IndexStore) (*ChainRegistry, error) {
+	var cfg ChainsConfig
+	if path := os.Getenv("CHAINS_CONFIG"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CHAINS_CONFIG: %w", err)
+		}
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse CHAINS_CONFIG: %w", err)
+		}
+	} else if rpcURL := os.Getenv("RPC_URL"); rpcURL != "" {
+		cfg.Chains = []ChainConfig{{
+			Name:              os.Getenv("CHAIN_NAME"),
+			RPCURL:            rpcURL,
+			ProvenanceAddress: os.Getenv("CONTRACT_ADDRESS"),
+			ProofOfArtAddress: os.Getenv("PROOF_OF_ART_ADDRESS"),
+			ExplorerTxURL:     os.Getenv("EXPLORER_TX_URL"),
+		}}
+	}
+
+	txCfg := txManagerConfigFromEnv()
+	ixCfg := indexerConfigFromEnv()
+	batchCfg := batcherConfigFromEnv()
+	manifestCfg := manifestBatcherConfigFromEnv()
+
+	chains := []*Chain{}
+	for _, cc := range cfg.Chains {
+		chain, err := OpenChain(ctx, cc, store, txCfg, ixCfg, batchCfg, manifestCfg)
+		if err != nil {
+			log.Printf("⚠️  Chain %q disabled: %v", cc.Name, err)
+			continue
+		}
+		chains = append(chains, chain)
+	}
+
+	r, err := NewChainRegistry("", chains...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Default != "" {
+		// Never fall back to another chain for requests that omit one
+		if _, ok := r.chains[cfg.Default]; !ok {
+			log.Printf("⚠️  Default chain %q is not available, requests must name a chain", cfg.Default)
+		}
+		r.defaultName = cfg.Default
+	}
+	return r, nil
+}
+
+// Get returns a chain by name; "" selects the default chain
+func (r *ChainRegistry) Get(name string) (*Chain, bool) {
+	if name == "" {
+		name = r.defaultName
+	}
+	chain, ok := r.chains[name]
+	return chain, ok
+}
+
+// Default returns the default chain name, or "" when there is none
+func (r *ChainRegistry) Default() string {
+	return r.defaultName
+}
+
+// Chains returns every chain in configuration order
+func (r *ChainRegistry) Chains() []*Chain {
+	out := make([]*Chain, 0, len(r.names))
+	for _, name := range r.names {
+		out = append(out, r.chains[name])
+	}
+	return out
+}
+
+// Names returns the configured chain names, sorted
+func (r *ChainRegistry) Names() []string {
+	names := append([]string(nil), r.names...)
+	sort.Strings(names)
+	return names
+}
+
+// FindTransaction looks up a transaction submitted by this server on any
+// chain and returns the chain it was sent on together with its live details
+func (r *ChainRegistry) FindTransaction(ctx context.Context, hash string) (*Chain, *TxDetails, bool, error) {
+	for _, chain := range r.Chains() {
+		if chain.TxManager == nil {
+			continue
+		}
+		if _, ok := chain.TxManager.Status(hash); !ok {
+			continue
+		}
+		details, found, err := chain.TxManager.Details(ctx, hash)
+		return chain, details, found, err
+	}
+	return nil, nil, false, nil
+}
+
+// Run drives every chain's transaction manager, batchers and indexer until
+// ctx is cancelled
+func (r *ChainRegistry) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, chain := range r.Chains() {
+		chain := chain
+		if chain.TxManager != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := chain.TxManager.Run(ctx); err != nil && err != context.Canceled {
+					log.Printf("⚠️  Transaction manager error (%s): %v", chain.Name, err)
+				}
+			}()
+		}
+		if chain.Batcher != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := chain.Batcher.Run(ctx); err != nil && err != context.Canceled {
+					log.Printf("⚠️  Artwork batcher error (%s): %v", chain.Name, err)
+				}
+			}()
+		}
+		if chain.Manifests != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := chain.Manifests.Run(ctx); err != nil && err != context.Canceled {
+					log.Printf("⚠️  Manifest batcher error (%s): %v", chain.Name, err)
+				}
+			}()
+		}
+		if chain.Indexer != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := chain.Indexer.Run(ctx); err != nil && err != context.Canceled {
+					log.Printf("⚠️  Chain indexer error (%s): %v", chain.Name, err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// Close releases every chain's RPC connection
+func (r *ChainRegistry) Close() {
+	for _, chain := range r.chains {
+		chain.Close()
+	}
+}
+
+// txManagerConfigFromEnv applies TX_CONFIRMATIONS, TX_POLL_INTERVAL,
+// TX_STUCK_AFTER, TX_FEE_BUMP_PERCENT and TX_MAX_FEE_GWEI to the defaults
+func txManagerConfigFromEnv() TxManagerConfig {
+	cfg := DefaultTxManagerConfig()
+	if v := os.Getenv("TX_CONFIRMATIONS"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			cfg.Confirmations = n
+		}
+	}
+	if v := os.Getenv("TX_POLL_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.PollInterval = d
+		}
+	}
+	if v := os.Getenv("TX_STUCK_AFTER"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.StuckAfter = d
+		}
+	}
+	if v := os.Getenv("TX_FEE_BUMP_PERCENT"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 10 {
+			cfg.FeeBumpPercent = n
+		}
+	}
+	if v := os.Getenv("TX_MAX_FEE_GWEI"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			cfg.MaxFeeCap = new(big.Int).Mul(big.NewInt(n), big.NewInt(1_000_000_000))
+		}
+	}
+	return cfg
+}
+
+// indexerConfigFromEnv reads INDEXER_START_BLOCK, INDEXER_BATCH_BLOCKS and
+// INDEXER_POLL_INTERVAL
+func indexerConfigFromEnv() IndexerConfig {
+	cfg := IndexerConfig{}
+	if v := os.Getenv("INDEXER_START_BLOCK"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			cfg.StartBlock = n
+		}
+	}
+	if v := os.Getenv("INDEXER_BATCH_BLOCKS"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil && n > 0 {
+			cfg.BatchSize = n
+		}
+	}
+	if v := os.Getenv("INDEXER_POLL_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.PollInterval = d
+		}
+	}
+	return cfg
+}
+
+// batcherConfigFromEnv applies BATCH_MAX_SIZE and BATCH_FLUSH_INTERVAL to the defaults
+func batcherConfigFromEnv() BatcherConfig {
+	cfg := DefaultBatcherConfig()
+	if v := os.Getenv("BATCH_MAX_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxSize = n
+		}
+	}
+	if v := os.Getenv("BATCH_FLUSH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.FlushInterval = d
+		}
+	}
+	return cfg
+}
+
+// manifestBatcherConfigFromEnv reads MANIFEST_ANCHORING ("direct" or
+// "merkle"), MANIFEST_BATCH_MAX_SIZE and MANIFEST_BATCH_INTERVAL
+func manifestBatcherConfigFromEnv() ManifestBatcherConfig {
+	cfg := DefaultManifestBatcherConfig()
+	cfg.Enabled = os.Getenv("MANIFEST_ANCHORING") == "merkle"
+	if v := os.Getenv("MANIFEST_BATCH_MAX_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxSize = n
+		}
+	}
+	if v := os.Getenv("MANIFEST_BATCH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.FlushInterval = d
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,174 @@
+package eth
+
+import (
+	_ "embed"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// ImageProvenanceABI is the ABI of contracts/ImageProvenance.sol, written to
+// abi.json by scripts/deploy.js and embedded at build time
+//
+//go:embed abi.json
+var ImageProvenanceABI string
+
+// ImageProvenanceMetaData holds the parsed ImageProvenance ABI
+var ImageProvenanceMetaData = &bind.MetaData{ABI: ImageProvenanceABI}
+
+// ImageProvenanceManifest mirrors the ImageProvenance.Manifest struct
+type ImageProvenanceManifest struct {
+	Cid       string         `json:"cid"`
+	Creator   common.Address `json:"creator"`
+	Timestamp *big.Int       `json:"timestamp"`
+}
+
+// ImageProvenance is a typed binding for the ImageProvenance contract
+type ImageProvenance struct {
+	ImageProvenanceCaller     // read-only contract methods
+	ImageProvenanceTransactor // state-changing contract methods
+	ImageProvenanceFilterer   // contract event filters
+
+	address common.Address
+}
+
+// ImageProvenanceCaller binds the read-only ImageProvenance methods
+type ImageProvenanceCaller struct {
+	contract *bind.BoundContract
+}
+
+// ImageProvenanceTransactor binds the state-changing ImageProvenance methods
+type ImageProvenanceTransactor struct {
+	contract *bind.BoundContract
+}
+
+// ImageProvenanceFilterer binds the ImageProvenance events
+type ImageProvenanceFilterer struct {
+	contract *bind.BoundContract
+}
+
+// NewImageProvenance binds to a deployed ImageProvenance contract
+func NewImageProvenance(address common.Address, backend bind.ContractBackend) (*ImageProvenance, error) {
+	contract, err := bindImageProvenance(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &ImageProvenance{
+		ImageProvenanceCaller:     ImageProvenanceCaller{contract: contract},
+		ImageProvenanceTransactor: ImageProvenanceTransactor{contract: contract},
+		ImageProvenanceFilterer:   ImageProvenanceFilterer{contract: contract},
+		address:                   address,
+	}, nil
+}
+
+// NewImageProvenanceCaller binds the read-only methods of a deployed contract
+func NewImageProvenanceCaller(address common.Address, caller bind.ContractCaller) (*ImageProvenanceCaller, error) {
+	contract, err := bindImageProvenance(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ImageProvenanceCaller{contract: contract}, nil
+}
+
+// NewImageProvenanceTransactor binds the state-changing methods of a deployed contract
+func NewImageProvenanceTransactor(address common.Address, transactor bind.ContractTransactor) (*ImageProvenanceTransactor, error) {
+	contract, err := bindImageProvenance(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ImageProvenanceTransactor{contract: contract}, nil
+}
+
+// NewImageProvenanceFilterer binds the events of a deployed contract
+func NewImageProvenanceFilterer(address common.Address, filterer bind.ContractFilterer) (*ImageProvenanceFilterer, error) {
+	contract, err := bindImageProvenance(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &ImageProvenanceFilterer{contract: contract}, nil
+}
+
+// bindImageProvenance binds a generic wrapper to an already deployed contract
+func bindImageProvenance(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := ImageProvenanceMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Address returns the address the binding is attached to
+func (p *ImageProvenance) Address() common.Address {
+	return p.address
+}
+
+// GetAll calls getAll() returns (Manifest[])
+func (p *ImageProvenanceCaller) GetAll(opts *bind.CallOpts) ([]ImageProvenanceManifest, error) {
+	var out []interface{}
+	if err := p.contract.Call(opts, &out, "getAll"); err != nil {
+		return nil, err
+	}
+	return *abi.ConvertType(out[0], new([]ImageProvenanceManifest)).(*[]ImageProvenanceManifest), nil
+}
+
+// GetCount calls getCount() returns (uint256)
+func (p *ImageProvenanceCaller) GetCount(opts *bind.CallOpts) (*big.Int, error) {
+	var out []interface{}
+	if err := p.contract.Call(opts, &out, "getCount"); err != nil {
+		return nil, err
+	}
+	return *abi.ConvertType(out[0], new(*big.Int)).(**big.Int), nil
+}
+
+// Manifests calls manifests(uint256) returns (string cid, address creator, uint256 timestamp)
+func (p *ImageProvenanceCaller) Manifests(opts *bind.CallOpts, index *big.Int) (ImageProvenanceManifest, error) {
+	var out []interface{}
+	if err := p.contract.Call(opts, &out, "manifests", index); err != nil {
+		return ImageProvenanceManifest{}, err
+	}
+	return ImageProvenanceManifest{
+		Cid:       *abi.ConvertType(out[0], new(string)).(*string),
+		Creator:   *abi.ConvertType(out[1], new(common.Address)).(*common.Address),
+		Timestamp: *abi.ConvertType(out[2], new(*big.Int)).(**big.Int),
+	}, nil
+}
+
+// StoreManifest submits storeManifest(string _cid)
+func (p *ImageProvenanceTransactor) StoreManifest(opts *bind.TransactOpts, cid string) (*types.Transaction, error) {
+	return p.contract.Transact(opts, "storeManifest", cid)
+}
+
+// ImageProvenanceManifestStored is a ManifestStored event raised by the
+// ImageProvenance contract
+type ImageProvenanceManifestStored struct {
+	Creator   common.Address `json:"creator"`
+	Cid       string         `json:"cid"`
+	Timestamp *big.Int       `json:"timestamp"`
+	Raw       types.Log      `json:"-"` // blockchain specific contextual infos
+}
+
+// FilterManifestStored retrieves ManifestStored(address indexed creator, string cid, uint256 timestamp)
+// logs, optionally restricted to the given creators
+func (p *ImageProvenanceFilterer) FilterManifestStored(opts *bind.FilterOpts, creator []common.Address) (*EventIterator[ImageProvenanceManifestStored], error) {
+	return filterEvent(p.contract, opts, "ManifestStored", p.ParseManifestStored, addressRule(creator))
+}
+
+// WatchManifestStored subscribes to ManifestStored logs, optionally
+// restricted to the given creators
+func (p *ImageProvenanceFilterer) WatchManifestStored(opts *bind.WatchOpts, sink chan<- *ImageProvenanceManifestStored, creator []common.Address) (event.Subscription, error) {
+	return watchEvent(p.contract, opts, "ManifestStored", sink, p.ParseManifestStored, addressRule(creator))
+}
+
+// ParseManifestStored decodes a ManifestStored log
+func (p *ImageProvenanceFilterer) ParseManifestStored(log types.Log) (*ImageProvenanceManifestStored, error) {
+	ev := new(ImageProvenanceManifestStored)
+	if err := p.contract.UnpackLog(ev, "ManifestStored", log); err != nil {
+		return nil, err
+	}
+	ev.Raw = log
+	return ev, nil
+}
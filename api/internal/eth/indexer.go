@@ -0,0 +1,457 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// maxCheckpoints bounds how far back a reorg can be rolled back without a
+// full re-index
+const maxCheckpoints = 128
+
+// ManifestEvent is an indexed ImageProvenance.ManifestStored log
+type ManifestEvent struct {
+	Creator     common.Address `json:"creator"`
+	CID         string         `json:"cid"`
+	Timestamp   uint64         `json:"timestamp"`
+	BlockNumber uint64         `json:"block_number"`
+	BlockHash   string         `json:"block_hash"`
+	TxHash      string         `json:"tx_hash"`
+	LogIndex    uint           `json:"log_index"`
+}
+
+// ArtworkEvent is an indexed ProofOfArt.ArtworkRegistered log. artworkId is
+// an indexed string, so only its keccak256 is in the log; ArtworkID is
+// recovered from the transaction calldata when possible.
+type ArtworkEvent struct {
+	ArtworkID     string         `json:"artwork_id,omitempty"`
+	ArtworkIDHash common.Hash    `json:"artwork_id_hash"`
+	Artist        common.Address `json:"artist"`
+	ContentHash   string         `json:"content_hash"`
+	IPFSHash      string         `json:"ipfs_hash"`
+	Timestamp     uint64         `json:"timestamp"`
+	BlockNumber   uint64         `json:"block_number"`
+	BlockHash     string         `json:"block_hash"`
+	TxHash        string         `json:"tx_hash"`
+	LogIndex      uint           `json:"log_index"`
+}
+
+// BlockRef is a block number/hash pair used as a reorg checkpoint
+type BlockRef struct {
+	Number uint64 `json:"number"`
+	Hash   string `json:"hash"`
+}
+
+// IndexCheckpoint is the persisted progress of an Indexer, newest last
+type IndexCheckpoint struct {
+	Blocks []BlockRef `json:"blocks"`
+}
+
+// IndexStore persists indexed events (satisfied by ipfsdb.Store)
+type IndexStore interface {
+	Save(key string, value interface{}) error
+	Get(key string) (interface{}, bool)
+	Delete(key string) error
+	ListKeys() []string
+}
+
+// IndexerBackend is the chain access the indexer needs. Both
+// *ethclient.Client and go-ethereum's simulated backend implement it.
+type IndexerBackend interface {
+	ethereum.LogFilterer
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+}
+
+// IndexerConfig sets the contracts to index and how to walk the chain
+type IndexerConfig struct {
+	ChainID      *big.Int
+	Provenance   common.Address // ImageProvenance, zero to skip
+	ProofOfArt   common.Address // ProofOfArt, zero to skip
+	StartBlock   uint64         // first block to backfill from
+	BatchSize    uint64         // max blocks per eth_getLogs request
+	PollInterval time.Duration
+}
+
+// Indexer backfills and follows ManifestStored and ArtworkRegistered logs,
+// rolling back events from blocks that are reorged out
+type Indexer struct {
+	backend       IndexerBackend
+	store         IndexStore
+	cfg           IndexerConfig
+	provenance    *ImageProvenanceFilterer
+	proofOfArt    *ProofOfArtFilterer
+	manifestTopic common.Hash // ManifestStored event ID
+	artworkTopic  common.Hash // ArtworkRegistered event ID
+	prefix        string
+
+	mu          sync.RWMutex
+	manifests   map[string]*ManifestEvent // store key -> event
+	artworks    map[string]*ArtworkEvent  // store key -> event
+	checkpoints []BlockRef
+}
+
+// NewIndexer creates an indexer and reloads previously indexed events and
+// checkpoints from store
+func NewIndexer(backend IndexerBackend, store IndexStore, cfg IndexerConfig) (*Indexer, error) {
+	provenance, err := NewImageProvenanceFilterer(cfg.Provenance, backend)
+	if err != nil {
+		return nil, err
+	}
+	proofOfArt, err := NewProofOfArtFilterer(cfg.ProofOfArt, backend)
+	if err != nil {
+		return nil, err
+	}
+	provenanceABI, err := ImageProvenanceMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	proofOfArtABI, err := ProofOfArtMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 2000
+	}
+	if cfg.PollInterval == 0 {
+		cfg.PollInterval = 15 * time.Second
+	}
+
+	ix := &Indexer{
+		backend:       backend,
+		store:         store,
+		cfg:           cfg,
+		provenance:    provenance,
+		proofOfArt:    proofOfArt,
+		manifestTopic: provenanceABI.Events["ManifestStored"].ID,
+		artworkTopic:  proofOfArtABI.Events["ArtworkRegistered"].ID,
+		prefix:        fmt.Sprintf("/chain/%s/", cfg.ChainID),
+		manifests:     make(map[string]*ManifestEvent),
+		artworks:      make(map[string]*ArtworkEvent),
+	}
+
+	for _, key := range store.ListKeys() {
+		if !strings.HasPrefix(key, ix.prefix) {
+			continue
+		}
+		val, _ := store.Get(key)
+		switch v := val.(type) {
+		case *ManifestEvent:
+			ix.manifests[key] = v
+		case *ArtworkEvent:
+			ix.artworks[key] = v
+		case *IndexCheckpoint:
+			ix.checkpoints = append([]BlockRef(nil), v.Blocks...)
+		}
+	}
+
+	return ix, nil
+}
+
+// Run indexes until ctx is cancelled
+func (ix *Indexer) Run(ctx context.Context) error {
+	ticker := time.NewTicker(ix.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := ix.Sync(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("⚠️  Chain indexer: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sync rolls back any reorged blocks, then indexes up to the current head
+func (ix *Indexer) Sync(ctx context.Context) error {
+	next, err := ix.handleReorg(ctx)
+	if err != nil {
+		return err
+	}
+
+	head, err := ix.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest header: %w", err)
+	}
+
+	for from := next; from <= head.Number.Uint64(); {
+		to := from + ix.cfg.BatchSize - 1
+		if to > head.Number.Uint64() {
+			to = head.Number.Uint64()
+		}
+		if err := ix.indexRange(ctx, from, to); err != nil {
+			return err
+		}
+		from = to + 1
+	}
+	return nil
+}
+
+// IndexedTo returns the last block covered by the index
+func (ix *Indexer) IndexedTo() (uint64, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if len(ix.checkpoints) == 0 {
+		return 0, false
+	}
+	return ix.checkpoints[len(ix.checkpoints)-1].Number, true
+}
+
+// handleReorg compares the stored checkpoints with the canonical chain,
+// drops events above the newest surviving checkpoint and returns the next
+// block to index
+func (ix *Indexer) handleReorg(ctx context.Context) (uint64, error) {
+	ix.mu.RLock()
+	checkpoints := append([]BlockRef(nil), ix.checkpoints...)
+	ix.mu.RUnlock()
+
+	keep := len(checkpoints)
+	for keep > 0 {
+		cp := checkpoints[keep-1]
+		header, err := ix.backend.HeaderByNumber(ctx, new(big.Int).SetUint64(cp.Number))
+		if err != nil && err != ethereum.NotFound {
+			return 0, fmt.Errorf("failed to get header %d: %w", cp.Number, err)
+		}
+		if err == nil && header.Hash().Hex() == cp.Hash {
+			break
+		}
+		keep--
+	}
+
+	if keep == len(checkpoints) {
+		if keep == 0 {
+			return ix.cfg.StartBlock, nil
+		}
+		return checkpoints[keep-1].Number + 1, nil
+	}
+
+	// Everything above the last matching checkpoint is suspect
+	next := ix.cfg.StartBlock
+	if keep > 0 {
+		next = checkpoints[keep-1].Number + 1
+	}
+	log.Printf("🔀 Chain indexer: reorg detected, rolling back to block %d", next)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for key, ev := range ix.manifests {
+		if ev.BlockNumber >= next {
+			if err := ix.store.Delete(key); err != nil {
+				return 0, err
+			}
+			delete(ix.manifests, key)
+		}
+	}
+	for key, ev := range ix.artworks {
+		if ev.BlockNumber >= next {
+			if err := ix.store.Delete(key); err != nil {
+				return 0, err
+			}
+			delete(ix.artworks, key)
+		}
+	}
+	ix.checkpoints = checkpoints[:keep]
+	if err := ix.persistCheckpointsLocked(); err != nil {
+		return 0, err
+	}
+	return next, nil
+}
+
+// indexRange fetches and stores logs for [from, to] and records a checkpoint
+func (ix *Indexer) indexRange(ctx context.Context, from, to uint64) error {
+	addresses := []common.Address{}
+	if ix.cfg.Provenance != (common.Address{}) {
+		addresses = append(addresses, ix.cfg.Provenance)
+	}
+	if ix.cfg.ProofOfArt != (common.Address{}) {
+		addresses = append(addresses, ix.cfg.ProofOfArt)
+	}
+
+	toBig := new(big.Int).SetUint64(to)
+	before, err := ix.backend.HeaderByNumber(ctx, toBig)
+	if err != nil {
+		return fmt.Errorf("failed to get header %d: %w", to, err)
+	}
+
+	logs, err := ix.backend.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   toBig,
+		Addresses: addresses,
+		Topics:    [][]common.Hash{{ix.manifestTopic, ix.artworkTopic}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to filter logs %d-%d: %w", from, to, err)
+	}
+
+	// If the range tip changed while we were reading, retry on the next sync
+	after, err := ix.backend.HeaderByNumber(ctx, toBig)
+	if err != nil {
+		return fmt.Errorf("failed to get header %d: %w", to, err)
+	}
+	if after.Hash() != before.Hash() {
+		return fmt.Errorf("block %d changed during indexing, retrying", to)
+	}
+
+	manifests := map[string]*ManifestEvent{}
+	artworks := map[string]*ArtworkEvent{}
+	calldata := map[common.Hash][]byte{}
+
+	for _, l := range logs {
+		if l.Removed || len(l.Topics) == 0 {
+			continue
+		}
+		id := fmt.Sprintf("%s-%d", l.TxHash.Hex(), l.Index)
+
+		switch {
+		case l.Address == ix.cfg.Provenance && l.Topics[0] == ix.manifestTopic:
+			ev, err := ix.provenance.ParseManifestStored(l)
+			if err != nil {
+				return fmt.Errorf("tx %s: %w", l.TxHash.Hex(), err)
+			}
+			manifests[ix.prefix+"manifest/"+id] = &ManifestEvent{
+				Creator:     ev.Creator,
+				CID:         ev.Cid,
+				Timestamp:   ev.Timestamp.Uint64(),
+				BlockNumber: l.BlockNumber,
+				BlockHash:   l.BlockHash.Hex(),
+				TxHash:      l.TxHash.Hex(),
+				LogIndex:    l.Index,
+			}
+
+		case l.Address == ix.cfg.ProofOfArt && l.Topics[0] == ix.artworkTopic:
+			ev, err := ix.decodeArtworkRegistered(ctx, l, calldata)
+			if err != nil {
+				return fmt.Errorf("tx %s: %w", l.TxHash.Hex(), err)
+			}
+			artworks[ix.prefix+"artwork/"+id] = ev
+		}
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for key, ev := range manifests {
+		if err := ix.store.Save(key, ev); err != nil {
+			return err
+		}
+		ix.manifests[key] = ev
+	}
+	for key, ev := range artworks {
+		if err := ix.store.Save(key, ev); err != nil {
+			return err
+		}
+		ix.artworks[key] = ev
+	}
+
+	ix.checkpoints = append(ix.checkpoints, BlockRef{Number: to, Hash: after.Hash().Hex()})
+	if len(ix.checkpoints) > maxCheckpoints {
+		ix.checkpoints = ix.checkpoints[len(ix.checkpoints)-maxCheckpoints:]
+	}
+	return ix.persistCheckpointsLocked()
+}
+
+// decodeArtworkRegistered unpacks an ArtworkRegistered log and recovers the
+// plain artworkId from the registerArtwork/batchRegisterArtworks calldata
+func (ix *Indexer) decodeArtworkRegistered(ctx context.Context, l types.Log, calldata map[common.Hash][]byte) (*ArtworkEvent, error) {
+	parsed, err := ix.proofOfArt.ParseArtworkRegistered(l)
+	if err != nil {
+		return nil, err
+	}
+
+	ev := &ArtworkEvent{
+		ArtworkIDHash: parsed.ArtworkId,
+		Artist:        parsed.Artist,
+		ContentHash:   parsed.ContentHash,
+		IPFSHash:      parsed.IpfsHash,
+		Timestamp:     parsed.Timestamp.Uint64(),
+		BlockNumber:   l.BlockNumber,
+		BlockHash:     l.BlockHash.Hex(),
+		TxHash:        l.TxHash.Hex(),
+		LogIndex:      l.Index,
+	}
+
+	input, ok := calldata[l.TxHash]
+	if !ok {
+		tx, _, err := ix.backend.TransactionByHash(ctx, l.TxHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transaction: %w", err)
+		}
+		input = tx.Data()
+		calldata[l.TxHash] = input
+	}
+
+	// Calls routed through another contract leave ArtworkID empty
+	if ids, err := RegisteredArtworkIDs(input); err == nil {
+		for _, id := range ids {
+			if crypto.Keccak256Hash([]byte(id)) == ev.ArtworkIDHash {
+				ev.ArtworkID = id
+				break
+			}
+		}
+	}
+
+	return ev, nil
+}
+
+// persistCheckpointsLocked saves the checkpoint list. Caller must hold ix.mu.
+func (ix *Indexer) persistCheckpointsLocked() error {
+	return ix.store.Save(ix.prefix+"checkpoint", &IndexCheckpoint{Blocks: append([]BlockRef(nil), ix.checkpoints...)})
+}
+
+// Manifests returns indexed ManifestStored events, optionally filtered by
+// creator, in chain order
+func (ix *Indexer) Manifests(creator *common.Address) []ManifestEvent {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	out := []ManifestEvent{}
+	for _, ev := range ix.manifests {
+		if creator == nil || ev.Creator == *creator {
+			out = append(out, *ev)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].BlockNumber != out[j].BlockNumber {
+			return out[i].BlockNumber < out[j].BlockNumber
+		}
+		return out[i].LogIndex < out[j].LogIndex
+	})
+	return out
+}
+
+// Artworks returns indexed ArtworkRegistered events, optionally filtered by
+// artist, in chain order
+func (ix *Indexer) Artworks(artist *common.Address) []ArtworkEvent {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	out := []ArtworkEvent{}
+	for _, ev := range ix.artworks {
+		if artist == nil || ev.Artist == *artist {
+			out = append(out, *ev)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].BlockNumber != out[j].BlockNumber {
+			return out[i].BlockNumber < out[j].BlockNumber
+		}
+		return out[i].LogIndex < out[j].LogIndex
+	})
+	return out
+}
@@ -0,0 +1,401 @@
+package eth
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"yourproject/internal/crypto"
+)
+
+// MerkleRootPrefix marks a storeManifest value that is the BLAKE3 Merkle
+// root of a manifest batch rather than a single manifest CID
+const MerkleRootPrefix = "blake3-merkle:"
+
+// ManifestLeaf returns the Merkle leaf hash of a manifest CID
+func ManifestLeaf(cid string) [32]byte {
+	return crypto.MerkleLeaf([]byte(cid))
+}
+
+// ManifestBatch is one Merkle root anchored with a single storeManifest call
+type ManifestBatch struct {
+	Root        string       `json:"root"` // hex BLAKE3 Merkle root
+	ChainID     uint64       `json:"chain_id"`
+	CIDs        []string     `json:"cids"` // leaves, in tree order
+	Status      AnchorStatus `json:"status"`
+	TxID        string       `json:"tx_id,omitempty"`
+	TxHash      string       `json:"tx_hash,omitempty"`
+	BlockNumber uint64       `json:"block_number,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// Anchored returns the value passed to storeManifest for this batch
+func (b *ManifestBatch) Anchored() string {
+	return MerkleRootPrefix + b.Root
+}
+
+// ManifestAnchor places one manifest CID in a batch. Root is empty while the
+// manifest waits for the next batch.
+type ManifestAnchor struct {
+	CID      string    `json:"cid"`
+	ChainID  uint64    `json:"chain_id"`
+	Root     string    `json:"root,omitempty"`
+	Index    int       `json:"index"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// ManifestProof is everything a third party needs to check offline that a
+// manifest CID is covered by an anchored root
+type ManifestProof struct {
+	CID   string              `json:"cid"`
+	Leaf  string              `json:"leaf"` // hex BLAKE3(0x00 || cid)
+	Index int                 `json:"index"`
+	Proof []crypto.MerkleStep `json:"proof"`
+	Batch *ManifestBatch      `json:"batch"`
+}
+
+// ManifestBatcherConfig sets when queued manifests are rolled into a root
+type ManifestBatcherConfig struct {
+	Enabled       bool          // anchor /upload manifests in Merkle batches instead of one tx each
+	MaxSize       int           // flush as soon as this many manifests are queued
+	FlushInterval time.Duration // flush whatever is queued at least this often
+	PollInterval  time.Duration // how often submitted roots are checked
+}
+
+// DefaultManifestBatcherConfig returns the defaults used when no env
+// overrides are set
+func DefaultManifestBatcherConfig() ManifestBatcherConfig {
+	return ManifestBatcherConfig{
+		MaxSize:       256,
+		FlushInterval: time.Minute,
+		PollInterval:  5 * time.Second,
+	}
+}
+
+// ManifestBatcher collects manifest CIDs, anchors the Merkle root of each
+// window through ImageProvenance.storeManifest and serves inclusion proofs
+type ManifestBatcher struct {
+	registry *ProvenanceRegistry
+	store    TxStore
+	cfg      ManifestBatcherConfig
+	chainID  uint64
+	flushCh  chan struct{}
+	flushMu  sync.Mutex // serializes flushes
+
+	mu      sync.RWMutex
+	anchors map[string]*ManifestAnchor // CID -> anchor
+	batches map[string]*ManifestBatch  // root -> batch
+	queue   []string                   // queued CIDs, oldest first
+}
+
+// NewManifestBatcher creates a batcher for registry and reloads batches and
+// queued manifests from store
+func NewManifestBatcher(registry *ProvenanceRegistry, store TxStore, cfg ManifestBatcherConfig) *ManifestBatcher {
+	def := DefaultManifestBatcherConfig()
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = def.MaxSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = def.FlushInterval
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = def.PollInterval
+	}
+
+	b := &ManifestBatcher{
+		registry: registry,
+		store:    store,
+		cfg:      cfg,
+		chainID:  registry.txm.ChainID().Uint64(),
+		flushCh:  make(chan struct{}, 1),
+		anchors:  make(map[string]*ManifestAnchor),
+		batches:  make(map[string]*ManifestBatch),
+	}
+
+	queued := []*ManifestAnchor{}
+	for _, key := range store.ListKeys() {
+		switch {
+		case strings.HasPrefix(key, b.anchorPrefix()):
+			val, _ := store.Get(key)
+			if a, ok := val.(*ManifestAnchor); ok {
+				c := *a
+				b.anchors[c.CID] = &c
+				if c.Root == "" {
+					queued = append(queued, &c)
+				}
+			}
+		case strings.HasPrefix(key, b.batchPrefix()):
+			val, _ := store.Get(key)
+			if mb, ok := val.(*ManifestBatch); ok {
+				c := *mb
+				b.batches[c.Root] = &c
+			}
+		}
+	}
+	sort.Slice(queued, func(i, j int) bool { return queued[i].QueuedAt.Before(queued[j].QueuedAt) })
+	for _, a := range queued {
+		b.queue = append(b.queue, a.CID)
+	}
+
+	return b
+}
+
+func (b *ManifestBatcher) anchorPrefix() string {
+	return fmt.Sprintf("/manifest-anchor/%d/", b.chainID)
+}
+
+func (b *ManifestBatcher) batchPrefix() string {
+	return fmt.Sprintf("/manifest-batch/%d/", b.chainID)
+}
+
+// Enqueue adds a manifest CID to the next batch. Enqueuing a CID that is
+// already tracked is a no-op.
+func (b *ManifestBatcher) Enqueue(cid string) error {
+	b.mu.Lock()
+	if _, ok := b.anchors[cid]; ok {
+		b.mu.Unlock()
+		return nil
+	}
+
+	a := &ManifestAnchor{CID: cid, ChainID: b.chainID, QueuedAt: time.Now()}
+	if err := b.store.Save(b.anchorPrefix()+cid, a); err != nil {
+		b.mu.Unlock()
+		return err
+	}
+	c := *a
+	b.anchors[cid] = &c
+	b.queue = append(b.queue, cid)
+	full := len(b.queue) >= b.cfg.MaxSize
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Status returns the anchor of a manifest CID and, once it has been rolled
+// into a root, its batch
+func (b *ManifestBatcher) Status(cid string) (*ManifestAnchor, *ManifestBatch, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	a, ok := b.anchors[cid]
+	if !ok {
+		return nil, nil, false
+	}
+	ac := *a
+	if mb, ok := b.batches[a.Root]; ok {
+		bc := *mb
+		return &ac, &bc, true
+	}
+	return &ac, nil, true
+}
+
+// Proof builds the inclusion proof of a manifest CID. It fails while the
+// manifest is still queued.
+func (b *ManifestBatcher) Proof(cid string) (*ManifestProof, error) {
+	a, batch, ok := b.Status(cid)
+	if !ok {
+		return nil, fmt.Errorf("manifest %s is not tracked", cid)
+	}
+	if batch == nil {
+		return nil, fmt.Errorf("manifest %s is queued for the next batch", cid)
+	}
+
+	tree, err := manifestTree(batch.CIDs)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := tree.Proof(a.Index)
+	if err != nil {
+		return nil, err
+	}
+	leaf := ManifestLeaf(cid)
+	return &ManifestProof{
+		CID:   cid,
+		Leaf:  hex.EncodeToString(leaf[:]),
+		Index: a.Index,
+		Proof: proof,
+		Batch: batch,
+	}, nil
+}
+
+// Run flushes on size and time thresholds and follows submitted roots until
+// ctx is cancelled
+func (b *ManifestBatcher) Run(ctx context.Context) error {
+	flushTicker := time.NewTicker(b.cfg.FlushInterval)
+	defer flushTicker.Stop()
+	pollTicker := time.NewTicker(b.cfg.PollInterval)
+	defer pollTicker.Stop()
+
+	b.Poll()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.flushCh:
+			b.flushAll(ctx)
+		case <-flushTicker.C:
+			b.flushAll(ctx)
+		case <-pollTicker.C:
+			b.Poll()
+		}
+	}
+}
+
+// flushAll anchors batches until the queue is empty or a submission fails
+func (b *ManifestBatcher) flushAll(ctx context.Context) {
+	for {
+		n, err := b.Flush(ctx)
+		if err != nil {
+			log.Printf("⚠️  Manifest batch failed: %v", err)
+			return
+		}
+		if n == 0 {
+			return
+		}
+	}
+}
+
+// Flush anchors the Merkle root of up to MaxSize queued manifests and
+// returns how many were included
+func (b *ManifestBatcher) Flush(ctx context.Context) (int, error) {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	n := len(b.queue)
+	if n > b.cfg.MaxSize {
+		n = b.cfg.MaxSize
+	}
+	cids := append([]string(nil), b.queue[:n]...)
+	b.queue = b.queue[n:]
+	b.mu.Unlock()
+	if n == 0 {
+		return 0, nil
+	}
+
+	tree, err := manifestTree(cids)
+	if err != nil {
+		b.requeue(cids)
+		return 0, err
+	}
+	root := tree.Root()
+	batch := &ManifestBatch{
+		Root:      hex.EncodeToString(root[:]),
+		ChainID:   b.chainID,
+		CIDs:      cids,
+		Status:    AnchorSubmitted,
+		CreatedAt: time.Now(),
+	}
+
+	rec, err := b.registry.StoreManifest(ctx, batch.Anchored())
+	if err != nil {
+		b.requeue(cids)
+		return 0, err
+	}
+	batch.TxID = rec.ID
+	batch.TxHash = rec.Hash
+	batch.UpdatedAt = time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.store.Save(b.batchPrefix()+batch.Root, batch); err != nil {
+		log.Printf("⚠️  Failed to persist manifest batch %s: %v", batch.Root, err)
+	}
+	b.batches[batch.Root] = batch
+	for i, cid := range cids {
+		a := *b.anchors[cid]
+		a.Root = batch.Root
+		a.Index = i
+		if err := b.store.Save(b.anchorPrefix()+cid, &a); err != nil {
+			log.Printf("⚠️  Failed to persist manifest anchor %s: %v", cid, err)
+		}
+		b.anchors[cid] = &a
+	}
+
+	log.Printf("🌳 Anchored Merkle root of %d manifests: %s (tx %s)", len(cids), batch.Root, rec.Hash)
+	return n, nil
+}
+
+// requeue puts manifests back at the front of the queue
+func (b *ManifestBatcher) requeue(cids []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queue = append(append([]string(nil), cids...), b.queue...)
+}
+
+// Poll copies the TxManager state of every unconfirmed root onto its batch.
+// Manifests of a reverted root are queued again.
+func (b *ManifestBatcher) Poll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for root, batch := range b.batches {
+		if batch.Status == AnchorConfirmed || batch.Status == AnchorFailed {
+			continue
+		}
+		rec, ok := b.registry.txm.Status(batch.TxID)
+		if !ok {
+			continue
+		}
+
+		next := *batch
+		next.TxHash = rec.Hash
+		next.BlockNumber = rec.BlockNumber
+		switch rec.Status {
+		case TxPending, TxReorged:
+			next.Status = AnchorSubmitted
+			next.BlockNumber = 0
+		case TxMined:
+			next.Status = AnchorMined
+			if rec.Final {
+				next.Status = AnchorConfirmed
+			}
+		case TxFailed:
+			next.Status = AnchorFailed
+		}
+		if next.Status == batch.Status && next.TxHash == batch.TxHash && next.BlockNumber == batch.BlockNumber {
+			continue
+		}
+
+		next.UpdatedAt = time.Now()
+		if err := b.store.Save(b.batchPrefix()+root, &next); err != nil {
+			log.Printf("⚠️  Failed to persist manifest batch %s: %v", root, err)
+		}
+		b.batches[root] = &next
+
+		if next.Status == AnchorFailed {
+			log.Printf("⚠️  Manifest root %s reverted, queueing %d manifests again", root, len(next.CIDs))
+			for _, cid := range next.CIDs {
+				a := *b.anchors[cid]
+				a.Root, a.Index = "", 0
+				if err := b.store.Save(b.anchorPrefix()+cid, &a); err != nil {
+					log.Printf("⚠️  Failed to persist manifest anchor %s: %v", cid, err)
+				}
+				b.anchors[cid] = &a
+			}
+			b.queue = append(append([]string(nil), next.CIDs...), b.queue...)
+		}
+	}
+}
+
+// manifestTree builds the Merkle tree over manifest CIDs
+func manifestTree(cids []string) (*crypto.MerkleTree, error) {
+	leaves := make([][32]byte, len(cids))
+	for i, cid := range cids {
+		leaves[i] = ManifestLeaf(cid)
+	}
+	return crypto.NewMerkleTree(leaves)
+}
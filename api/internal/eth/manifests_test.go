@@ -0,0 +1,112 @@
+package eth
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	pcrypto "yourproject/internal/crypto"
+)
+
+func TestManifestBatcherProofs(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	contractAddress := common.HexToAddress("0x00000000000000000000000000000000000a1701")
+
+	// storeManifest only has to succeed: a contract that stops at once will do
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		from:            {Balance: new(big.Int).Mul(big.NewInt(1e18), big.NewInt(100))},
+		contractAddress: {Code: []byte{0x00}},
+	}, 30_000_000)
+	defer sim.Close()
+
+	cfg := DefaultTxManagerConfig()
+	cfg.Confirmations = 1
+	txm := NewTxManager(sim, key, big.NewInt(1337), &memTxStore{data: map[string]interface{}{}}, cfg)
+	registry, err := NewProvenanceRegistry(txm, contractAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &memTxStore{data: map[string]interface{}{}}
+	batcher := NewManifestBatcher(registry, store, ManifestBatcherConfig{Enabled: true, MaxSize: 4})
+
+	// Seven manifests make a full batch of four and an odd one of three
+	cids := make([]string, 7)
+	for i := range cids {
+		cids[i] = fmt.Sprintf("bafyreimanifest%d", i)
+		if err := batcher.Enqueue(cids[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := batcher.Proof(cids[0]); err == nil {
+		t.Error("proof of a queued manifest built")
+	}
+	for _, want := range []int{4, 3, 0} {
+		if n, err := batcher.Flush(ctx); err != nil || n != want {
+			t.Fatalf("Flush = %d, %v; want %d", n, err, want)
+		}
+	}
+	sim.Commit()
+	txm.Poll(ctx)
+	batcher.Poll()
+
+	parsed, err := ImageProvenanceMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cid := range cids {
+		proof, err := batcher.Proof(cid)
+		if err != nil {
+			t.Fatalf("Proof(%s): %v", cid, err)
+		}
+		if proof.Batch.Status != AnchorConfirmed {
+			t.Errorf("%s: batch %s, want confirmed", cid, proof.Batch.Status)
+		}
+
+		// Check the proof the way a third party would: from the CID, the
+		// proof and the storeManifest call of the anchoring transaction
+		leaf := ManifestLeaf(cid)
+		if proof.Leaf != hex.EncodeToString(leaf[:]) {
+			t.Errorf("%s: leaf %s", cid, proof.Leaf)
+		}
+		tx, _, err := sim.TransactionByHash(ctx, common.HexToHash(proof.Batch.TxHash))
+		if err != nil {
+			t.Fatalf("%s: anchoring tx %s: %v", cid, proof.Batch.TxHash, err)
+		}
+		args, err := parsed.Methods["storeManifest"].Inputs.Unpack(tx.Data()[4:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		anchored, _ := args[0].(string)
+		if anchored != MerkleRootPrefix+proof.Batch.Root {
+			t.Errorf("%s: anchored %q, batch root %s", cid, anchored, proof.Batch.Root)
+		}
+		var root [32]byte
+		hex.Decode(root[:], []byte(proof.Batch.Root))
+		if !pcrypto.VerifyMerkleProof(leaf, proof.Proof, root) {
+			t.Errorf("%s: proof does not verify against the anchored root", cid)
+		}
+		if other := ManifestLeaf(cid + "x"); pcrypto.VerifyMerkleProof(other, proof.Proof, root) {
+			t.Errorf("%s: proof verifies another CID", cid)
+		}
+	}
+
+	// Batches and anchors survive a restart
+	reloaded := NewManifestBatcher(registry, store, ManifestBatcherConfig{Enabled: true, MaxSize: 4})
+	for _, cid := range cids {
+		if _, err := reloaded.Proof(cid); err != nil {
+			t.Errorf("after reload, Proof(%s): %v", cid, err)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package eth
+
+import (
+	_ "embed"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// ProofOfArtABI is the ABI of SmartContract/ProofOfArt.sol
+//
+//go:embed proofofart_abi.json
+var ProofOfArtABI string
+
+// ProofOfArtMetaData holds the parsed ProofOfArt ABI
+var ProofOfArtMetaData = &bind.MetaData{ABI: ProofOfArtABI}
+
+// ArtProof mirrors the ProofOfArt.ArtProof struct
+type ArtProof struct {
+	ArtworkId      string
+	ArtistWallet   common.Address
+	ContentHash    string
+	IpfsHash       string
+	NoiseSignature string
+	Timestamp      *big.Int
+	Exists         bool
+}
+
+// ProofOfArt is a typed binding for the ProofOfArt contract
+type ProofOfArt struct {
+	ProofOfArtCaller     // read-only contract methods
+	ProofOfArtTransactor // state-changing contract methods
+	ProofOfArtFilterer   // contract event filters
+
+	address common.Address
+}
+
+// ProofOfArtCaller binds the read-only ProofOfArt methods
+type ProofOfArtCaller struct {
+	contract *bind.BoundContract
+}
+
+// ProofOfArtTransactor binds the state-changing ProofOfArt methods
+type ProofOfArtTransactor struct {
+	contract *bind.BoundContract
+}
+
+// ProofOfArtFilterer binds the ProofOfArt events
+type ProofOfArtFilterer struct {
+	contract *bind.BoundContract
+}
+
+// NewProofOfArt binds to a deployed ProofOfArt contract. Any
+// bind.ContractBackend works, including ethclient and the simulated backend.
+func NewProofOfArt(address common.Address, backend bind.ContractBackend) (*ProofOfArt, error) {
+	contract, err := bindProofOfArt(address, backend, backend, backend)
+	if err != nil {
+		return nil, err
+	}
+	return &ProofOfArt{
+		ProofOfArtCaller:     ProofOfArtCaller{contract: contract},
+		ProofOfArtTransactor: ProofOfArtTransactor{contract: contract},
+		ProofOfArtFilterer:   ProofOfArtFilterer{contract: contract},
+		address:              address,
+	}, nil
+}
+
+// NewProofOfArtCaller binds the read-only methods of a deployed contract
+func NewProofOfArtCaller(address common.Address, caller bind.ContractCaller) (*ProofOfArtCaller, error) {
+	contract, err := bindProofOfArt(address, caller, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ProofOfArtCaller{contract: contract}, nil
+}
+
+// NewProofOfArtTransactor binds the state-changing methods of a deployed contract
+func NewProofOfArtTransactor(address common.Address, transactor bind.ContractTransactor) (*ProofOfArtTransactor, error) {
+	contract, err := bindProofOfArt(address, nil, transactor, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ProofOfArtTransactor{contract: contract}, nil
+}
+
+// NewProofOfArtFilterer binds the events of a deployed contract
+func NewProofOfArtFilterer(address common.Address, filterer bind.ContractFilterer) (*ProofOfArtFilterer, error) {
+	contract, err := bindProofOfArt(address, nil, nil, filterer)
+	if err != nil {
+		return nil, err
+	}
+	return &ProofOfArtFilterer{contract: contract}, nil
+}
+
+// bindProofOfArt binds a generic wrapper to an already deployed contract
+func bindProofOfArt(address common.Address, caller bind.ContractCaller, transactor bind.ContractTransactor, filterer bind.ContractFilterer) (*bind.BoundContract, error) {
+	parsed, err := ProofOfArtMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewBoundContract(address, *parsed, caller, transactor, filterer), nil
+}
+
+// Address returns the address the binding is attached to
+func (p *ProofOfArt) Address() common.Address {
+	return p.address
+}
+
+// ArtistArtworks calls artistArtworks(address, uint256) returns (string)
+func (p *ProofOfArtCaller) ArtistArtworks(opts *bind.CallOpts, artist common.Address, index *big.Int) (string, error) {
+	var out []interface{}
+	if err := p.contract.Call(opts, &out, "artistArtworks", artist, index); err != nil {
+		return "", err
+	}
+	return *abi.ConvertType(out[0], new(string)).(*string), nil
+}
+
+// ArtProofs calls artProofs(string) and returns the stored proof, which is
+// zero-valued for unregistered IDs
+func (p *ProofOfArtCaller) ArtProofs(opts *bind.CallOpts, artworkID string) (ArtProof, error) {
+	var out []interface{}
+	if err := p.contract.Call(opts, &out, "artProofs", artworkID); err != nil {
+		return ArtProof{}, err
+	}
+	return ArtProof{
+		ArtworkId:      *abi.ConvertType(out[0], new(string)).(*string),
+		ArtistWallet:   *abi.ConvertType(out[1], new(common.Address)).(*common.Address),
+		ContentHash:    *abi.ConvertType(out[2], new(string)).(*string),
+		IpfsHash:       *abi.ConvertType(out[3], new(string)).(*string),
+		NoiseSignature: *abi.ConvertType(out[4], new(string)).(*string),
+		Timestamp:      *abi.ConvertType(out[5], new(*big.Int)).(**big.Int),
+		Exists:         *abi.ConvertType(out[6], new(bool)).(*bool),
+	}, nil
+}
+
+// GetArtistArtworks calls getArtistArtworks(address) returns (string[])
+func (p *ProofOfArtCaller) GetArtistArtworks(opts *bind.CallOpts, artist common.Address) ([]string, error) {
+	var out []interface{}
+	if err := p.contract.Call(opts, &out, "getArtistArtworks", artist); err != nil {
+		return nil, err
+	}
+	return *abi.ConvertType(out[0], new([]string)).(*[]string), nil
+}
+
+// GetArtworkArtist calls getArtworkArtist(string) returns (address). The call
+// reverts with "Artwork not found" for unregistered IDs.
+func (p *ProofOfArtCaller) GetArtworkArtist(opts *bind.CallOpts, artworkID string) (common.Address, error) {
+	var out []interface{}
+	if err := p.contract.Call(opts, &out, "getArtworkArtist", artworkID); err != nil {
+		return common.Address{}, err
+	}
+	return *abi.ConvertType(out[0], new(common.Address)).(*common.Address), nil
+}
+
+// GetArtworkProof calls getArtworkProof(string). The call reverts with
+// "Artwork not found" for unregistered IDs.
+func (p *ProofOfArtCaller) GetArtworkProof(opts *bind.CallOpts, artworkID string) (ArtProof, error) {
+	var out []interface{}
+	if err := p.contract.Call(opts, &out, "getArtworkProof", artworkID); err != nil {
+		return ArtProof{}, err
+	}
+	return *abi.ConvertType(out[0], new(ArtProof)).(*ArtProof), nil
+}
+
+// IsHashRegistered calls isHashRegistered(string) returns (bool)
+func (p *ProofOfArtCaller) IsHashRegistered(opts *bind.CallOpts, contentHash string) (bool, error) {
+	var out []interface{}
+	if err := p.contract.Call(opts, &out, "isHashRegistered", contentHash); err != nil {
+		return false, err
+	}
+	return *abi.ConvertType(out[0], new(bool)).(*bool), nil
+}
+
+// RegisteredHashes calls registeredHashes(string) returns (bool)
+func (p *ProofOfArtCaller) RegisteredHashes(opts *bind.CallOpts, contentHash string) (bool, error) {
+	var out []interface{}
+	if err := p.contract.Call(opts, &out, "registeredHashes", contentHash); err != nil {
+		return false, err
+	}
+	return *abi.ConvertType(out[0], new(bool)).(*bool), nil
+}
+
+// VerifyOwnership calls verifyOwnership(string, address) returns (bool)
+func (p *ProofOfArtCaller) VerifyOwnership(opts *bind.CallOpts, artworkID string, artist common.Address) (bool, error) {
+	var out []interface{}
+	if err := p.contract.Call(opts, &out, "verifyOwnership", artworkID, artist); err != nil {
+		return false, err
+	}
+	return *abi.ConvertType(out[0], new(bool)).(*bool), nil
+}
+
+// RegisterArtwork submits registerArtwork(string, string, string, string)
+func (p *ProofOfArtTransactor) RegisterArtwork(opts *bind.TransactOpts, artworkID, contentHash, ipfsHash, noiseSignature string) (*types.Transaction, error) {
+	return p.contract.Transact(opts, "registerArtwork", artworkID, contentHash, ipfsHash, noiseSignature)
+}
+
+// BatchRegisterArtworks submits batchRegisterArtworks(string[], string[], string[], string[]).
+// All four slices must have the same length.
+func (p *ProofOfArtTransactor) BatchRegisterArtworks(opts *bind.TransactOpts, artworkIDs, contentHashes, ipfsHashes, noiseSignatures []string) (*types.Transaction, error) {
+	return p.contract.Transact(opts, "batchRegisterArtworks", artworkIDs, contentHashes, ipfsHashes, noiseSignatures)
+}
+
+// VerifyArtwork submits verifyArtwork(string), which records a
+// VerificationRequested event. Use GetArtworkProof to read a proof without a
+// transaction.
+func (p *ProofOfArtTransactor) VerifyArtwork(opts *bind.TransactOpts, artworkID string) (*types.Transaction, error) {
+	return p.contract.Transact(opts, "verifyArtwork", artworkID)
+}
+
+// ProofOfArtArtworkRegistered is an ArtworkRegistered event raised by the
+// ProofOfArt contract. ArtworkId is an indexed string, so the log only
+// carries its keccak256 hash.
+type ProofOfArtArtworkRegistered struct {
+	ArtworkId   common.Hash    `json:"artwork_id_hash"`
+	Artist      common.Address `json:"artist"`
+	ContentHash string         `json:"content_hash"`
+	IpfsHash    string         `json:"ipfs_hash"`
+	Timestamp   *big.Int       `json:"timestamp"`
+	Raw         types.Log      `json:"-"` // blockchain specific contextual infos
+}
+
+// FilterArtworkRegistered retrieves ArtworkRegistered logs, optionally
+// restricted to the given artwork IDs and artists
+func (p *ProofOfArtFilterer) FilterArtworkRegistered(opts *bind.FilterOpts, artworkID []string, artist []common.Address) (*EventIterator[ProofOfArtArtworkRegistered], error) {
+	return filterEvent(p.contract, opts, "ArtworkRegistered", p.ParseArtworkRegistered, stringRule(artworkID), addressRule(artist))
+}
+
+// WatchArtworkRegistered subscribes to ArtworkRegistered logs, optionally
+// restricted to the given artwork IDs and artists
+func (p *ProofOfArtFilterer) WatchArtworkRegistered(opts *bind.WatchOpts, sink chan<- *ProofOfArtArtworkRegistered, artworkID []string, artist []common.Address) (event.Subscription, error) {
+	return watchEvent(p.contract, opts, "ArtworkRegistered", sink, p.ParseArtworkRegistered, stringRule(artworkID), addressRule(artist))
+}
+
+// ParseArtworkRegistered decodes an ArtworkRegistered log
+func (p *ProofOfArtFilterer) ParseArtworkRegistered(log types.Log) (*ProofOfArtArtworkRegistered, error) {
+	ev := new(ProofOfArtArtworkRegistered)
+	if err := p.contract.UnpackLog(ev, "ArtworkRegistered", log); err != nil {
+		return nil, err
+	}
+	ev.Raw = log
+	return ev, nil
+}
+
+// ProofOfArtVerificationRequested is a VerificationRequested event raised by
+// the ProofOfArt contract
+type ProofOfArtVerificationRequested struct {
+	ArtworkId common.Hash    `json:"artwork_id_hash"`
+	Requester common.Address `json:"requester"`
+	Timestamp *big.Int       `json:"timestamp"`
+	Raw       types.Log      `json:"-"` // blockchain specific contextual infos
+}
+
+// FilterVerificationRequested retrieves VerificationRequested logs,
+// optionally restricted to the given artwork IDs and requesters
+func (p *ProofOfArtFilterer) FilterVerificationRequested(opts *bind.FilterOpts, artworkID []string, requester []common.Address) (*EventIterator[ProofOfArtVerificationRequested], error) {
+	return filterEvent(p.contract, opts, "VerificationRequested", p.ParseVerificationRequested, stringRule(artworkID), addressRule(requester))
+}
+
+// WatchVerificationRequested subscribes to VerificationRequested logs,
+// optionally restricted to the given artwork IDs and requesters
+func (p *ProofOfArtFilterer) WatchVerificationRequested(opts *bind.WatchOpts, sink chan<- *ProofOfArtVerificationRequested, artworkID []string, requester []common.Address) (event.Subscription, error) {
+	return watchEvent(p.contract, opts, "VerificationRequested", sink, p.ParseVerificationRequested, stringRule(artworkID), addressRule(requester))
+}
+
+// ParseVerificationRequested decodes a VerificationRequested log
+func (p *ProofOfArtFilterer) ParseVerificationRequested(log types.Log) (*ProofOfArtVerificationRequested, error) {
+	ev := new(ProofOfArtVerificationRequested)
+	if err := p.contract.UnpackLog(ev, "VerificationRequested", log); err != nil {
+		return nil, err
+	}
+	ev.Raw = log
+	return ev, nil
+}
+
+// RegisteredArtworkIDs returns the artwork IDs passed to a registerArtwork or
+// batchRegisterArtworks call, given the transaction input. Indexed string
+// topics only carry a hash, so this is how the plain IDs behind
+// ArtworkRegistered events are recovered.
+func RegisteredArtworkIDs(input []byte) ([]string, error) {
+	parsed, err := ProofOfArtMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	if len(input) < 4 {
+		return nil, fmt.Errorf("input too short")
+	}
+	method, err := parsed.MethodById(input[:4])
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := method.Inputs.Unpack(input[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s input: %w", method.Name, err)
+	}
+	switch method.Name {
+	case "registerArtwork":
+		return []string{*abi.ConvertType(args[0], new(string)).(*string)}, nil
+	case "batchRegisterArtworks":
+		return *abi.ConvertType(args[0], new([]string)).(*[]string), nil
+	default:
+		return nil, fmt.Errorf("%s does not register artworks", method.Name)
+	}
+}
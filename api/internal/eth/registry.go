@@ -0,0 +1,130 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ArtworkRegistry registers artwork proofs on the ProofOfArt contract.
+// Transactions are signed and tracked by a TxManager.
+type ArtworkRegistry struct {
+	contract *ProofOfArt
+	txm      *TxManager
+}
+
+// NewArtworkRegistry binds the ProofOfArt contract at contractAddress using
+// the manager's backend (e.g. an ethclient connection or go-ethereum's
+// simulated backend)
+func NewArtworkRegistry(txm *TxManager, contractAddress common.Address) (*ArtworkRegistry, error) {
+	contract, err := NewProofOfArt(contractAddress, txm.Backend())
+	if err != nil {
+		return nil, err
+	}
+	return &ArtworkRegistry{contract: contract, txm: txm}, nil
+}
+
+// From returns the address that signs registration transactions
+func (r *ArtworkRegistry) From() common.Address {
+	return r.txm.From()
+}
+
+// ContractAddress returns the ProofOfArt contract address
+func (r *ArtworkRegistry) ContractAddress() common.Address {
+	return r.contract.Address()
+}
+
+// RegisterArtwork submits registerArtwork for a single artwork. It returns as
+// soon as the transaction is broadcast; the TxManager tracks its receipt.
+func (r *ArtworkRegistry) RegisterArtwork(ctx context.Context, artworkID, contentHash, ipfsHash, noiseSignature string) (*TxRecord, error) {
+	rec, err := r.txm.Transact(ctx, "registerArtwork", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return r.contract.RegisterArtwork(opts, artworkID, contentHash, ipfsHash, noiseSignature)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("registerArtwork failed: %w", err)
+	}
+
+	log.Printf("✅ Artwork %s registered on-chain: %s", artworkID, rec.Hash)
+	return rec, nil
+}
+
+// BatchRegisterArtworks submits batchRegisterArtworks for up to 50 artworks.
+// The contract reverts the whole batch if any entry is already registered.
+func (r *ArtworkRegistry) BatchRegisterArtworks(ctx context.Context, artworkIDs, contentHashes, ipfsHashes, noiseSignatures []string) (*TxRecord, error) {
+	rec, err := r.txm.Transact(ctx, "batchRegisterArtworks", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return r.contract.BatchRegisterArtworks(opts, artworkIDs, contentHashes, ipfsHashes, noiseSignatures)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("batchRegisterArtworks failed: %w", err)
+	}
+	return rec, nil
+}
+
+// GetArtworkProof reads the registered proof for an artwork
+func (r *ArtworkRegistry) GetArtworkProof(ctx context.Context, artworkID string) (ArtProof, error) {
+	return r.contract.GetArtworkProof(&bind.CallOpts{Context: ctx}, artworkID)
+}
+
+// VerifyOwnership reports whether artist is the registered owner of an artwork
+func (r *ArtworkRegistry) VerifyOwnership(ctx context.Context, artworkID string, artist common.Address) (bool, error) {
+	return r.contract.VerifyOwnership(&bind.CallOpts{Context: ctx}, artworkID, artist)
+}
+
+// ProvenanceRegistry anchors manifest CIDs on the ImageProvenance contract
+type ProvenanceRegistry struct {
+	contract *ImageProvenance
+	txm      *TxManager
+}
+
+// NewProvenanceRegistry binds the ImageProvenance contract at contractAddress
+func NewProvenanceRegistry(txm *TxManager, contractAddress common.Address) (*ProvenanceRegistry, error) {
+	contract, err := NewImageProvenance(contractAddress, txm.Backend())
+	if err != nil {
+		return nil, err
+	}
+	return &ProvenanceRegistry{contract: contract, txm: txm}, nil
+}
+
+// ContractAddress returns the ImageProvenance contract address
+func (r *ProvenanceRegistry) ContractAddress() common.Address {
+	return r.contract.Address()
+}
+
+// StoreManifest submits storeManifest(cid). It returns as soon as the
+// transaction is broadcast; the TxManager tracks its receipt.
+func (r *ProvenanceRegistry) StoreManifest(ctx context.Context, cid string) (*TxRecord, error) {
+	rec, err := r.txm.Transact(ctx, "storeManifest", func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return r.contract.StoreManifest(opts, cid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storeManifest failed: %w", err)
+	}
+	return rec, nil
+}
+
+// ManifestStoredEvents decodes every ManifestStored log this contract emitted
+// in receipt
+func (r *ProvenanceRegistry) ManifestStoredEvents(receipt *types.Receipt) ([]*ImageProvenanceManifestStored, error) {
+	parsed, err := ImageProvenanceMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	eventID := parsed.Events["ManifestStored"].ID
+
+	events := []*ImageProvenanceManifestStored{}
+	for _, l := range receipt.Logs {
+		if l.Address != r.contract.Address() || len(l.Topics) == 0 || l.Topics[0] != eventID {
+			continue
+		}
+		ev, err := r.contract.ParseManifestStored(*l)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
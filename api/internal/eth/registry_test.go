@@ -0,0 +1,301 @@
+package eth
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// proofOfArtStub is EVM assembly for a stand-in of ProofOfArt's
+// registerArtwork and getArtworkProof. solc is not part of the Go build, so
+// the real contract cannot be compiled here; the stub has the same ABI for
+// those two calls. registerArtwork stores the ABI-encoded ArtProof under
+// keccak256(artworkId) and reverts if it is already there; getArtworkProof
+// returns it and reverts for unknown IDs.
+const proofOfArtStub = `
+	PUSH 0
+	CALLDATALOAD
+	PUSH 224
+	SHR
+	DUP1
+	PUSH %s
+	EQ
+	JUMPI @register
+	PUSH %s
+	EQ
+	JUMPI @getproof
+	PUSH 0
+	DUP1
+	REVERT
+
+register:
+	POP
+	;; memory = abi.encode(ArtProof): the four string offsets move by the
+	;; three extra head words, their tails are copied from the calldata
+	PUSH 32
+	PUSH 0
+	MSTORE
+	PUSH 96
+	PUSH 4
+	CALLDATALOAD
+	ADD
+	PUSH 32
+	MSTORE
+	CALLER
+	PUSH 64
+	MSTORE
+	PUSH 96
+	PUSH 36
+	CALLDATALOAD
+	ADD
+	PUSH 96
+	MSTORE
+	PUSH 96
+	PUSH 68
+	CALLDATALOAD
+	ADD
+	PUSH 128
+	MSTORE
+	PUSH 96
+	PUSH 100
+	CALLDATALOAD
+	ADD
+	PUSH 160
+	MSTORE
+	TIMESTAMP
+	PUSH 192
+	MSTORE
+	PUSH 1
+	PUSH 224
+	MSTORE
+	PUSH 132
+	CALLDATASIZE
+	SUB
+	PUSH 132
+	PUSH 256
+	CALLDATACOPY
+	;; key = keccak256(artworkId)
+	PUSH 128
+	PUSH 4
+	CALLDATALOAD
+	ADD
+	DUP1
+	MLOAD
+	SWAP1
+	PUSH 32
+	ADD
+	KECCAK256
+	DUP1
+	SLOAD
+	ISZERO
+	JUMPI @store
+	PUSH 0
+	DUP1
+	REVERT
+store:
+	;; slot key holds the length, the following slots the words
+	PUSH 124
+	CALLDATASIZE
+	ADD
+	DUP2
+	SSTORE
+	PUSH 0
+storeloop:
+	PUSH 124
+	CALLDATASIZE
+	ADD
+	DUP2
+	LT
+	ISZERO
+	JUMPI @stored
+	DUP1
+	MLOAD
+	DUP2
+	PUSH 32
+	SWAP1
+	DIV
+	DUP4
+	ADD
+	PUSH 1
+	ADD
+	SSTORE
+	PUSH 32
+	ADD
+	JUMP @storeloop
+stored:
+	STOP
+
+getproof:
+	PUSH 4
+	CALLDATALOAD
+	PUSH 4
+	ADD
+	DUP1
+	CALLDATALOAD
+	SWAP1
+	PUSH 32
+	ADD
+	DUP2
+	SWAP1
+	PUSH 0
+	CALLDATACOPY
+	PUSH 0
+	KECCAK256
+	DUP1
+	SLOAD
+	DUP1
+	JUMPI @found
+	PUSH 0
+	DUP1
+	REVERT
+found:
+	PUSH 0
+loadloop:
+	DUP2
+	DUP2
+	LT
+	ISZERO
+	JUMPI @loaded
+	DUP1
+	PUSH 32
+	SWAP1
+	DIV
+	DUP4
+	ADD
+	PUSH 1
+	ADD
+	SLOAD
+	DUP2
+	MSTORE
+	PUSH 32
+	ADD
+	JUMP @loadloop
+loaded:
+	POP
+	PUSH 0
+	RETURN
+`
+
+// memTxStore is an in-memory TxStore
+type memTxStore struct {
+	mu   sync.Mutex
+	data map[string]interface{}
+}
+
+func (s *memTxStore) Save(key string, value interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data[key] = value
+	return nil
+}
+
+func (s *memTxStore) Get(key string) (interface{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.data[key]
+	return v, ok
+}
+
+func (s *memTxStore) ListKeys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// proofOfArtStubCode assembles proofOfArtStub
+func proofOfArtStubCode(t *testing.T) []byte {
+	t.Helper()
+	parsed, err := ProofOfArtMetaData.GetAbi()
+	if err != nil {
+		t.Fatal(err)
+	}
+	source := fmt.Sprintf(proofOfArtStub,
+		"0x"+hex.EncodeToString(parsed.Methods["registerArtwork"].ID),
+		"0x"+hex.EncodeToString(parsed.Methods["getArtworkProof"].ID))
+
+	c := asm.NewCompiler(false)
+	c.Feed(asm.Lex([]byte(source), false))
+	code, errs := c.Compile()
+	if len(errs) != 0 {
+		t.Fatalf("failed to assemble stub: %v", errs)
+	}
+	out, err := hex.DecodeString(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestArtworkRegistrySimulated(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	contractAddress := common.HexToAddress("0x00000000000000000000000000000000000a1700")
+
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		from:            {Balance: new(big.Int).Mul(big.NewInt(1e18), big.NewInt(100))},
+		contractAddress: {Code: proofOfArtStubCode(t)},
+	}, 30_000_000)
+	defer sim.Close()
+
+	cfg := DefaultTxManagerConfig()
+	cfg.Confirmations = 1
+	txm := NewTxManager(sim, key, big.NewInt(1337), &memTxStore{data: map[string]interface{}{}}, cfg)
+	registry, err := NewArtworkRegistry(txm, contractAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		artworkID      = "artwork-7f3a9c2e-41d8-4b6a-9e0f-1c2d3e4f5a6b"
+		contentHash    = "blake2b:3f1e0c9a7b5d4e2f8a6c1b0d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f"
+		ipfsHash       = "bafyreigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi"
+		noiseSignature = "noise-sig"
+	)
+	rec, err := registry.RegisterArtwork(ctx, artworkID, contentHash, ipfsHash, noiseSignature)
+	if err != nil {
+		t.Fatalf("RegisterArtwork: %v", err)
+	}
+	sim.Commit()
+	txm.Poll(ctx)
+
+	status, ok := txm.Status(rec.Hash)
+	if !ok || status.Status != TxMined || !status.Final {
+		t.Fatalf("tx %s: got %+v, want mined and final", rec.Hash, status)
+	}
+
+	proof, err := registry.GetArtworkProof(ctx, artworkID)
+	if err != nil {
+		t.Fatalf("GetArtworkProof: %v", err)
+	}
+	if proof.ArtworkId != artworkID || proof.ContentHash != contentHash || proof.IpfsHash != ipfsHash ||
+		proof.NoiseSignature != noiseSignature || proof.ArtistWallet != from || !proof.Exists {
+		t.Errorf("proof = %+v", proof)
+	}
+	if proof.Timestamp == nil || proof.Timestamp.Sign() == 0 {
+		t.Errorf("proof has no timestamp")
+	}
+
+	if _, err := registry.RegisterArtwork(ctx, artworkID, contentHash, ipfsHash, noiseSignature); err == nil {
+		t.Errorf("registering %s twice succeeded", artworkID)
+	}
+	if _, err := registry.GetArtworkProof(ctx, "unknown"); err == nil {
+		t.Errorf("GetArtworkProof of an unregistered artwork succeeded")
+	}
+}
@@ -0,0 +1,557 @@
+package eth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// TxStatus is the lifecycle state of a submitted transaction
+type TxStatus string
+
+const (
+	TxPending TxStatus = "pending" // broadcast, not yet in a block
+	TxMined   TxStatus = "mined"   // included in a block with a successful receipt
+	TxFailed  TxStatus = "failed"  // included in a block but reverted
+	TxReorged TxStatus = "reorged" // was mined, then dropped from the canonical chain
+)
+
+// txKeyPrefix namespaces transaction records in the TxStore
+const txKeyPrefix = "/tx/"
+
+// TxRecord is the persisted state of one submission. A submission keeps its
+// ID (the hash of the first broadcast) across fee-bump replacements.
+type TxRecord struct {
+	ID              string         `json:"id"`
+	ChainID         uint64         `json:"chain_id"`
+	Label           string         `json:"label"`
+	Hash            string         `json:"hash"`
+	ReplacedHashes  []string       `json:"replaced_hashes,omitempty"`
+	From            common.Address `json:"from"`
+	To              common.Address `json:"to"`
+	Nonce           uint64         `json:"nonce"`
+	Data            []byte         `json:"data"`
+	GasLimit        uint64         `json:"gas_limit"`
+	GasTipCap       *big.Int       `json:"gas_tip_cap"`
+	GasFeeCap       *big.Int       `json:"gas_fee_cap"`
+	Status          TxStatus       `json:"status"`
+	BlockNumber     uint64         `json:"block_number,omitempty"`
+	BlockHash       string         `json:"block_hash,omitempty"`
+	GasUsed         uint64         `json:"gas_used,omitempty"`
+	Confirmations   uint64         `json:"confirmations"`
+	Final           bool           `json:"final"`
+	SubmittedAt     time.Time      `json:"submitted_at"`
+	LastBroadcastAt time.Time      `json:"last_broadcast_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// clone returns a deep copy safe to hand out to other goroutines
+func (r *TxRecord) clone() *TxRecord {
+	c := *r
+	c.ReplacedHashes = append([]string(nil), r.ReplacedHashes...)
+	c.Data = append([]byte(nil), r.Data...)
+	if r.GasTipCap != nil {
+		c.GasTipCap = new(big.Int).Set(r.GasTipCap)
+	}
+	if r.GasFeeCap != nil {
+		c.GasFeeCap = new(big.Int).Set(r.GasFeeCap)
+	}
+	return &c
+}
+
+// hashes returns every hash this submission has been broadcast under
+func (r *TxRecord) hashes() []string {
+	return append([]string{r.Hash}, r.ReplacedHashes...)
+}
+
+// TxStore persists transaction records (satisfied by ipfsdb.Store)
+type TxStore interface {
+	Save(key string, value interface{}) error
+	Get(key string) (interface{}, bool)
+	ListKeys() []string
+}
+
+// TxBackend is the chain access the manager needs. Both *ethclient.Client
+// and go-ethereum's simulated backend implement it.
+type TxBackend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+// TxManagerConfig tunes confirmation depth, polling and fee bumping
+type TxManagerConfig struct {
+	Confirmations  uint64        // blocks on top of inclusion before a tx is final
+	PollInterval   time.Duration // how often receipts are polled
+	StuckAfter     time.Duration // pending time before fees are bumped
+	FeeBumpPercent int64         // fee increase per replacement (geth requires >= 10)
+	MaxFeeCap      *big.Int      // upper bound for maxFeePerGas, nil for no limit
+}
+
+// DefaultTxManagerConfig returns the defaults used when no env overrides are set
+func DefaultTxManagerConfig() TxManagerConfig {
+	return TxManagerConfig{
+		Confirmations:  2,
+		PollInterval:   5 * time.Second,
+		StuckAfter:     2 * time.Minute,
+		FeeBumpPercent: 20,
+	}
+}
+
+// TxManager owns the server signing key, hands out nonces serially, tracks
+// receipts and replaces stuck transactions with higher EIP-1559 fees
+type TxManager struct {
+	backend    TxBackend
+	privateKey *ecdsa.PrivateKey
+	from       common.Address
+	chainID    *big.Int
+	store      TxStore
+	cfg        TxManagerConfig
+
+	sendMu      sync.Mutex // serializes nonce allocation and broadcast
+	nonce       uint64
+	nonceLoaded bool
+
+	mu      sync.RWMutex
+	records map[string]*TxRecord // submission ID -> record
+	byHash  map[string]string    // any broadcast hash -> submission ID
+}
+
+// NewTxManager creates a manager on top of an existing backend and reloads
+// any records previously persisted in store
+func NewTxManager(backend TxBackend, privateKey *ecdsa.PrivateKey, chainID *big.Int, store TxStore, cfg TxManagerConfig) *TxManager {
+	m := &TxManager{
+		backend:    backend,
+		privateKey: privateKey,
+		from:       crypto.PubkeyToAddress(privateKey.PublicKey),
+		chainID:    chainID,
+		store:      store,
+		cfg:        cfg,
+		records:    make(map[string]*TxRecord),
+		byHash:     make(map[string]string),
+	}
+
+	for _, key := range store.ListKeys() {
+		if !strings.HasPrefix(key, txKeyPrefix) {
+			continue
+		}
+		val, ok := store.Get(key)
+		if !ok {
+			continue
+		}
+		// Several managers share the store, one per chain
+		if rec, ok := val.(*TxRecord); ok && rec.ChainID == chainID.Uint64() {
+			m.indexLocked(rec.clone())
+		}
+	}
+
+	return m
+}
+
+// Backend returns the chain backend used for calls and transactions
+func (m *TxManager) Backend() TxBackend {
+	return m.backend
+}
+
+// From returns the address that signs every transaction
+func (m *TxManager) From() common.Address {
+	return m.from
+}
+
+// ChainID returns the chain the manager signs for
+func (m *TxManager) ChainID() *big.Int {
+	return new(big.Int).Set(m.chainID)
+}
+
+// Transact allocates the next nonce and current fee caps, lets build create
+// the signed transaction through a typed binding (opts has NoSend set), then
+// broadcasts and tracks it. Calls are serialized so concurrent submissions
+// never share a nonce.
+func (m *TxManager) Transact(ctx context.Context, label string, build func(opts *bind.TransactOpts) (*types.Transaction, error)) (*TxRecord, error) {
+	m.sendMu.Lock()
+	defer m.sendMu.Unlock()
+
+	tx, err := m.buildLocked(ctx, build)
+	if err != nil {
+		return nil, err
+	}
+
+	err = m.send(ctx, tx)
+	if err != nil && isNonceError(err) {
+		// Someone else used this key, or the node restarted: resync and retry once
+		log.Printf("⚠️  Nonce %d rejected (%v), resyncing", tx.Nonce(), err)
+		m.nonceLoaded = false
+		if tx, err = m.buildLocked(ctx, build); err != nil {
+			return nil, err
+		}
+		err = m.send(ctx, tx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	}
+	m.nonce++
+
+	now := time.Now()
+	rec := &TxRecord{
+		ID:              tx.Hash().Hex(),
+		ChainID:         m.chainID.Uint64(),
+		Label:           label,
+		Hash:            tx.Hash().Hex(),
+		From:            m.from,
+		To:              *tx.To(),
+		Nonce:           tx.Nonce(),
+		Data:            tx.Data(),
+		GasLimit:        tx.Gas(),
+		GasTipCap:       tx.GasTipCap(),
+		GasFeeCap:       tx.GasFeeCap(),
+		Status:          TxPending,
+		SubmittedAt:     now,
+		LastBroadcastAt: now,
+		UpdatedAt:       now,
+	}
+
+	m.mu.Lock()
+	m.indexLocked(rec)
+	err = m.persistLocked(rec)
+	m.mu.Unlock()
+	if err != nil {
+		log.Printf("⚠️  Failed to persist tx %s: %v", rec.Hash, err)
+	}
+
+	log.Printf("✅ Transaction submitted (%s, nonce %d): %s", label, rec.Nonce, rec.Hash)
+	return rec.clone(), nil
+}
+
+// buildLocked prepares TransactOpts and runs build. Caller must hold sendMu.
+func (m *TxManager) buildLocked(ctx context.Context, build func(opts *bind.TransactOpts) (*types.Transaction, error)) (*types.Transaction, error) {
+	if !m.nonceLoaded {
+		nonce, err := m.backend.PendingNonceAt(ctx, m.from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nonce: %w", err)
+		}
+		m.nonce = nonce
+		m.nonceLoaded = true
+	}
+
+	tipCap, feeCap, err := m.suggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := bind.NewKeyedTransactorWithChainID(m.privateKey, m.chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transactor: %w", err)
+	}
+	opts.Context = ctx
+	opts.Nonce = new(big.Int).SetUint64(m.nonce)
+	opts.GasTipCap = tipCap
+	opts.GasFeeCap = feeCap
+	opts.NoSend = true
+
+	tx, err := build(opts)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// suggestFees returns EIP-1559 tip and fee caps (2x base fee + tip), bounded by MaxFeeCap
+func (m *TxManager) suggestFees(ctx context.Context) (*big.Int, *big.Int, error) {
+	tipCap, err := m.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get gas tip cap: %w", err)
+	}
+	head, err := m.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get latest header: %w", err)
+	}
+	if head.BaseFee == nil {
+		return nil, nil, fmt.Errorf("chain does not support EIP-1559 fees")
+	}
+
+	feeCap := new(big.Int).Add(tipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+	if m.cfg.MaxFeeCap != nil && feeCap.Cmp(m.cfg.MaxFeeCap) > 0 {
+		feeCap = new(big.Int).Set(m.cfg.MaxFeeCap)
+		if tipCap.Cmp(feeCap) > 0 {
+			tipCap = new(big.Int).Set(feeCap)
+		}
+	}
+	return tipCap, feeCap, nil
+}
+
+// send broadcasts tx. A node that already has this exact transaction, for
+// instance after a retried RPC call, answers "already known": tx was
+// broadcast, so that counts as sent.
+func (m *TxManager) send(ctx context.Context, tx *types.Transaction) error {
+	err := m.backend.SendTransaction(ctx, tx)
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "already known") {
+		log.Printf("ℹ️  Transaction %s already known to the node", tx.Hash().Hex())
+		return nil
+	}
+	return err
+}
+
+// isNonceError reports whether a send failed because the nonce was already
+// used by another transaction
+func isNonceError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "nonce too low") || strings.Contains(msg, "replacement transaction underpriced")
+}
+
+// Status returns the record for a submission, looked up by any hash it was
+// broadcast under
+func (m *TxManager) Status(hash string) (*TxRecord, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	id, ok := m.byHash[strings.ToLower(hash)]
+	if !ok {
+		return nil, false
+	}
+	return m.records[id].clone(), true
+}
+
+// TxDetails is a live view of a tracked submission, read from the chain
+type TxDetails struct {
+	Record        *TxRecord
+	Receipt       *types.Receipt // nil while the transaction is not in a block
+	HeadNumber    uint64
+	Confirmations uint64
+}
+
+// Details looks up a submission by any of its hashes and fetches its current
+// receipt and confirmation count from the backend. The bool is false when the
+// hash was not submitted through this manager.
+func (m *TxManager) Details(ctx context.Context, hash string) (*TxDetails, bool, error) {
+	rec, ok := m.Status(hash)
+	if !ok {
+		return nil, false, nil
+	}
+
+	head, err := m.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to get latest header: %w", err)
+	}
+	details := &TxDetails{Record: rec, HeadNumber: head.Number.Uint64()}
+
+	for _, h := range rec.hashes() {
+		receipt, err := m.backend.TransactionReceipt(ctx, common.HexToHash(h))
+		if errors.Is(err, ethereum.NotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to get receipt: %w", err)
+		}
+		details.Receipt = receipt
+		if n := receipt.BlockNumber.Uint64(); details.HeadNumber >= n {
+			details.Confirmations = details.HeadNumber - n + 1
+		}
+		break
+	}
+
+	return details, true, nil
+}
+
+// WaitMined blocks until the submission is mined or failed, or ctx ends
+func (m *TxManager) WaitMined(ctx context.Context, hash string) (*TxRecord, error) {
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		rec, ok := m.Status(hash)
+		if !ok {
+			return nil, fmt.Errorf("unknown transaction %s", hash)
+		}
+		if rec.Status == TxMined || rec.Status == TxFailed {
+			return rec, nil
+		}
+		select {
+		case <-ctx.Done():
+			return rec, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run polls receipts until ctx is cancelled
+func (m *TxManager) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			m.Poll(ctx)
+		}
+	}
+}
+
+// Poll refreshes every non-final submission once
+func (m *TxManager) Poll(ctx context.Context) {
+	head, err := m.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		log.Printf("⚠️  Tx manager: failed to get latest header: %v", err)
+		return
+	}
+
+	m.mu.RLock()
+	active := []*TxRecord{}
+	for _, rec := range m.records {
+		if !rec.Final {
+			active = append(active, rec.clone())
+		}
+	}
+	m.mu.RUnlock()
+
+	for _, rec := range active {
+		if err := m.refresh(ctx, rec, head.Number.Uint64()); err != nil {
+			log.Printf("⚠️  Tx manager: %s: %v", rec.Hash, err)
+		}
+	}
+}
+
+// refresh updates one submission from its receipt and bumps fees if stuck
+func (m *TxManager) refresh(ctx context.Context, rec *TxRecord, headNumber uint64) error {
+	var receipt *types.Receipt
+	var minedHash string
+	for _, h := range rec.hashes() {
+		r, err := m.backend.TransactionReceipt(ctx, common.HexToHash(h))
+		if err == nil {
+			receipt, minedHash = r, h
+			break
+		}
+		if !errors.Is(err, ethereum.NotFound) {
+			return fmt.Errorf("failed to get receipt: %w", err)
+		}
+	}
+
+	now := time.Now()
+	switch {
+	case receipt != nil:
+		if rec.Status == TxMined || rec.Status == TxFailed {
+			if rec.BlockHash != receipt.BlockHash.Hex() {
+				log.Printf("🔀 Tx %s moved from block %s to %s", rec.ID, rec.BlockHash, receipt.BlockHash.Hex())
+			}
+		}
+		rec.Hash = minedHash
+		rec.BlockNumber = receipt.BlockNumber.Uint64()
+		rec.BlockHash = receipt.BlockHash.Hex()
+		rec.GasUsed = receipt.GasUsed
+		rec.Status = TxMined
+		if receipt.Status == types.ReceiptStatusFailed {
+			rec.Status = TxFailed
+		}
+		rec.Confirmations = 0
+		if headNumber >= rec.BlockNumber {
+			rec.Confirmations = headNumber - rec.BlockNumber + 1
+		}
+		rec.Final = rec.Confirmations >= m.cfg.Confirmations
+
+	case rec.Status == TxMined || rec.Status == TxFailed:
+		// Previously mined but the receipt is gone: the block was reorged out
+		log.Printf("🔀 Tx %s dropped from block %s by a reorg", rec.ID, rec.BlockHash)
+		rec.Status = TxReorged
+		rec.BlockNumber, rec.BlockHash, rec.GasUsed, rec.Confirmations = 0, "", 0, 0
+		rec.LastBroadcastAt = now
+
+	case now.Sub(rec.LastBroadcastAt) >= m.cfg.StuckAfter:
+		if err := m.bumpFees(ctx, rec); err != nil {
+			return err
+		}
+
+	default:
+		return nil
+	}
+
+	rec.UpdatedAt = now
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.indexLocked(rec)
+	return m.persistLocked(rec)
+}
+
+// bumpFees re-signs a stuck submission at the same nonce with higher fee caps
+func (m *TxManager) bumpFees(ctx context.Context, rec *TxRecord) error {
+	bump := func(v *big.Int) *big.Int {
+		out := new(big.Int).Mul(v, big.NewInt(100+m.cfg.FeeBumpPercent))
+		return out.Div(out, big.NewInt(100))
+	}
+
+	tipCap := bump(rec.GasTipCap)
+	feeCap := bump(rec.GasFeeCap)
+
+	// Track the market if the base fee moved more than our bump
+	if suggestedTip, suggestedFee, err := m.suggestFees(ctx); err == nil {
+		if suggestedTip.Cmp(tipCap) > 0 {
+			tipCap = suggestedTip
+		}
+		if suggestedFee.Cmp(feeCap) > 0 {
+			feeCap = suggestedFee
+		}
+	}
+
+	if m.cfg.MaxFeeCap != nil && feeCap.Cmp(m.cfg.MaxFeeCap) > 0 {
+		if rec.GasFeeCap.Cmp(m.cfg.MaxFeeCap) >= 0 {
+			return fmt.Errorf("stuck at max fee cap %s wei", m.cfg.MaxFeeCap)
+		}
+		feeCap = new(big.Int).Set(m.cfg.MaxFeeCap)
+	}
+	if tipCap.Cmp(feeCap) > 0 {
+		tipCap = new(big.Int).Set(feeCap)
+	}
+
+	to := rec.To
+	tx, err := types.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID:   m.chainID,
+		Nonce:     rec.Nonce,
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       rec.GasLimit,
+		To:        &to,
+		Value:     new(big.Int),
+		Data:      rec.Data,
+	}), types.LatestSignerForChainID(m.chainID), m.privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign replacement: %w", err)
+	}
+
+	if err := m.send(ctx, tx); err != nil {
+		return fmt.Errorf("failed to send replacement: %w", err)
+	}
+
+	log.Printf("⛽ Replaced stuck tx %s with %s (maxFeePerGas %s wei)", rec.Hash, tx.Hash().Hex(), feeCap)
+
+	rec.ReplacedHashes = append(rec.ReplacedHashes, rec.Hash)
+	rec.Hash = tx.Hash().Hex()
+	rec.GasTipCap = tipCap
+	rec.GasFeeCap = feeCap
+	rec.Status = TxPending
+	rec.LastBroadcastAt = time.Now()
+	return nil
+}
+
+// indexLocked stores rec and maps all of its hashes. Caller must hold m.mu.
+func (m *TxManager) indexLocked(rec *TxRecord) {
+	m.records[rec.ID] = rec
+	for _, h := range rec.hashes() {
+		m.byHash[strings.ToLower(h)] = rec.ID
+	}
+}
+
+// persistLocked writes rec to the store. Caller must hold m.mu.
+func (m *TxManager) persistLocked(rec *TxRecord) error {
+	return m.store.Save(txKeyPrefix+rec.ID, rec.clone())
+}
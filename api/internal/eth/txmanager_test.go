@@ -0,0 +1,104 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// alreadyKnownBackend delivers every transaction to the simulated chain but
+// answers "already known" for the first ones, as a node does when a retried
+// RPC call carries a transaction it already received
+type alreadyKnownBackend struct {
+	*backends.SimulatedBackend
+
+	mu      sync.Mutex
+	answers int // sends still answered with "already known"
+	sent    []common.Hash
+}
+
+func (b *alreadyKnownBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.SimulatedBackend.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, tx.Hash())
+	if b.answers > 0 {
+		b.answers--
+		return errors.New("already known")
+	}
+	return nil
+}
+
+// transfer builds a plain value-less transfer to to
+func transfer(to common.Address) func(opts *bind.TransactOpts) (*types.Transaction, error) {
+	return func(opts *bind.TransactOpts) (*types.Transaction, error) {
+		return opts.Signer(opts.From, types.NewTx(&types.DynamicFeeTx{
+			Nonce:     opts.Nonce.Uint64(),
+			GasTipCap: opts.GasTipCap,
+			GasFeeCap: opts.GasFeeCap,
+			Gas:       21000,
+			To:        &to,
+			Value:     new(big.Int),
+		}))
+	}
+}
+
+func TestTransactAlreadyKnown(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x00000000000000000000000000000000000b0b00")
+
+	sim := backends.NewSimulatedBackend(core.GenesisAlloc{
+		from: {Balance: new(big.Int).Mul(big.NewInt(1e18), big.NewInt(100))},
+	}, 30_000_000)
+	defer sim.Close()
+	backend := &alreadyKnownBackend{SimulatedBackend: sim, answers: 1}
+
+	cfg := DefaultTxManagerConfig()
+	cfg.Confirmations = 1
+	txm := NewTxManager(backend, key, big.NewInt(1337), &memTxStore{data: map[string]interface{}{}}, cfg)
+
+	first, err := txm.Transact(ctx, "first", transfer(to))
+	if err != nil {
+		t.Fatalf("Transact: %v", err)
+	}
+	if len(backend.sent) != 1 {
+		t.Fatalf("%d transactions sent, want 1: an already known transaction is not sent again", len(backend.sent))
+	}
+	if first.Hash != backend.sent[0].Hex() || first.Nonce != 0 {
+		t.Errorf("recorded %s with nonce %d, sent %s with nonce 0", first.Hash, first.Nonce, backend.sent[0].Hex())
+	}
+
+	// The nonce moved on: the next submission does not replace the first
+	second, err := txm.Transact(ctx, "second", transfer(to))
+	if err != nil {
+		t.Fatalf("Transact: %v", err)
+	}
+	if second.Nonce != 1 {
+		t.Errorf("second submission has nonce %d, want 1", second.Nonce)
+	}
+
+	sim.Commit()
+	txm.Poll(ctx)
+	for _, rec := range []*TxRecord{first, second} {
+		status, ok := txm.Status(rec.Hash)
+		if !ok || status.Status != TxMined {
+			t.Errorf("%s (%s): got %+v, want mined", rec.Label, rec.Hash, status)
+		}
+	}
+}
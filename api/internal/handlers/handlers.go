@@ -5,38 +5,56 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"image"
 	"image/png"
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/google/uuid"
+	"github.com/ipfs/go-cid"
 	"github.com/labstack/echo/v4"
 	_ "golang.org/x/image/webp"
 
 	"yourproject/internal/auth"
 	"yourproject/internal/crypto"
+	"yourproject/internal/dag"
 	"yourproject/internal/eth"
 	"yourproject/internal/ipfsdb"
+	"yourproject/internal/jcs"
 	"yourproject/internal/models"
 	"yourproject/internal/pinata"
 )
 
 type Handler struct {
+	db               ipfsdb.Store
 	storage          *ipfsdb.StorageService
 	ipfsClient       *ipfsdb.IPFSClient
 	blockchainClient *ipfsdb.BlockchainClient
+	watermarkKey     []byte
+	keys             *auth.Keystore
+	wallets          *auth.WalletLinker
 }
 
-func NewHandler(storage *ipfsdb.StorageService, ipfs *ipfsdb.IPFSClient, bc *ipfsdb.BlockchainClient) *Handler {
+func NewHandler(db ipfsdb.Store, storage *ipfsdb.StorageService, ipfs *ipfsdb.IPFSClient, bc *ipfsdb.BlockchainClient, keys *auth.Keystore) *Handler {
 	return &Handler{
+		db:               db,
 		storage:          storage,
 		ipfsClient:       ipfs,
 		blockchainClient: bc,
+		watermarkKey:     crypto.WatermarkKeyFromEnv(),
+		keys:             keys,
+		wallets:          auth.NewWalletLinker(db, auth.SIWEConfigFromEnv()),
 	}
 }
 
@@ -95,10 +113,16 @@ func (h *Handler) GenerateArt(c echo.Context) error {
 	// Validate user has a wallet address set
 	if user.WalletAddress == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "wallet address not set. Please update your profile with a wallet address.",
+			"error": "wallet address not set. Link a wallet with POST /me/wallet/nonce and POST /me/wallet.",
 		})
 	}
 
+	// Resolve the target chain before paying for generation
+	chain, err := h.blockchainClient.Chain(req.Chain)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
 	// Call model provider API with temperature=0 for reproducibility
 	artworkData, err := h.callLLMAPI(req.LLMProvider, req.Prompt, req.ContentType, req.Parameters)
 	if err != nil {
@@ -107,7 +131,7 @@ func (h *Handler) GenerateArt(c echo.Context) error {
 	}
 
 	// Process and watermark the artwork - use user ID and wallet address
-	artwork, certificate, err := h.processArtwork(c.Request().Context(), user.ID, user.WalletAddress, req.Prompt, artworkData, req.ContentType, req.LLMProvider)
+	artwork, certificate, err := h.processArtwork(c.Request().Context(), chain, user.ID, user.WalletAddress, req.Prompt, artworkData, req.ContentType, req.LLMProvider)
 	if err != nil {
 		c.Logger().Errorf("failed to process artwork in GenerateArt: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -138,13 +162,19 @@ func (h *Handler) ImportArt(c echo.Context) error {
 	// Validate user has a wallet address set
 	if user.WalletAddress == "" {
 		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "wallet address not set. Please update your profile with a wallet address.",
+			"error": "wallet address not set. Link a wallet with POST /me/wallet/nonce and POST /me/wallet.",
 		})
 	}
 
+	chain, err := h.blockchainClient.Chain(req.Chain)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
 	// Process imported artwork - use user ID and wallet address
 	artwork, certificate, err := h.processArtwork(
 		c.Request().Context(),
+		chain,
 		user.ID,
 		user.WalletAddress,
 		req.Prompt,
@@ -152,6 +182,9 @@ func (h *Handler) ImportArt(c echo.Context) error {
 		req.ContentType,
 		req.SourcePlatform,
 	)
+	if errors.Is(err, crypto.ErrImageTooSmall) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 	if err != nil {
 		c.Logger().Errorf("failed to process artwork: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -164,18 +197,33 @@ func (h *Handler) ImportArt(c echo.Context) error {
 	})
 }
 
-// processArtwork handles the complete watermarking and storage pipeline
-func (h *Handler) processArtwork(ctx context.Context, userID, walletAddress, prompt string, artworkData []byte, contentType, provider string) (*models.Artwork, *models.ProofCertificate, error) {
+// processArtwork handles the complete watermarking and storage pipeline.
+// chain may be nil, in which case the artwork is stored off-chain only.
+func (h *Handler) processArtwork(ctx context.Context, chain *eth.Chain, userID, walletAddress, prompt string, artworkData []byte, contentType, provider string) (*models.Artwork, *models.ProofCertificate, error) {
 	// 1. Hash the prompt
 	promptHash := crypto.HashPrompt(prompt)
 
 	// 2. Hash original file
 	originalHash := crypto.HashFile(artworkData)
 
+	// The artwork ID is assigned up front so the watermark can carry it
+	artworkID := uuid.New().String()
+
+	// Per-artwork noise seed; it is kept sealed on the artwork record so
+	// verification can regenerate the exact pattern
+	seed, err := crypto.NewNoiseSeed()
+	if err != nil {
+		return nil, nil, err
+	}
+	sealedSeed, err := crypto.SealNoiseSeed(h.watermarkKey, artworkID, seed)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to seal noise seed: %w", err)
+	}
+
 	// 3. Load image (for image content type)
 	var watermarkedData []byte
 	var noisePattern *crypto.NoisePattern
-	var publicKey string
+	var perceptualHash string
 
 	if contentType == "image" {
 		img, _, err := image.Decode(bytes.NewReader(artworkData))
@@ -183,25 +231,18 @@ func (h *Handler) processArtwork(ctx context.Context, userID, walletAddress, pro
 			return nil, nil, fmt.Errorf("failed to decode image: %w", err)
 		}
 
-		bounds := img.Bounds()
-
-		// Generate unique noise pattern for this user
-		noisePattern, err = crypto.GenerateNoisePattern(userID, bounds.Dx(), bounds.Dy())
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to generate noise pattern: %w", err)
-		}
-
-		// Generate key pair for this artwork
-		keyPair, err := crypto.GenerateKeyPair()
+		// Apply the robust watermark carrying the artwork ID and the
+		// unique noise pattern for this artwork
+		var watermarkedImg *image.RGBA
+		watermarkedImg, noisePattern, err = crypto.MarkImage(img, h.watermarkKey, artworkID, seed)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to generate key pair: %w", err)
+			return nil, nil, err
 		}
-		publicKey = base64.StdEncoding.EncodeToString(keyPair.PublicKey)
 
-		// Apply watermark
-		watermarkedImg, err := crypto.ApplyWatermark(img, noisePattern, publicKey)
+		// Perceptual hash for near-duplicate lookups on /verify/upload
+		perceptualHash, err = crypto.PerceptualHash(watermarkedImg)
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to apply watermark: %w", err)
+			return nil, nil, err
 		}
 
 		// Encode watermarked image
@@ -211,20 +252,22 @@ func (h *Handler) processArtwork(ctx context.Context, userID, walletAddress, pro
 		}
 		watermarkedData = buf.Bytes()
 	} else {
-		// For non-image content, store as-is
+		// For non-image content, store as-is. The empty pattern still
+		// yields a signature committing to the seed.
 		watermarkedData = artworkData
-		publicKey = uuid.New().String()
+		noisePattern, err = crypto.GenerateNoisePattern(seed, 0, 0)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate noise pattern: %w", err)
+		}
 	}
 
 	// 4. Hash watermarked content
 	watermarkedHash := crypto.HashFile(watermarkedData)
 
 	// 5. Create metadata for IPFS DAG
-	artworkID := uuid.New().String()
 	metadata := &ipfsdb.DAGMetadata{
 		ArtworkID:      artworkID,
 		ArtistWallet:   walletAddress,
-		PublicKey:      publicKey,
 		PromptHash:     promptHash,
 		ContentHash:    watermarkedHash,
 		NoiseSignature: noisePattern.Signature,
@@ -237,12 +280,34 @@ func (h *Handler) processArtwork(ctx context.Context, userID, walletAddress, pro
 		},
 	}
 
-	// 6. Store on IPFS and blockchain
-	dagCID, txHash, err := h.storage.StoreArtwork(ctx, watermarkedData, metadata)
+	// 5b. Artist's detached signature over the metadata, pinned with it. The
+	// artist signs with their keystore key, which is also the key published
+	// on their profile; the metadata embeds the key that signed it.
+	var publicKey string
+	metadata.Signature, publicKey, err = h.keys.Sign(userID, func(publicKey string) ([]byte, error) {
+		metadata.PublicKey = publicKey
+		return metadata.SigningBytes()
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign metadata: %w", err)
+	}
+
+	// 6. Store on IPFS
+	dagCID, err := h.storage.StoreArtwork(ctx, watermarkedData, metadata)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to store artwork: %w", err)
 	}
 
+	// 6b. Resolve where the proof will be registered on-chain
+	var chainName, anchorStatus, contractAddr string
+	if chain != nil && chain.Batcher != nil {
+		chainName = chain.Name
+		anchorStatus = string(eth.AnchorQueued)
+		contractAddr = chain.Artworks.ContractAddress().Hex()
+	} else {
+		log.Printf("⚠️  ProofOfArt not configured - artwork %s stored off-chain only", artworkID)
+	}
+
 	// 7. Create artwork record
 	artwork := &models.Artwork{
 		ID:                artworkID,
@@ -252,10 +317,14 @@ func (h *Handler) processArtwork(ctx context.Context, userID, walletAddress, pro
 		ContentType:       contentType,
 		OriginalFileHash:  originalHash,
 		WatermarkedHash:   watermarkedHash,
+		PerceptualHash:    perceptualHash,
 		IPFSHash:          dagCID,
 		PublicKeyEmbedded: publicKey,
 		NoisePattern:      noisePattern.Signature,
-		BlockchainTxHash:  txHash,
+		NoiseSeed:         sealedSeed,
+		GPGSignature:      metadata.Signature,
+		BlockchainChain:   chainName,
+		BlockchainStatus:  anchorStatus,
 		DAGNodeID:         dagCID,
 		CreatedAt:         time.Now(),
 		LLMProvider:       provider,
@@ -263,27 +332,57 @@ func (h *Handler) processArtwork(ctx context.Context, userID, walletAddress, pro
 	}
 
 	// Store artwork in database
-	h.storage.GetDB().StoreArtwork(artwork)
+	if err := h.db.StoreArtwork(artwork); err != nil {
+		return nil, nil, fmt.Errorf("failed to save artwork record: %w", err)
+	}
+
+	// 7b. Queue the proof for the next ProofOfArt batch. The artwork record
+	// is saved first so batch updates always find it; the tx hash is filled
+	// in once the batch is mined.
+	if chainName != "" {
+		if _, err := h.blockchainClient.RegisterArtwork(chain, metadata); err != nil {
+			return nil, nil, fmt.Errorf("failed to queue artwork for registration on %s: %w", chain.Name, err)
+		}
+	}
 
 	// 8. Create proof certificate
 	certificate := &models.ProofCertificate{
-		CertificateID:    uuid.New().String(),
-		ArtworkID:        artworkID,
-		ArtistWallet:     walletAddress,
-		Prompt:           prompt,
-		PromptHash:       promptHash,
-		ContentHash:      watermarkedHash,
-		IPFSHash:         dagCID,
-		BlockchainTxHash: txHash,
-		NoiseSignature:   noisePattern.Signature,
-		Timestamp:        time.Now(),
-		IssuedAt:         time.Now(),
-		VerificationURL:  fmt.Sprintf("/verify/%s", artworkID),
+		CertificateID:     uuid.New().String(),
+		ArtworkID:         artworkID,
+		ArtistWallet:      walletAddress,
+		Prompt:            prompt,
+		PromptHash:        promptHash,
+		ContentHash:       watermarkedHash,
+		IPFSHash:          dagCID,
+		BlockchainChain:   chainName,
+		BlockchainStatus:  anchorStatus,
+		NoiseSignature:    noisePattern.Signature,
+		Timestamp:         time.Now(),
+		IssuedAt:          time.Now(),
+		VerificationURL:   fmt.Sprintf("/verify/%s", artworkID),
+		SmartContractAddr: contractAddr,
+	}
+	if err := h.signCertificate(userID, certificate); err != nil {
+		return nil, nil, err
 	}
 
 	return artwork, certificate, nil
 }
 
+// signCertificate sets the artist's current public key and their detached
+// signature made with it on a certificate
+func (h *Handler) signCertificate(userID string, certificate *models.ProofCertificate) error {
+	var err error
+	certificate.GPGSignature, certificate.PublicKey, err = h.keys.Sign(userID, func(publicKey string) ([]byte, error) {
+		certificate.PublicKey = publicKey
+		return certificate.SigningBytes()
+	})
+	if err != nil {
+		return fmt.Errorf("failed to sign certificate: %w", err)
+	}
+	return nil
+}
+
 // VerifyArtwork handles artwork verification
 func (h *Handler) VerifyArtwork(c echo.Context) error {
 	artworkID := c.Param("id")
@@ -298,35 +397,161 @@ func (h *Handler) VerifyArtwork(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to download artwork"})
 	}
 
+	steps := []string{}
+	authentic := true
+
+	// On-chain proof must match the DAG metadata, on the chain it was
+	// registered on. An empty chain means the artwork was never anchored;
+	// resolving it would pick the default chain, which never saw it.
+	var chain *eth.Chain
+	var chainErr error
+	if proof.BlockchainChain != "" {
+		chain, chainErr = h.blockchainClient.Chain(proof.BlockchainChain)
+	}
+	var txURL string
+
+	// Artworks still waiting in a registration batch have nothing on-chain yet
+	var anchor *eth.ArtworkAnchor
+	var tracked bool
+	if chain != nil {
+		anchor, tracked = h.blockchainClient.Anchor(chain, artworkID)
+	}
+	if proof.BlockchainChain == "" {
+		steps = append(steps, "Blockchain verification: SKIPPED (artwork is not anchored on-chain)")
+	} else if chainErr != nil {
+		steps = append(steps, fmt.Sprintf("Blockchain verification: FAILED (%v)", chainErr))
+		authentic = false
+	} else if tracked && anchor.Status != eth.AnchorMined && anchor.Status != eth.AnchorConfirmed {
+		steps = append(steps, fmt.Sprintf("Blockchain: %s (chain ID %s)", chain.Name, chain.ChainID))
+		if anchor.Status == eth.AnchorFailed {
+			steps = append(steps, fmt.Sprintf("Blockchain registration: FAILED (%s)", anchor.Error))
+		} else {
+			steps = append(steps, fmt.Sprintf("Blockchain registration: PENDING (%s)", anchor.Status))
+		}
+		authentic = false
+	} else if chain != nil && chain.Artworks != nil {
+		onChain, err := h.blockchainClient.VerifyArtwork(c.Request().Context(), chain, metadata)
+		if err != nil {
+			c.Logger().Errorf("failed to verify artwork on %s: %v", chain.Name, err)
+			return c.JSON(http.StatusBadGateway, map[string]string{"error": "failed to read blockchain proof"})
+		}
+		steps = append(steps, fmt.Sprintf("Blockchain: %s (chain ID %s)", chain.Name, chain.ChainID))
+		steps = append(steps, onChain.Steps...)
+		authentic = authentic && onChain.Passed()
+		txURL = chain.TxURL(proof.BlockchainTxHash)
+	} else {
+		steps = append(steps, "Blockchain verification: SKIPPED (ProofOfArt contract not configured)")
+	}
+
+	// Downloaded content must hash to the recorded content hash
+	if contentHash := crypto.HashFile(artworkData); contentHash == metadata.ContentHash {
+		steps = append(steps, "IPFS integrity check: PASSED")
+	} else {
+		steps = append(steps, fmt.Sprintf("IPFS integrity check: FAILED (content hash %s, expected %s)", contentHash, metadata.ContentHash))
+		authentic = false
+	}
+
 	var tamperDetected bool
 	var confidence float64
 
-	if metadata.Metadata["content_type"] == "image" {
-		img, _, err := image.Decode(bytes.NewReader(artworkData))
-		if err == nil {
-			bounds := img.Bounds()
-			expectedPattern, _ := crypto.GenerateNoisePattern(metadata.ArtistWallet, bounds.Dx(), bounds.Dy())
-			isValid, conf := crypto.DetectWatermark(img, expectedPattern, 10.0)
-			tamperDetected = !isValid
+	isImage := metadata.Metadata["content_type"] == "image"
+	var img image.Image
+	if isImage {
+		// The embedded watermark must decode and name this artwork
+		tamperDetected = true
+		if img, _, err = image.Decode(bytes.NewReader(artworkData)); err == nil {
+			payload, conf, err := crypto.ExtractWatermark(img, h.watermarkKey)
 			confidence = conf
+			tamperDetected = err != nil || payload.ArtworkID != artworkID
+		}
+		if tamperDetected {
+			steps = append(steps, fmt.Sprintf("Watermark payload: FAILED (confidence %.2f%%)", confidence*100))
+		} else {
+			steps = append(steps, fmt.Sprintf("Watermark payload: PASSED (confidence %.2f%%)", confidence*100))
+		}
+	}
+
+	// The metadata must carry the artist's signature, made with one of their
+	// registered keys that has not been revoked
+	if metadata.Signature == "" {
+		steps = append(steps, "Artist signature: SKIPPED (metadata is unsigned)")
+	} else {
+		signingBytes, err := metadata.SigningBytes()
+		if err == nil {
+			err = crypto.VerifyEd25519(metadata.PublicKey, signingBytes, metadata.Signature)
+		}
+		if errors.Is(err, crypto.ErrBadSignature) {
+			// Artworks signed before canonical JSON was introduced
+			if legacyBytes, lerr := metadata.LegacySigningBytes(); lerr == nil && crypto.VerifyEd25519(metadata.PublicKey, legacyBytes, metadata.Signature) == nil {
+				err = nil
+			}
+		}
+		if err == nil {
+			artwork, aerr := h.db.GetArtworkByID(c.Request().Context(), artworkID)
+			if aerr != nil {
+				err = aerr
+			} else {
+				_, err = h.keys.CheckKey(artwork.ArtistID, metadata.PublicKey)
+			}
+		}
+		if err != nil {
+			steps = append(steps, fmt.Sprintf("Artist signature: FAILED (%v)", err))
+			authentic = false
+		} else {
+			steps = append(steps, "Artist signature: PASSED")
+		}
+	}
+
+	// The noise pattern is regenerated from the artwork's sealed seed; its
+	// signature must match the registered one and, for images, the pixels
+	// must still carry it
+	if artwork, err := h.db.GetArtworkByID(c.Request().Context(), artworkID); err != nil || artwork.NoiseSeed == "" {
+		steps = append(steps, "Noise pattern: SKIPPED (no noise seed recorded)")
+	} else if seed, err := crypto.OpenNoiseSeed(h.watermarkKey, artworkID, artwork.NoiseSeed); err != nil {
+		steps = append(steps, fmt.Sprintf("Noise pattern: FAILED (%v)", err))
+		authentic = false
+	} else {
+		var width, height int
+		if img != nil {
+			width, height = img.Bounds().Dx(), img.Bounds().Dy()
+		}
+		pattern, err := crypto.GenerateNoisePattern(seed, width, height)
+		switch {
+		case err != nil:
+			steps = append(steps, fmt.Sprintf("Noise pattern: FAILED (%v)", err))
+			authentic = false
+		case pattern.Signature != metadata.NoiseSignature:
+			steps = append(steps, "Noise pattern: FAILED (signature does not match the registered noise signature)")
+			authentic = false
+		case img == nil && isImage:
+			steps = append(steps, "Noise pattern: FAILED (content is not a decodable image)")
+			authentic = false
+		case img == nil:
+			steps = append(steps, "Noise pattern: PASSED (signature matches)")
+		default:
+			intact, match := crypto.DetectWatermark(img, pattern, crypto.NoiseMatchThreshold)
+			if intact {
+				steps = append(steps, fmt.Sprintf("Noise pattern: PASSED (%.2f%% of bits match)", match*100))
+			} else {
+				steps = append(steps, fmt.Sprintf("Noise pattern: FAILED (%.2f%% of bits match)", match*100))
+				tamperDetected = true
+			}
 		}
 	}
 
 	result := &models.VerificationResult{
-		IsAuthentic:      !tamperDetected,
-		ArtworkID:        artworkID,
-		OriginalArtist:   metadata.ArtistWallet,
-		CreationDate:     metadata.Timestamp,
-		Prompt:           "",
-		TamperDetected:   tamperDetected,
-		SimilarityScore:  confidence,
-		BlockchainTxHash: proof.IPFSHash,
-		CertificateURL:   fmt.Sprintf("/certificate/%s", artworkID),
-		VerificationSteps: []string{
-			"Blockchain verification: PASSED",
-			"IPFS integrity check: PASSED",
-			fmt.Sprintf("Watermark detection: confidence %.2f%%", confidence*100),
-		},
+		IsAuthentic:       authentic && !tamperDetected,
+		ArtworkID:         artworkID,
+		OriginalArtist:    metadata.ArtistWallet,
+		CreationDate:      metadata.Timestamp,
+		Prompt:            "",
+		TamperDetected:    tamperDetected,
+		SimilarityScore:   confidence,
+		BlockchainTxHash:  proof.BlockchainTxHash,
+		BlockchainChain:   proof.BlockchainChain,
+		BlockchainTxURL:   txURL,
+		CertificateURL:    fmt.Sprintf("/certificate/%s", artworkID),
+		VerificationSteps: steps,
 	}
 
 	return c.JSON(http.StatusOK, result)
@@ -348,17 +573,78 @@ func (h *Handler) GetCertificate(c echo.Context) error {
 		PromptHash:       metadata.PromptHash,
 		ContentHash:      metadata.ContentHash,
 		IPFSHash:         metadata.ContentCID,
-		BlockchainTxHash: proof.IPFSHash,
+		BlockchainTxHash: proof.BlockchainTxHash,
+		BlockchainChain:  proof.BlockchainChain,
 		NoiseSignature:   metadata.NoiseSignature,
 		Timestamp:        metadata.Timestamp,
 		IssuedAt:         time.Now(),
 		VerificationURL:  fmt.Sprintf("/verify/%s", artworkID),
+		PublicKey:        metadata.PublicKey,
+	}
+	if proof.BlockchainChain != "" {
+		if chain, err := h.blockchainClient.Chain(proof.BlockchainChain); err == nil {
+			certificate.BlockchainTxURL = chain.TxURL(proof.BlockchainTxHash)
+			if chain.Artworks != nil {
+				certificate.SmartContractAddr = chain.Artworks.ContractAddress().Hex()
+			}
+		}
+	}
+
+	// Each issued certificate is signed by the artist's current key, as long
+	// as the artwork was signed by one of their keys that is still good.
+	// Artworks created before signing keys existed get an unsigned
+	// certificate.
+	artwork, err := h.db.GetArtworkByID(c.Request().Context(), artworkID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "certificate not found"})
+	}
+	if _, err := h.keys.CheckKey(artwork.ArtistID, metadata.PublicKey); err == nil {
+		if err := h.signCertificate(artwork.ArtistID, certificate); err != nil {
+			c.Logger().Errorf("failed to sign certificate for %s: %v", artworkID, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to sign certificate"})
+		}
 	}
 
 	return c.JSON(http.StatusOK, certificate)
 }
 
-// UploadForVerification handles file upload for verification
+// GetRegistration returns the on-chain registration status of an artwork
+// while it waits in a batch and after the batch is mined
+func (h *Handler) GetRegistration(c echo.Context) error {
+	artworkID := c.Param("id")
+
+	artwork, err := h.db.GetArtworkByID(c.Request().Context(), artworkID)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "artwork not found"})
+	}
+	if artwork.BlockchainChain == "" {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "artwork is not registered on-chain"})
+	}
+
+	chain, err := h.blockchainClient.Chain(artwork.BlockchainChain)
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+	}
+	anchor, ok := h.blockchainClient.Anchor(chain, artworkID)
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "no registration found on " + chain.Name})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"registration": anchor,
+		"chain":        chain.Name,
+		"chain_id":     chain.ChainID.String(),
+		"contract":     chain.Artworks.ContractAddress().Hex(),
+		"explorer_url": chain.TxURL(anchor.TxHash),
+	})
+}
+
+// phashMatchDistance is the largest pHash distance (out of 64 bits) still
+// reported as a near-duplicate of a registered artwork
+const phashMatchDistance = 10
+
+// UploadForVerification identifies an uploaded image by exact hash, embedded
+// watermark and perceptual hash, in that order
 func (h *Handler) UploadForVerification(c echo.Context) error {
 	file, err := c.FormFile("file")
 	if err != nil {
@@ -376,17 +662,118 @@ func (h *Handler) UploadForVerification(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to read file"})
 	}
 
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid image file"})
+	}
+
+	ctx := c.Request().Context()
+	artworks, err := h.db.GetAllArtworks(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load artworks"})
+	}
+
+	steps := []string{}
+	var match *models.Artwork
+	var matchedBy string
+	var similarity float64
+
+	// 1. Exact BLAKE3 match against the distributed or the original file
 	fileHash := crypto.HashFile(data)
+	for _, artwork := range artworks {
+		if fileHash == artwork.WatermarkedHash || fileHash == artwork.OriginalFileHash {
+			match, matchedBy, similarity = artwork, "hash", 1
+			break
+		}
+	}
+	switch {
+	case match == nil:
+		steps = append(steps, "Exact hash match: NONE")
+	case fileHash == match.WatermarkedHash:
+		steps = append(steps, fmt.Sprintf("Exact hash match: PASSED (watermarked file of %s)", match.ID))
+	default:
+		steps = append(steps, fmt.Sprintf("Exact hash match: PASSED (original file of %s)", match.ID))
+	}
 
-	_, _, err = image.Decode(bytes.NewReader(data))
+	// 2. The watermark names the artwork even after re-encoding, scaling or
+	// cropping
+	tamperDetected := false
+	payload, confidence, err := crypto.ExtractWatermark(img, h.watermarkKey)
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid image file"})
+		steps = append(steps, fmt.Sprintf("Watermark payload: NONE (confidence %.2f%%)", confidence*100))
+	} else if marked, err := h.db.GetArtworkByID(ctx, payload.ArtworkID); err != nil {
+		steps = append(steps, fmt.Sprintf("Watermark payload: FAILED (artwork %s not found)", payload.ArtworkID))
+		tamperDetected = true
+	} else if match != nil && match.ID != marked.ID {
+		steps = append(steps, fmt.Sprintf("Watermark payload: FAILED (names %s, file matches %s)", marked.ID, match.ID))
+		tamperDetected = true
+	} else {
+		steps = append(steps, fmt.Sprintf("Watermark payload: PASSED (artwork %s, confidence %.2f%%)", marked.ID, confidence*100))
+		if match == nil {
+			match, matchedBy, similarity = marked, "watermark", confidence
+		}
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"file_hash": fileHash,
-		"message":   "File uploaded for verification",
-	})
+	// 3. Nearest perceptual-hash neighbour, for copies that lost both the
+	// exact bytes and the watermark
+	if hash, err := crypto.PerceptualHash(img); err != nil {
+		steps = append(steps, "Perceptual hash: SKIPPED (failed to hash image)")
+	} else {
+		var nearest *models.Artwork
+		best := crypto.PerceptualHashBits + 1
+		for _, artwork := range artworks {
+			if artwork.PerceptualHash == "" {
+				continue
+			}
+			if d, err := crypto.PerceptualDistance(hash, artwork.PerceptualHash); err == nil && d < best {
+				nearest, best = artwork, d
+			}
+		}
+		switch {
+		case nearest == nil || best > phashMatchDistance:
+			steps = append(steps, "Perceptual hash: NO MATCH")
+		default:
+			steps = append(steps, fmt.Sprintf("Perceptual hash: nearest %s (distance %d/%d)", nearest.ID, best, crypto.PerceptualHashBits))
+			if match == nil {
+				match, matchedBy = nearest, "phash"
+				similarity = 1 - float64(best)/crypto.PerceptualHashBits
+			}
+		}
+	}
+
+	if match == nil {
+		return c.JSON(http.StatusOK, &models.VerificationResult{
+			IsAuthentic:       false,
+			SimilarityScore:   0,
+			VerificationSteps: append(steps, "Result: no registered artwork matches this file"),
+		})
+	}
+
+	// A look-alike is only a lead: without the exact bytes or the watermark
+	// it cannot be called authentic
+	authentic := matchedBy != "phash" && !tamperDetected
+	result := &models.VerificationResult{
+		IsAuthentic:       authentic,
+		ArtworkID:         match.ID,
+		OriginalArtist:    match.ArtistID,
+		CreationDate:      match.CreatedAt,
+		TamperDetected:    tamperDetected || matchedBy == "phash",
+		SimilarityScore:   similarity,
+		BlockchainTxHash:  match.BlockchainTxHash,
+		BlockchainChain:   match.BlockchainChain,
+		CertificateURL:    fmt.Sprintf("/certificate/%s", match.ID),
+		MatchedBy:         matchedBy,
+		VerificationSteps: steps,
+	}
+	if metadata, _, err := h.storage.VerifyArtwork(ctx, match.ID); err == nil {
+		result.OriginalArtist = metadata.ArtistWallet
+	}
+	if chain, err := h.blockchainClient.Chain(match.BlockchainChain); err == nil && chain != nil && match.BlockchainTxHash != "" {
+		result.BlockchainTxURL = chain.TxURL(match.BlockchainTxHash)
+	}
+	result.VerificationSteps = append(result.VerificationSteps, fmt.Sprintf("Result: artwork %s by %s (matched by %s)", match.ID, result.OriginalArtist, matchedBy))
+
+	return c.JSON(http.StatusOK, result)
 }
 
 // callLLMAPI calls various provider APIs
@@ -639,6 +1026,7 @@ func (h *Handler) UploadManifest(c echo.Context) error {
 		Timestamp   int64             `json:"timestamp" form:"timestamp"`
 		DerivedFrom *string           `json:"derived_from,omitempty" form:"derived_from"`
 		Metadata    map[string]string `json:"metadata,omitempty" form:"metadata"`
+		Chain       string            `json:"chain,omitempty" form:"chain"`
 	}
 
 	var creator string
@@ -672,6 +1060,16 @@ func (h *Handler) UploadManifest(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "creator (wallet address) is required"})
 	}
 
+	chain, err := h.blockchainClient.Chain(req.Chain)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if chain == nil || chain.Provenance == nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Ethereum not configured. Please set RPC_URL, PRIVATE_KEY, and CONTRACT_ADDRESS in .env, or configure the chain in CHAINS_CONFIG",
+		})
+	}
+
 	manifest := map[string]interface{}{
 		"image_cid":  imageCID,
 		"creator":    creator,
@@ -690,6 +1088,14 @@ func (h *Handler) UploadManifest(c echo.Context) error {
 		manifest["metadata"] = req.Metadata
 	}
 
+	// Pinned as RFC 8785 canonical JSON, so manifest_hash can be recomputed
+	// from the pinned content
+	manifestJSON, err := jcs.Marshal(manifest)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "manifest is not valid canonical JSON: " + err.Error()})
+	}
+	manifestHash := crypto.Blake3Hex(manifestJSON)
+
 	log.Printf("📤 Uploading manifest to Pinata...")
 	cid, err := pinataClient.PinJSONManifest(manifest)
 	if err != nil {
@@ -699,37 +1105,208 @@ func (h *Handler) UploadManifest(c echo.Context) error {
 
 	log.Printf("✅ Manifest pinned to Pinata: CID=%s", cid)
 
-	rpcURL := os.Getenv("RPC_URL")
-	privateKey := os.Getenv("PRIVATE_KEY")
-	contractAddress := os.Getenv("CONTRACT_ADDRESS")
-
-	if rpcURL == "" || privateKey == "" || contractAddress == "" {
-		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"error":     "Ethereum not configured. Please set RPC_URL, PRIVATE_KEY, and CONTRACT_ADDRESS in .env",
-			"cid":       cid,
-			"image_cid": imageCID,
+	if chain.Manifests != nil {
+		if err := h.blockchainClient.QueueManifest(chain, cid); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]interface{}{
+				"error": fmt.Sprintf("failed to queue manifest on %s: %v", chain.Name, err),
+				"cid":   cid,
+			})
+		}
+		log.Printf("🌳 Manifest %s queued for Merkle anchoring on %s", cid, chain.Name)
+
+		return c.JSON(http.StatusAccepted, map[string]interface{}{
+			"image_cid":     imageCID,
+			"cid":           cid,
+			"chain":         chain.Name,
+			"chainId":       chain.ChainID.String(),
+			"anchoring":     "merkle",
+			"txStatus":      eth.AnchorQueued,
+			"proofUrl":      fmt.Sprintf("/manifests/%s/proof", cid),
+			"manifest":      manifest,
+			"manifest_hash": manifestHash,
 		})
 	}
 
-	log.Printf("📤 Storing manifest CID on Ethereum Sepolia...")
-	txHash, err := eth.StoreManifest(ctx, rpcURL, privateKey, contractAddress, cid)
+	log.Printf("📤 Storing manifest CID on %s (chain ID %s)...", chain.Name, chain.ChainID)
+	tx, err := h.blockchainClient.StoreManifest(ctx, chain, cid)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]interface{}{
-			"error": "failed to store on Ethereum: " + err.Error(),
+			"error": fmt.Sprintf("failed to store on %s: %v", chain.Name, err),
 			"cid":   cid,
 		})
 	}
+	txHash := tx.Hash
+
+	log.Printf("✅ Manifest stored on %s: TX=%s", chain.Name, txHash)
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"image_cid":     imageCID,
+		"cid":           cid,
+		"chain":         chain.Name,
+		"chainId":       chain.ChainID.String(),
+		"anchoring":     "direct",
+		"txHash":        txHash,
+		"txStatus":      tx.Status,
+		"explorerUrl":   chain.TxURL(txHash),
+		"manifest":      manifest,
+		"manifest_hash": manifestHash,
+	})
+}
+
+// GetManifestProof returns the Merkle inclusion proof of a batched manifest
+// together with the transaction that anchored its root, so inclusion can be
+// checked offline: fold leaf with each proof step (BLAKE3(0x01 || left ||
+// right)) and compare with root, then confirm the transaction emitted
+// ManifestStored with cid "blake3-merkle:<root>".
+func (h *Handler) GetManifestProof(c echo.Context) error {
+	cid := c.Param("cid")
+
+	chain, proof, found, err := h.blockchainClient.ManifestProof(cid)
+	if !found {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "manifest not anchored in a merkle batch"})
+	}
+	if err != nil {
+		// Still waiting for its batch
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error(), "status": string(eth.AnchorQueued)})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"cid":          proof.CID,
+		"leaf":         proof.Leaf,
+		"index":        proof.Index,
+		"proof":        proof.Proof,
+		"root":         proof.Batch.Root,
+		"anchored":     proof.Batch.Anchored(),
+		"batch_size":   len(proof.Batch.CIDs),
+		"hash":         "blake3",
+		"leaf_hash":    "BLAKE3(0x00 || cid)",
+		"node_hash":    "BLAKE3(0x01 || left || right)",
+		"status":       proof.Batch.Status,
+		"chain":        chain.Name,
+		"chain_id":     chain.ChainID.String(),
+		"contract":     chain.Provenance.ContractAddress().Hex(),
+		"tx_hash":      proof.Batch.TxHash,
+		"block_number": proof.Batch.BlockNumber,
+		"explorer_url": chain.TxURL(proof.Batch.TxHash),
+	})
+}
+
+// GetTransaction handles GET /tx/:hash for transactions submitted by this server
+func (h *Handler) GetTransaction(c echo.Context) error {
+	hash := c.Param("hash")
+	if len(hash) != 66 || !strings.HasPrefix(hash, "0x") {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid transaction hash"})
+	}
+
+	chain, details, found, err := h.blockchainClient.TransactionDetails(c.Request().Context(), hash)
+	if err != nil {
+		c.Logger().Errorf("failed to get transaction %s: %v", hash, err)
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "failed to query RPC: " + err.Error()})
+	}
+	if !found {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "transaction not submitted by this server"})
+	}
+
+	rec := details.Record
+	response := map[string]interface{}{
+		"hash":            rec.Hash,
+		"chain":           chain.Name,
+		"chain_id":        chain.ChainID.String(),
+		"submission_id":   rec.ID,
+		"replaced_hashes": rec.ReplacedHashes,
+		"label":           rec.Label,
+		"status":          rec.Status,
+		"from":            rec.From.Hex(),
+		"to":              rec.To.Hex(),
+		"nonce":           rec.Nonce,
+		"head_block":      details.HeadNumber,
+		"confirmations":   details.Confirmations,
+		"final":           rec.Final,
+		"submitted_at":    rec.SubmittedAt,
+		"explorer_url":    chain.TxURL(rec.Hash),
+	}
+
+	if receipt := details.Receipt; receipt != nil {
+		response["hash"] = receipt.TxHash.Hex()
+		response["explorer_url"] = chain.TxURL(receipt.TxHash.Hex())
+		response["block_number"] = receipt.BlockNumber.Uint64()
+		response["block_hash"] = receipt.BlockHash.Hex()
+		response["gas_used"] = receipt.GasUsed
+		response["succeeded"] = receipt.Status == types.ReceiptStatusSuccessful
+
+		events, err := h.blockchainClient.ManifestStoredEvents(chain, receipt)
+		if err != nil {
+			c.Logger().Errorf("failed to decode ManifestStored events for %s: %v", hash, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to decode events"})
+		}
+		response["manifest_stored"] = events
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// chainAddressFilter parses an optional address query parameter
+func chainAddressFilter(c echo.Context, name string) (*common.Address, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	if !common.IsHexAddress(value) {
+		return nil, fmt.Errorf("invalid %s address", name)
+	}
+	addr := common.HexToAddress(value)
+	return &addr, nil
+}
+
+// GetChainManifests lists indexed ManifestStored events, optionally filtered by creator
+func (h *Handler) GetChainManifests(c echo.Context) error {
+	chain, err := h.blockchainClient.Chain(c.QueryParam("chain"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if chain == nil || chain.Indexer == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "chain indexer not configured"})
+	}
+	indexer := chain.Indexer
+
+	creator, err := chainAddressFilter(c, "creator")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+
+	manifests := indexer.Manifests(creator)
+	indexedTo, _ := indexer.IndexedTo()
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"chain":      chain.Name,
+		"manifests":  manifests,
+		"count":      len(manifests),
+		"indexed_to": indexedTo,
+	})
+}
 
-	etherscanURL := fmt.Sprintf("https://sepolia.etherscan.io/tx/%s", txHash)
+// GetChainArtworks lists indexed ArtworkRegistered events, optionally filtered by artist
+func (h *Handler) GetChainArtworks(c echo.Context) error {
+	chain, err := h.blockchainClient.Chain(c.QueryParam("chain"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
+	if chain == nil || chain.Indexer == nil {
+		return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": "chain indexer not configured"})
+	}
+	indexer := chain.Indexer
 
-	log.Printf("✅ Manifest stored on Ethereum: TX=%s", txHash)
+	artist, err := chainAddressFilter(c, "artist")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	}
 
+	artworks := indexer.Artworks(artist)
+	indexedTo, _ := indexer.IndexedTo()
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"image_cid": imageCID,
-		"cid":       cid,
-		"txHash":    txHash,
-		"etherscan": etherscanURL,
-		"manifest":  manifest,
+		"chain":      chain.Name,
+		"artworks":   artworks,
+		"count":      len(artworks),
+		"indexed_to": indexedTo,
 	})
 }
 
@@ -744,7 +1321,7 @@ func (h *Handler) GetNotifications(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
 	}
 
-	results, err := h.storage.GetDB().GetCrawlerResultsByUserID(c.Request().Context(), user.ID)
+	results, err := h.db.GetCrawlerResultsByUserID(c.Request().Context(), user.ID)
 	if err != nil {
 		c.Logger().Errorf("failed to get notifications: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to retrieve notifications"})
@@ -776,16 +1353,16 @@ func (h *Handler) GetNotificationsByArtwork(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
 	}
 
-	artwork, err := h.storage.GetDB().GetArtworkByID(c.Request().Context(), artworkID)
+	artwork, err := h.db.GetArtworkByID(c.Request().Context(), artworkID)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "artwork not found"})
 	}
 
-	if artwork.ArtistID != user.ID {
+	if artwork.ArtistID != user.ID && !auth.Can(user, auth.PermViewCrawlers) {
 		return c.JSON(http.StatusForbidden, map[string]string{"error": "you don't have permission to view these notifications"})
 	}
 
-	results, err := h.storage.GetDB().GetCrawlerResultsByArtworkID(c.Request().Context(), artworkID)
+	results, err := h.db.GetCrawlerResultsByArtworkID(c.Request().Context(), artworkID)
 	if err != nil {
 		c.Logger().Errorf("failed to get notifications: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to retrieve notifications"})
@@ -810,7 +1387,11 @@ func (h *Handler) MarkNotificationAsRead(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
 	}
 
-	err := h.storage.GetDB().UpdateCrawlerResultStatus(c.Request().Context(), notificationID, "read")
+	if status, msg := h.checkNotificationAccess(c.Request().Context(), user, notificationID); status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	err := h.db.UpdateCrawlerResultStatus(c.Request().Context(), notificationID, "read")
 	if err != nil {
 		c.Logger().Errorf("failed to update notification: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update notification"})
@@ -836,7 +1417,11 @@ func (h *Handler) MarkNotificationAsVerified(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
 	}
 
-	err := h.storage.GetDB().UpdateCrawlerResultStatus(c.Request().Context(), notificationID, "verified")
+	if status, msg := h.checkNotificationAccess(c.Request().Context(), user, notificationID); status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	err := h.db.UpdateCrawlerResultStatus(c.Request().Context(), notificationID, "verified")
 	if err != nil {
 		c.Logger().Errorf("failed to update notification: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update notification"})
@@ -862,7 +1447,11 @@ func (h *Handler) DismissNotification(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
 	}
 
-	err := h.storage.GetDB().UpdateCrawlerResultStatus(c.Request().Context(), notificationID, "dismissed")
+	if status, msg := h.checkNotificationAccess(c.Request().Context(), user, notificationID); status != 0 {
+		return c.JSON(status, map[string]string{"error": msg})
+	}
+
+	err := h.db.UpdateCrawlerResultStatus(c.Request().Context(), notificationID, "dismissed")
 	if err != nil {
 		c.Logger().Errorf("failed to update notification: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update notification"})
@@ -876,6 +1465,68 @@ func (h *Handler) DismissNotification(c echo.Context) error {
 	})
 }
 
+// checkNotificationAccess returns the status and message refusing user a
+// change to notification id, or 0 when user is the artist of its artwork or
+// a moderator
+func (h *Handler) checkNotificationAccess(ctx context.Context, user *models.User, id string) (int, string) {
+	val, ok := h.db.Get(id)
+	result, isResult := val.(*models.CrawlerResult)
+	if !ok || !isResult {
+		return http.StatusNotFound, "notification not found"
+	}
+	if auth.Can(user, auth.PermModerate) {
+		return 0, ""
+	}
+	artwork, err := h.db.GetArtworkByID(ctx, result.OriginalArtworkID)
+	if err != nil || artwork.ArtistID != user.ID {
+		return http.StatusForbidden, "you don't have permission to change this notification"
+	}
+	return 0, ""
+}
+
+// ReportInfringement records a user's report of a suspected copy of an
+// artwork. It reaches the artist as a pending notification, like a crawler
+// finding.
+func (h *Handler) ReportInfringement(c echo.Context) error {
+	user, ok := auth.GetDBUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
+	}
+
+	var req struct {
+		ArtworkID string `json:"artwork_id"`
+		FoundURL  string `json:"found_url"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if req.ArtworkID == "" || req.FoundURL == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "artwork_id and found_url are required"})
+	}
+	if u, err := url.Parse(req.FoundURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "found_url must be an http(s) URL"})
+	}
+	if _, err := h.db.GetArtworkByID(c.Request().Context(), req.ArtworkID); err != nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "artwork not found"})
+	}
+
+	report := &models.CrawlerResult{
+		ID:                "report-" + uuid.New().String(),
+		OriginalArtworkID: req.ArtworkID,
+		FoundURL:          req.FoundURL,
+		DetectedAt:        time.Now(),
+		Status:            "pending",
+		ReportedBy:        user.ID,
+	}
+	if err := h.db.StoreCrawlerResult(c.Request().Context(), report); err != nil {
+		c.Logger().Errorf("failed to store report: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store report"})
+	}
+
+	log.Printf("🚩 User %s reported %s as a copy of artwork %s", user.ID, req.FoundURL, req.ArtworkID)
+	return c.JSON(http.StatusCreated, report)
+}
+
 // GetCrawlerStats returns statistics about crawler activity
 func (h *Handler) GetCrawlerStats(c echo.Context) error {
 	user, ok := auth.GetDBUserFromContext(c)
@@ -883,7 +1534,7 @@ func (h *Handler) GetCrawlerStats(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
 	}
 
-	results, err := h.storage.GetDB().GetCrawlerResultsByUserID(c.Request().Context(), user.ID)
+	results, err := h.db.GetCrawlerResultsByUserID(c.Request().Context(), user.ID)
 	if err != nil {
 		c.Logger().Errorf("failed to get crawler stats: %v", err)
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to retrieve stats"})
@@ -945,12 +1596,12 @@ func (h *Handler) TriggerManualScan(c echo.Context) error {
 		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
 	}
 
-	artwork, err := h.storage.GetDB().GetArtworkByID(c.Request().Context(), artworkID)
+	artwork, err := h.db.GetArtworkByID(c.Request().Context(), artworkID)
 	if err != nil {
 		return c.JSON(http.StatusNotFound, map[string]string{"error": "artwork not found"})
 	}
 
-	if artwork.ArtistID != user.ID {
+	if artwork.ArtistID != user.ID && !auth.Can(user, auth.PermModerate) {
 		return c.JSON(http.StatusForbidden, map[string]string{"error": "you don't have permission to scan this artwork"})
 	}
 
@@ -963,23 +1614,356 @@ func (h *Handler) TriggerManualScan(c echo.Context) error {
 	})
 }
 
+// ============================================
+// SIGNING KEY ENDPOINTS
+// ============================================
+
+// signingKeyResponse is a key record together with its public JWK
+type signingKeyResponse struct {
+	*models.SigningKeyRecord
+	JWK interface{} `json:"jwk,omitempty"`
+}
+
+// newSigningKeyResponse attaches the JWK of rec's public key
+func newSigningKeyResponse(rec *models.SigningKeyRecord) signingKeyResponse {
+	resp := signingKeyResponse{SigningKeyRecord: rec}
+	if pub, err := crypto.ParseEd25519PublicKey(rec.PublicKey); err == nil {
+		if key, err := crypto.PublicJWK(pub); err == nil {
+			resp.JWK = key
+		}
+	}
+	return resp
+}
+
+// GetSigningKeys lists every signing key the authenticated user has held
+func (h *Handler) GetSigningKeys(c echo.Context) error {
+	user, ok := auth.GetDBUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
+	}
+
+	keys := []signingKeyResponse{}
+	for _, rec := range h.keys.Keys(user.ID) {
+		keys = append(keys, newSigningKeyResponse(rec))
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"current": auth.KeyIDFor(user.PublicKey),
+		"keys":    keys,
+		"count":   len(keys),
+	})
+}
+
+// GetSigningKey returns the public record of any signing key, so nodes and
+// artworks signed with it can be checked by anyone
+func (h *Handler) GetSigningKey(c echo.Context) error {
+	rec, ok := h.keys.KeyRecord(c.Param("kid"))
+	if !ok {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "signing key not found"})
+	}
+	return c.JSON(http.StatusOK, newSigningKeyResponse(rec))
+}
+
+// RotateSigningKey replaces the authenticated user's signing key. The old
+// key is kept as rotated and still verifies what it signed.
+func (h *Handler) RotateSigningKey(c echo.Context) error {
+	user, ok := auth.GetDBUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
+	}
+
+	rec, err := h.keys.Rotate(user)
+	if err != nil {
+		c.Logger().Errorf("failed to rotate signing key for %s: %v", user.ID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to rotate signing key"})
+	}
+	return c.JSON(http.StatusOK, newSigningKeyResponse(rec))
+}
+
+// RevokeSigningKey revokes one of the authenticated user's signing keys.
+// Signatures made with it no longer verify; revoking the current key
+// rotates to a new one.
+func (h *Handler) RevokeSigningKey(c echo.Context) error {
+	user, ok := auth.GetDBUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	rec, err := h.keys.Revoke(user, c.Param("kid"), req.Reason)
+	if errors.Is(err, auth.ErrNoSigningKey) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "signing key not found"})
+	}
+	if err != nil {
+		c.Logger().Errorf("failed to revoke signing key for %s: %v", user.ID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to revoke signing key"})
+	}
+	return c.JSON(http.StatusOK, newSigningKeyResponse(rec))
+}
+
+// ============================================
+// PROFILE ENDPOINTS
+// ============================================
+
+// GetMe returns the authenticated user's profile
+func (h *Handler) GetMe(c echo.Context) error {
+	user, ok := auth.GetDBUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
+	}
+	return c.JSON(http.StatusOK, user)
+}
+
+// UpdateMe updates the authenticated user's display name. The wallet
+// address is only set by linking a wallet and the role by an admin.
+func (h *Handler) UpdateMe(c echo.Context) error {
+	user, ok := auth.GetDBUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
+	}
+
+	var req struct {
+		DisplayName   *string `json:"display_name"`
+		UserType      *string `json:"user_type"`
+		WalletAddress *string `json:"wallet_address"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+
+	if req.WalletAddress != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "wallet_address cannot be set directly. Link a wallet with POST /me/wallet/nonce and POST /me/wallet.",
+		})
+	}
+	if req.UserType != nil && *req.UserType != user.UserType {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": "user_type is a role and can only be changed by an admin"})
+	}
+	if req.DisplayName != nil && len(strings.TrimSpace(*req.DisplayName)) > 64 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "display_name must be at most 64 characters"})
+	}
+
+	updated, err := ipfsdb.UpdateRecord(h.db, user.ID, func(u *models.User) bool {
+		if req.DisplayName == nil {
+			return false
+		}
+		u.DisplayName = strings.TrimSpace(*req.DisplayName)
+		return true
+	})
+	if err != nil {
+		c.Logger().Errorf("failed to save user %s: %v", user.ID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update profile"})
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// GetWalletNonce issues a nonce for linking a wallet and the EIP-4361
+// message to sign with it. address and chain_id are optional; a client that
+// builds its own message only needs the nonce.
+func (h *Handler) GetWalletNonce(c echo.Context) error {
+	user, ok := auth.GetDBUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
+	}
+
+	var req struct {
+		Address string `json:"address"`
+		ChainID int64  `json:"chain_id"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if req.Address != "" && !common.IsHexAddress(req.Address) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid address"})
+	}
+
+	nonce, err := h.wallets.IssueNonce(user.ID)
+	if err != nil {
+		c.Logger().Errorf("failed to issue wallet nonce for %s: %v", user.ID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to issue nonce"})
+	}
+
+	message := h.wallets.Message(c.Request(), nonce, req.Address, req.ChainID)
+	resp := map[string]interface{}{
+		"nonce":           nonce.Nonce,
+		"issued_at":       nonce.IssuedAt,
+		"expiration_time": nonce.ExpiresAt,
+		"fields":          message,
+	}
+	if message.Address != "" {
+		resp["message"] = message.String()
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// LinkWallet links a wallet to the authenticated user from an EIP-4361
+// message carrying one of their nonces, signed with the wallet
+func (h *Handler) LinkWallet(c echo.Context) error {
+	user, ok := auth.GetDBUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
+	}
+
+	var req struct {
+		Message   string `json:"message"`
+		Signature string `json:"signature"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if req.Message == "" || req.Signature == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "message and signature are required"})
+	}
+
+	address, _, err := h.wallets.Verify(c.Request(), user.ID, req.Message, req.Signature)
+	switch {
+	case errors.Is(err, auth.ErrInvalidSIWEMessage):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, auth.ErrInvalidWalletNonce), errors.Is(err, auth.ErrWalletSignature):
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+	case err != nil:
+		c.Logger().Errorf("failed to verify wallet link for %s: %v", user.ID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to verify wallet"})
+	}
+
+	if owner, ok := h.db.FindUserByWalletAddress(address.Hex()); ok && owner.ID != user.ID {
+		return c.JSON(http.StatusConflict, map[string]string{"error": "wallet is already linked to another user"})
+	}
+
+	now := time.Now()
+	updated, err := ipfsdb.UpdateRecord(h.db, user.ID, func(u *models.User) bool {
+		u.WalletAddress = address.Hex()
+		u.WalletLinkedAt = &now
+		return true
+	})
+	if err != nil {
+		c.Logger().Errorf("failed to save user %s: %v", user.ID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to link wallet"})
+	}
+	log.Printf("👛 Wallet %s linked to user %s", updated.WalletAddress, updated.ID)
+	return c.JSON(http.StatusOK, updated)
+}
+
+// ============================================
+// ADMIN ENDPOINTS
+// ============================================
+
+// ListUsers returns every user with their role
+func (h *Handler) ListUsers(c echo.Context) error {
+	users := []*models.User{}
+	for _, key := range h.db.ListKeys() {
+		if val, ok := h.db.Get(key); ok {
+			if user, ok := val.(*models.User); ok {
+				users = append(users, user)
+			}
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.Before(users[j].CreatedAt) })
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"users": users,
+		"count": len(users),
+	})
+}
+
+// SetUserRole changes a user's role. Admins cannot change their own role,
+// so there is always one left.
+func (h *Handler) SetUserRole(c echo.Context) error {
+	admin, ok := auth.GetDBUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
+	}
+	if !auth.ValidRole(req.Role) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "role must be \"admirer\", \"artist\" or \"admin\""})
+	}
+
+	id := c.Param("id")
+	if id == admin.ID {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "admins cannot change their own role"})
+	}
+
+	var previous string
+	updated, err := ipfsdb.UpdateRecord(h.db, id, func(u *models.User) bool {
+		previous = u.UserType
+		u.UserType = req.Role
+		return true
+	})
+	if errors.Is(err, ipfsdb.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "user not found"})
+	}
+	if err != nil {
+		c.Logger().Errorf("failed to save user %s: %v", id, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to change role"})
+	}
+	log.Printf("🛡️  Admin %s changed the role of %s from %s to %s", admin.ID, id, previous, updated.UserType)
+	return c.JSON(http.StatusOK, updated)
+}
+
+// GetAllCrawlerResults returns the crawler findings and reports for every
+// artwork, optionally filtered by status
+func (h *Handler) GetAllCrawlerResults(c echo.Context) error {
+	artworks, err := h.db.GetAllArtworks(c.Request().Context())
+	if err != nil {
+		c.Logger().Errorf("failed to get artworks: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to retrieve crawler results"})
+	}
+
+	status := c.QueryParam("status")
+	results := []*models.CrawlerResult{}
+	for _, artwork := range artworks {
+		found, err := h.db.GetCrawlerResultsByArtworkID(c.Request().Context(), artwork.ID)
+		if err != nil {
+			c.Logger().Errorf("failed to get crawler results for %s: %v", artwork.ID, err)
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to retrieve crawler results"})
+		}
+		for _, result := range found {
+			if status == "" || result.Status == status {
+				results = append(results, result)
+			}
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].DetectedAt.After(results[j].DetectedAt) })
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"results": results,
+		"total":   len(results),
+	})
+}
+
 // ============================================
 // NODE/ARTIFACT HANDLERS (EXISTING)
 // ============================================
 
 // Handlers provides handlers for the node-based system
 type Handlers struct {
-	db           *ipfsdb.IPFSDB
+	db           ipfsdb.Store
 	artifactsDir string
 	manifestsDir string
+	keys         *auth.Keystore
+	blocks       *dag.Store
 }
 
 // NewHandlers creates a new handlers instance
-func NewHandlers(db *ipfsdb.IPFSDB, artifactsDir, manifestsDir string) *Handlers {
+func NewHandlers(db ipfsdb.Store, artifactsDir, manifestsDir string, keys *auth.Keystore, blocks *dag.Store) *Handlers {
 	return &Handlers{
 		db:           db,
 		artifactsDir: artifactsDir,
 		manifestsDir: manifestsDir,
+		keys:         keys,
+		blocks:       blocks,
 	}
 }
 
@@ -996,7 +1980,9 @@ func (h *Handlers) ExtPush(c echo.Context) error {
 	}
 
 	key := fmt.Sprintf("/ipfs/ext-%s", uuid.New().String())
-	h.db.Save(key, req)
+	if err := h.db.Save(key, req); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store prompt data"})
+	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
 		"key":    key,
@@ -1004,51 +1990,79 @@ func (h *Handlers) ExtPush(c echo.Context) error {
 	})
 }
 
-// CreateNode creates a signed node
+// CreateNode creates a node signed with the authenticated user's key. The
+// author is always that user; an author in the request body is ignored.
 func (h *Handlers) CreateNode(c echo.Context) error {
 	var req struct {
-		Kind   string                 `json:"kind"`
-		Author string                 `json:"author"`
-		Body   map[string]interface{} `json:"body"`
+		Kind string                 `json:"kind"`
+		Body map[string]interface{} `json:"body"`
 	}
 
 	if err := c.Bind(&req); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
 	}
 
-	signer, err := crypto.NewSigner()
+	user, ok := auth.GetDBUserFromContext(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{"error": "user not authenticated"})
+	}
+	if _, err := h.keys.EnsureKey(user); err != nil {
+		c.Logger().Errorf("failed to load signing key for %s: %v", user.ID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load signing key"})
+	}
+	signer, err := h.keys.Signer(user.ID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create signer"})
+		c.Logger().Errorf("failed to load signing key for %s: %v", user.ID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load signing key"})
 	}
 
 	nodeData := map[string]interface{}{
 		"kind":      req.Kind,
-		"author":    req.Author,
+		"author":    user.ID,
 		"body":      req.Body,
-		"timestamp": time.Now(),
+		"timestamp": time.Now().UTC(),
 	}
 
-	nodeJSON, _ := json.Marshal(nodeData)
+	// Sign and hash the canonical bytes, and store the data in the same
+	// generic form Verify re-encodes
+	nodeJSON, canonicalData, err := canonicalJSON(nodeData)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid node body"})
+	}
 	nodeHash := crypto.Blake3Hex(nodeJSON)
 
+	// Detached JWS (RFC 7797, unencoded payload) over the exact node JSON
 	signature, err := signer.JWSDetached(nodeJSON)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to sign node"})
 	}
+	publicJWK, err := signer.JWK()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to sign node"})
+	}
 
 	node := map[string]interface{}{
-		"data":       nodeData,
+		"type":       "node",
+		"data":       canonicalData,
 		"hash":       nodeHash,
 		"signature":  signature,
 		"public_key": signer.PublicKey(),
 		"key_id":     signer.KeyID(),
+		"jwk":        publicJWK,
 	}
 
-	key := fmt.Sprintf("/ipfs/node-%s", nodeHash[:16])
-	h.db.Save(key, node)
+	nodeCID, err := h.blocks.Put(node)
+	if errors.Is(err, dag.ErrInvalid) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid node body: " + err.Error()})
+	}
+	if err != nil {
+		c.Logger().Errorf("failed to store node: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store node"})
+	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"key":  key,
+		"cid":  nodeCID.String(),
+		"key":  "/ipfs/" + nodeCID.String(),
 		"node": node,
 	})
 }
@@ -1079,31 +2093,53 @@ func (h *Handlers) UploadArtifact(c echo.Context) error {
 	artifactHash := crypto.SHA256Hex(data)
 	blake3Hash := crypto.Blake3Hex(data)
 
-	artifactKey := fmt.Sprintf("/ipfs/artifact-%s", blake3Hash[:16])
+	// The content is a raw block; the artifact block links to it and, when
+	// nodeId is a CID, to its node
+	contentCID, err := h.blocks.PutRaw(data)
+	if err != nil {
+		c.Logger().Errorf("failed to store artifact content: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store artifact"})
+	}
 	artifact := map[string]interface{}{
-		"data":      data,
+		"type":      "artifact",
+		"content":   contentCID,
 		"hash":      artifactHash,
 		"blake3":    blake3Hash,
-		"node_id":   nodeID,
 		"filename":  file.Filename,
 		"size":      file.Size,
-		"timestamp": time.Now(),
+		"timestamp": time.Now().UTC(),
+	}
+	if nodeCID, err := dag.ParseCID(nodeID); err == nil {
+		if !h.blocks.Has(nodeCID) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "node " + nodeCID.String() + " not found"})
+		}
+		artifact["node"] = nodeCID
+	} else {
+		artifact["node_id"] = nodeID
 	}
 
-	h.db.Save(artifactKey, artifact)
+	artifactCID, err := h.blocks.Put(artifact)
+	if err != nil {
+		c.Logger().Errorf("failed to store artifact: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store artifact"})
+	}
 
-	filePath := fmt.Sprintf("%s/%s", h.artifactsDir, blake3Hash[:16])
+	filePath := fmt.Sprintf("%s/%s", h.artifactsDir, contentCID.String())
 	os.WriteFile(filePath, data, 0644)
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"key":     artifactKey,
-		"hash":    artifactHash,
-		"blake3":  blake3Hash,
-		"node_id": nodeID,
+		"cid":         artifactCID.String(),
+		"key":         "/ipfs/" + artifactCID.String(),
+		"content_cid": contentCID.String(),
+		"hash":        artifactHash,
+		"blake3":      blake3Hash,
+		"node_id":     nodeID,
 	})
 }
 
-// FinalizeManifest builds manifest from node/artifact keys
+// FinalizeManifest builds a manifest block linking to node and artifact
+// blocks by CID. Records stored before the DAG existed, under
+// /ipfs/node-... style keys, are copied into blocks first.
 func (h *Handlers) FinalizeManifest(c echo.Context) error {
 	var req struct {
 		NodeKeys     []string `json:"node_keys"`
@@ -1114,78 +2150,287 @@ func (h *Handlers) FinalizeManifest(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request"})
 	}
 
-	nodes := []interface{}{}
-	artifacts := []interface{}{}
-
-	for _, key := range req.NodeKeys {
-		if val, ok := h.db.Get(key); ok {
-			nodes = append(nodes, val)
-		}
+	nodes, err := h.linkKeys(req.NodeKeys, "node")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
-
-	for _, key := range req.ArtifactKeys {
-		if val, ok := h.db.Get(key); ok {
-			artifacts = append(artifacts, val)
-		}
+	artifacts, err := h.linkKeys(req.ArtifactKeys, "artifact")
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 	}
 
 	manifest := map[string]interface{}{
+		"type":      "manifest",
 		"nodes":     nodes,
 		"artifacts": artifacts,
-		"timestamp": time.Now(),
+		"timestamp": time.Now().UTC(),
+	}
+	manifestCID, err := h.blocks.Put(manifest)
+	if err != nil {
+		c.Logger().Errorf("failed to store manifest: %v", err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store manifest"})
 	}
 
-	manifestJSON, _ := json.Marshal(manifest)
-	manifestHash := crypto.Blake3Hex(manifestJSON)
-
-	manifestKey := fmt.Sprintf("/ipfs/manifest-%s", manifestHash[:16])
-	h.db.Save(manifestKey, manifest)
-
-	filePath := fmt.Sprintf("%s/%s.json", h.manifestsDir, manifestHash[:16])
-	os.WriteFile(filePath, manifestJSON, 0644)
+	if block, err := h.blocks.Block(manifestCID); err == nil {
+		filePath := fmt.Sprintf("%s/%s", h.manifestsDir, manifestCID.String())
+		os.WriteFile(filePath, block, 0644)
+	}
 
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"key":      manifestKey,
-		"hash":     manifestHash,
+		"cid":      manifestCID.String(),
+		"key":      "/ipfs/" + manifestCID.String(),
+		"hash":     dag.Digest(manifestCID),
 		"manifest": manifest,
 	})
 }
 
-// Verify verifies a signature for a given key
+// linkKeys returns the CIDs of the blocks named by keys, which are CIDs,
+// /ipfs/<cid> paths or legacy record keys of kind. Any other store key is
+// refused, so the rest of the store cannot be copied into public blocks.
+func (h *Handlers) linkKeys(keys []string, kind string) ([]cid.Cid, error) {
+	links := []cid.Cid{}
+	for _, key := range keys {
+		if blockCID, err := dag.ParseCID(key); err == nil {
+			if !h.blocks.Has(blockCID) {
+				return nil, fmt.Errorf("%s %s not found", kind, blockCID)
+			}
+			links = append(links, blockCID)
+			continue
+		}
+
+		if recordKind(key) != kind {
+			return nil, fmt.Errorf("%s is neither a CID nor a %s record key", key, kind)
+		}
+		val, ok := h.db.Get(key)
+		if !ok {
+			return nil, fmt.Errorf("%s %s not found", kind, key)
+		}
+		record, ok := val.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("malformed %s record %s", kind, key)
+		}
+		if _, typed := record["type"]; !typed {
+			copied := map[string]interface{}{"type": kind}
+			for k, v := range record {
+				copied[k] = v
+			}
+			record = copied
+		}
+		blockCID, err := h.blocks.Put(record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy %s into a block: %w", key, err)
+		}
+		links = append(links, blockCID)
+	}
+	return links, nil
+}
+
+// canonicalJSON returns the RFC 8785 canonical bytes of v, which are what
+// gets hashed and signed, along with v in its generic JSON form (maps,
+// slices, strings, float64s) so canonicalizing it again yields the same
+// bytes
+func canonicalJSON(v interface{}) ([]byte, interface{}, error) {
+	canonical, err := jcs.Marshal(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(canonical, &generic); err != nil {
+		return nil, nil, err
+	}
+	return canonical, generic, nil
+}
+
+// NodeVerification is the result of verifying a stored node
+type NodeVerification struct {
+	Key            string      `json:"key"`
+	Valid          bool        `json:"valid"`
+	HashValid      bool        `json:"hash_valid"`
+	SignatureValid bool        `json:"signature_valid"`
+	Reason         string      `json:"reason,omitempty"`
+	Data           interface{} `json:"data"`
+	Hash           string      `json:"hash"`
+	ComputedHash   string      `json:"computed_hash"`
+	Author         string      `json:"author,omitempty"`
+	PublicKey      string      `json:"public_key"`
+	KeyID          string      `json:"key_id,omitempty"`
+	KeyStatus      string      `json:"key_status,omitempty"`
+	Signature      string      `json:"signature"`
+}
+
+// nodeRecordKinds maps store key prefixes to the kind of record they hold
+var nodeRecordKinds = []struct{ prefix, kind string }{
+	{"/ipfs/node-", "node"},
+	{"/ipfs/artifact-", "artifact"},
+	{"/ipfs/manifest-", "manifest"},
+	{"/ipfs/ext-", "ext-push"},
+}
+
+// recordKind returns the kind of record a legacy store key holds, or
+// "unknown"
+func recordKind(key string) string {
+	for _, k := range nodeRecordKinds {
+		if strings.HasPrefix(key, k.prefix) {
+			return k.kind
+		}
+	}
+	return "unknown"
+}
+
+// Verify checks a stored node, given by CID (cid, or key as /ipfs/<cid>)
+// or by its legacy record key: its hash must be BLAKE3 of the canonical node
+// data, its detached JWS must verify over the same bytes with the node's
+// public key, and that key must be registered to the node's author and not
+// revoked. Keys rotated out since the node was signed still verify.
 func (h *Handlers) Verify(c echo.Context) error {
 	key := c.QueryParam("key")
 	if key == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "key parameter required"})
+		key = c.QueryParam("cid")
+	}
+	if key == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "key or cid parameter required"})
+	}
+
+	// Only nodes are signed; other records get a 4xx naming what they are
+	var val interface{}
+	kind := "unknown"
+	if blockCID, err := dag.ParseCID(key); err == nil {
+		n, err := h.blocks.Get(blockCID)
+		if errors.Is(err, dag.ErrNotFound) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "block not found"})
+		}
+		if err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error(), "kind": kind})
+		}
+		val = dag.ToJSON(n, nil)
+		if block, ok := val.(map[string]interface{}); ok {
+			if t, ok := block["type"].(string); ok {
+				kind = t
+			}
+		}
+		key = "/ipfs/" + blockCID.String()
+	} else {
+		var ok bool
+		if val, ok = h.db.Get(key); !ok {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "key not found"})
+		}
+		kind = recordKind(key)
+	}
+	if kind != "node" {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": fmt.Sprintf("%s records are not signed, only nodes can be verified", kind),
+			"kind":  kind,
+		})
 	}
 
-	val, ok := h.db.Get(key)
+	data, ok := val.(map[string]interface{})
 	if !ok {
-		return c.JSON(http.StatusNotFound, map[string]string{"error": "key not found"})
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "malformed node record", "kind": kind})
 	}
 
-	data := val.(map[string]interface{})
+	result := &NodeVerification{Key: key, Data: data["data"]}
+	result.Signature, _ = data["signature"].(string)
+	result.PublicKey, _ = data["public_key"].(string)
+	result.Hash, _ = data["hash"].(string)
+	result.KeyID, _ = data["key_id"].(string)
+	if nodeData, ok := result.Data.(map[string]interface{}); ok {
+		result.Author, _ = nodeData["author"].(string)
+	}
 
-	signature, _ := data["signature"].(string)
-	nodeData := data["data"]
-	publicKey, _ := data["public_key"].(string)
+	canonical, _, err := canonicalJSON(result.Data)
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "malformed node data", "kind": kind})
+	}
+	result.ComputedHash = crypto.Blake3Hex(canonical)
+	result.HashValid = result.Hash == result.ComputedHash
+
+	if result.Signature == "" || result.PublicKey == "" {
+		result.Reason = "no signature found"
+	} else if pub, err := crypto.ParseEd25519PublicKey(result.PublicKey); err != nil {
+		result.Reason = err.Error()
+	} else if _, err := crypto.VerifyJWS([]byte(result.Signature), canonical, pub); err != nil {
+		result.Reason = err.Error()
+	} else if rec, err := h.keys.CheckKey(result.Author, result.PublicKey); err != nil {
+		// Nodes signed before signing keys were bound to users, or by a
+		// revoked key, are not attributable to their author
+		if rec != nil {
+			result.KeyStatus = rec.Status
+		}
+		result.Reason = err.Error()
+	} else {
+		result.KeyStatus = rec.Status
+		result.SignatureValid = true
+	}
+	if result.Reason == "" && !result.HashValid {
+		result.Reason = "hash does not match BLAKE3 of the node data"
+	}
+	result.Valid = result.HashValid && result.SignatureValid
 
-	if signature == "" || publicKey == "" {
-		return c.JSON(http.StatusOK, map[string]interface{}{
-			"key":    key,
-			"valid":  false,
-			"reason": "no signature found",
-			"data":   nodeData,
-		})
+	return c.JSON(http.StatusOK, result)
+}
+
+// maxDAGDepth bounds how many levels of links GetDAG renders
+const maxDAGDepth = 16
+
+// GetDAG resolves /dag/:cid[/path...]: the path walks map keys and list
+// indexes from the block, following links, and the block it ends in is
+// rendered as DAG-JSON with linked blocks expanded depth levels deep
+// (default 1). format=raw returns the block bytes instead.
+func (h *Handlers) GetDAG(c echo.Context) error {
+	root, err := dag.ParseCID(c.Param("cid"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid CID: " + err.Error()})
 	}
 
-	_, _ = json.Marshal(nodeData)
+	depth := 1
+	if d := c.QueryParam("depth"); d != "" {
+		if depth, err = strconv.Atoi(d); err != nil || depth < 0 || depth > maxDAGDepth {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("depth must be between 0 and %d", maxDAGDepth)})
+		}
+	}
 
+	path := []string{}
+	for _, seg := range strings.Split(c.Param("*"), "/") {
+		if seg != "" {
+			path = append(path, seg)
+		}
+	}
+
+	n, at, whole, err := h.blocks.Resolve(root, path)
+	if errors.Is(err, dag.ErrNotFound) || errors.Is(err, dag.ErrNoPath) {
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+	}
+
+	if whole && c.QueryParam("format") == "raw" {
+		block, err := h.blocks.Block(at)
+		if err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		}
+		return c.Blob(http.StatusOK, "application/vnd.ipld."+dag.CodecName(at), block)
+	}
+	if whole {
+		return c.JSON(http.StatusOK, h.blocks.Render(at, depth))
+	}
+	if c.QueryParam("format") == "raw" {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": "path ends inside block " + at.String() + ", not on a block"})
+	}
+
+	// The path ends on a value inside a block
+	links := []cid.Cid{}
+	value := dag.ToJSON(n, &links)
+	resolved := map[string]*dag.Resolved{}
+	if depth > 0 {
+		for _, link := range links {
+			resolved[link.String()] = h.blocks.Render(link, depth-1)
+		}
+	}
 	return c.JSON(http.StatusOK, map[string]interface{}{
-		"key":        key,
-		"valid":      signature != "",
-		"data":       nodeData,
-		"hash":       data["hash"],
-		"public_key": publicKey,
-		"signature":  signature,
+		"cid":   at.String(),
+		"path":  strings.Join(path, "/"),
+		"value": value,
+		"links": resolved,
 	})
 }
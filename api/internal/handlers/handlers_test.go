@@ -0,0 +1,443 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"yourproject/internal/auth"
+	"yourproject/internal/crypto"
+	"yourproject/internal/dag"
+	"yourproject/internal/eth"
+	"yourproject/internal/ipfsdb"
+	"yourproject/internal/models"
+)
+
+// newTestHandler returns a Handler on an in-memory store with no chains
+// and no Pinata, and the ID of an artist with a signing key
+func newTestHandler(t *testing.T) (*Handler, string) {
+	t.Helper()
+	t.Setenv("WATERMARK_KEY", "test watermark key, 32 bytes....")
+	t.Setenv("PINATA_API_KEY", "")
+	t.Setenv("PINATA_API_SECRET", "")
+
+	db := ipfsdb.New()
+	keys, err := auth.NewKeystore(db, []byte("test keystore key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	chains, err := eth.NewChainRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(db, ipfsdb.NewStorageService(db), ipfsdb.NewIPFSClient(db), ipfsdb.NewBlockchainClient(db, chains), keys)
+
+	artist := &models.User{ID: "6a1f0d2c-8b3e-4f5a-9c7d-1e2f3a4b5c6d", UserType: auth.RoleArtist, CreatedAt: time.Now()}
+	if err := db.Save(artist.ID, artist); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.EnsureKey(artist); err != nil {
+		t.Fatal(err)
+	}
+	return h, artist.ID
+}
+
+// testArtwork draws a smooth, textured image like the artworks the
+// watermark is tuned for
+func testArtwork(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(128 + 80*math.Sin(float64(x)/23)*math.Cos(float64(y)/31)),
+				G: uint8(40 + 150*y/height),
+				B: uint8(200 - 120*x/width),
+				A: 0xff,
+			})
+		}
+	}
+	return img
+}
+
+// verifyArtwork calls GET /verify/:id
+func verifyArtwork(t *testing.T, h *Handler, artworkID string) *models.VerificationResult {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/verify/"+artworkID, nil), rec)
+	c.SetParamNames("id")
+	c.SetParamValues(artworkID)
+	if err := h.VerifyArtwork(c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /verify/%s: %d %s", artworkID, rec.Code, rec.Body)
+	}
+	var result models.VerificationResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	return &result
+}
+
+// uploadForVerification calls POST /verify/upload with data as the file
+func uploadForVerification(t *testing.T, h *Handler, data []byte) *models.VerificationResult {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", "upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/verify/upload", &body)
+	req.Header.Set(echo.HeaderContentType, form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	if err := h.UploadForVerification(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /verify/upload: %d %s", rec.Code, rec.Body)
+	}
+	var result models.VerificationResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	return &result
+}
+
+// hasStep reports whether a verification step starts with prefix
+func hasStep(result *models.VerificationResult, prefix string) bool {
+	for _, step := range result.VerificationSteps {
+		if strings.HasPrefix(step, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestArtworkRoundTrip(t *testing.T) {
+	artwork := testArtwork(512, 480)
+	encode := map[string]func(*image.RGBA) ([]byte, error){
+		"png": func(img *image.RGBA) ([]byte, error) {
+			var buf bytes.Buffer
+			err := png.Encode(&buf, img)
+			return buf.Bytes(), err
+		},
+		"jpeg": func(img *image.RGBA) ([]byte, error) {
+			var buf bytes.Buffer
+			err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90})
+			return buf.Bytes(), err
+		},
+		"webp": encodeWebPLossless,
+	}
+
+	for _, format := range []string{"png", "jpeg", "webp"} {
+		t.Run(format, func(t *testing.T) {
+			h, artistID := newTestHandler(t)
+			input, err := encode[format](artwork)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, decoded, err := image.Decode(bytes.NewReader(input)); err != nil || decoded != format {
+				t.Fatalf("input decodes as %q (%v), want %s", decoded, err, format)
+			}
+
+			stored, _, err := h.processArtwork(context.Background(), nil, artistID, "0x8ba1f109551bD432803012645Ac136ddd64DBA72", "a penguin", input, "image", "test")
+			if err != nil {
+				t.Fatalf("processArtwork: %v", err)
+			}
+
+			// Both layers, the content hash and the signature check out
+			result := verifyArtwork(t, h, stored.ID)
+			if !result.IsAuthentic || result.TamperDetected {
+				t.Errorf("artwork not authentic: %q", result.VerificationSteps)
+			}
+			for _, step := range []string{"IPFS integrity check: PASSED", "Watermark payload: PASSED", "Artist signature: PASSED", "Noise pattern: PASSED"} {
+				if !hasStep(result, step) {
+					t.Errorf("no %q step in %q", step, result.VerificationSteps)
+				}
+			}
+
+			// The distributed file is identified by its hash, and a JPEG
+			// copy of it by its watermark
+			content, err := h.ipfsClient.DownloadFile(stored.IPFSHash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result := uploadForVerification(t, h, content); result.ArtworkID != stored.ID || result.MatchedBy != "hash" || !result.IsAuthentic {
+				t.Errorf("upload of the stored file: %+v", result)
+			}
+			marked, _, err := image.Decode(bytes.NewReader(content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var copied bytes.Buffer
+			if err := jpeg.Encode(&copied, marked, &jpeg.Options{Quality: 75}); err != nil {
+				t.Fatal(err)
+			}
+			if result := uploadForVerification(t, h, copied.Bytes()); result.ArtworkID != stored.ID || result.MatchedBy != "watermark" || !result.IsAuthentic {
+				t.Errorf("upload of a JPEG copy: %+v", result)
+			}
+
+			// A retouched copy in place of the stored content loses the
+			// fragile noise layer but keeps the watermark
+			retouched := image.NewRGBA(marked.Bounds())
+			for y := 0; y < retouched.Bounds().Dy(); y++ {
+				for x := 0; x < retouched.Bounds().Dx(); x++ {
+					r, g, b, _ := marked.At(x, y).RGBA()
+					retouched.SetRGBA(x, y, color.RGBA{R: uint8(r>>8) ^ 1, G: uint8(g >> 8), B: uint8(b>>8) ^ 1, A: 0xff})
+				}
+			}
+			var tampered bytes.Buffer
+			if err := png.Encode(&tampered, retouched); err != nil {
+				t.Fatal(err)
+			}
+			if err := h.db.Save(stored.IPFSHash, tampered.Bytes()); err != nil {
+				t.Fatal(err)
+			}
+			result = verifyArtwork(t, h, stored.ID)
+			if result.IsAuthentic || !result.TamperDetected {
+				t.Errorf("retouched artwork verifies: %q", result.VerificationSteps)
+			}
+			for _, step := range []string{"IPFS integrity check: FAILED", "Watermark payload: PASSED", "Noise pattern: FAILED"} {
+				if !hasStep(result, step) {
+					t.Errorf("no %q step in %q", step, result.VerificationSteps)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessArtworkRejectsSmallImages(t *testing.T) {
+	h, artistID := newTestHandler(t)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testArtwork(320, 240)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := h.processArtwork(context.Background(), nil, artistID, "", "a penguin", buf.Bytes(), "image", "test"); !errors.Is(err, crypto.ErrImageTooSmall) {
+		t.Errorf("processArtwork of a 320x240 image: %v, want ErrImageTooSmall", err)
+	}
+}
+
+// encodeWebPLossless encodes img as a lossless WebP (VP8L) without
+// transforms or backward references: every pixel is four literals under
+// fixed 8-bit Huffman codes. The standard library has no WebP encoder.
+func encodeWebPLossless(img *image.RGBA) ([]byte, error) {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	w := &lsbWriter{}
+	w.write(0x2f, 8)
+	w.write(uint32(width-1), 14)
+	w.write(uint32(height-1), 14)
+	w.write(0, 1) // alpha hint
+	w.write(0, 3) // version
+	w.write(0, 1) // no transforms
+	w.write(0, 1) // no color cache
+	w.write(0, 1) // no meta prefix codes
+
+	// Green (256 literals + 24 length prefixes), red and blue: every
+	// literal gets an 8-bit code, so a symbol's code is its value
+	for _, alphabet := range []int{280, 256, 256} {
+		w.write(0, 1) // normal code
+		w.write(8, 4) // 12 code length code lengths, up to symbol 8
+		for i := 0; i < 12; i++ {
+			// In code length code order (17, 18, 0, 1, ..., 16, 6, 7, 8)
+			// only 0 and 8 are used, with 1-bit codes 0 and 1
+			if i == 2 || i == 11 {
+				w.write(1, 3)
+			} else {
+				w.write(0, 3)
+			}
+		}
+		w.write(0, 1) // lengths for the whole alphabet
+		for symbol := 0; symbol < alphabet; symbol++ {
+			if symbol < 256 {
+				w.write(1, 1)
+			} else {
+				w.write(0, 1)
+			}
+		}
+	}
+	// Alpha is always 255 and distances are unused: single-symbol codes
+	// take no bits per pixel
+	w.write(1, 1)
+	w.write(0, 1)
+	w.write(1, 1)
+	w.write(0xff, 8)
+	w.write(1, 1)
+	w.write(0, 1)
+	w.write(0, 1)
+	w.write(0, 1)
+
+	for i := 0; i < len(img.Pix); i += 4 {
+		if img.Pix[i+3] != 0xff {
+			return nil, errors.New("encodeWebPLossless: only opaque images are supported")
+		}
+		for _, c := range []byte{img.Pix[i+1], img.Pix[i], img.Pix[i+2]} {
+			// Huffman codes are written from their most significant bit
+			for bit := 7; bit >= 0; bit-- {
+				w.write(uint32(c>>bit)&1, 1)
+			}
+		}
+	}
+
+	data := w.bytes()
+	chunk := make([]byte, 0, 20+len(data)+1)
+	chunk = append(chunk, "RIFF"...)
+	chunk = binary.LittleEndian.AppendUint32(chunk, uint32(12+len(data)+len(data)%2))
+	chunk = append(chunk, "WEBPVP8L"...)
+	chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(data)))
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk, nil
+}
+
+// lsbWriter packs bits least significant first, as VP8L reads them
+type lsbWriter struct {
+	buf   []byte
+	acc   uint64
+	nBits uint
+}
+
+func (w *lsbWriter) write(v uint32, n uint) {
+	w.acc |= uint64(v) << w.nBits
+	w.nBits += n
+	for w.nBits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nBits -= 8
+	}
+}
+
+func (w *lsbWriter) bytes() []byte {
+	if w.nBits > 0 {
+		return append(w.buf, byte(w.acc))
+	}
+	return w.buf
+}
+
+// newTestHandlers returns node Handlers on an in-memory store, and the store
+func newTestHandlers(t *testing.T) (*Handlers, *ipfsdb.IPFSDB) {
+	t.Helper()
+	db := ipfsdb.New()
+	keys, err := auth.NewKeystore(db, []byte("test keystore key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, err := dag.New(db, dag.CodecDagCBOR, dag.HashBlake3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewHandlers(db, t.TempDir(), t.TempDir(), keys, blocks), db
+}
+
+// finalize calls POST /finalize with body and returns the response
+func finalize(t *testing.T, h *Handlers, body map[string][]string) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/finalize", bytes.NewReader(payload))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	if err := h.FinalizeManifest(echo.New().NewContext(req, rec)); err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestFinalizeManifestLinksOnlyRecordKeys(t *testing.T) {
+	h, db := newTestHandlers(t)
+	user := &models.User{ID: "0f4e2a1b-7c3d-4b5e-8a9f-6d7c8b9a0e1f", DisplayName: "Ada Lovelace"}
+	if err := db.Save(user.ID, user); err != nil {
+		t.Fatal(err)
+	}
+	user, err := h.keys.EnsureKey(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Save("/ipfs/node-legacy", map[string]interface{}{"data": map[string]interface{}{"prompt": "dawn"}}); err != nil {
+		t.Fatal(err)
+	}
+	blocks := func() int {
+		n := 0
+		for _, key := range db.ListKeys() {
+			if strings.HasPrefix(key, "/dag/") {
+				n++
+			}
+		}
+		return n
+	}
+
+	for _, body := range []map[string][]string{
+		{"node_keys": {"/keystore/" + user.ID}},
+		{"node_keys": {"/keystore/kid/" + auth.KeyIDFor(user.PublicKey)}},
+		{"node_keys": {user.ID}},
+		{"artifact_keys": {user.ID}},
+		{"artifact_keys": {"/ipfs/node-legacy"}},
+	} {
+		rec := finalize(t, h, body)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("POST /finalize %v: %d %s, want 400", body, rec.Code, rec.Body)
+		}
+		if strings.Contains(rec.Body.String(), "Lovelace") || strings.Contains(rec.Body.String(), "sealed") {
+			t.Errorf("POST /finalize %v leaked the user: %s", body, rec.Body)
+		}
+	}
+	if n := blocks(); n != 0 {
+		t.Fatalf("refused keys left %d blocks", n)
+	}
+
+	// A legacy node record is copied into a block and linked
+	rec := finalize(t, h, map[string][]string{"node_keys": {"/ipfs/node-legacy"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("POST /finalize with a legacy node key: %d %s", rec.Code, rec.Body)
+	}
+	if n := blocks(); n != 2 {
+		t.Errorf("%d blocks, want the node and the manifest", n)
+	}
+}
+
+func TestCreateNodeRejectsInvalidBody(t *testing.T) {
+	h, db := newTestHandlers(t)
+	user := &models.User{ID: "5b7c9d1e-3f2a-4c6b-8d0e-1a2b3c4d5e6f"}
+	if err := db.Save(user.ID, user); err != nil {
+		t.Fatal(err)
+	}
+
+	for body, want := range map[string]int{
+		`{"kind":"prompt","body":{"seed":100000000000000000000}}`: http.StatusBadRequest,
+		`{"kind":"prompt","body":{"seed":42}}`:                    http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/node", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.Set("db_user", user)
+		if err := h.CreateNode(c); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != want {
+			t.Errorf("POST /node %s: %d %s, want %d", body, rec.Code, rec.Body, want)
+		}
+	}
+}
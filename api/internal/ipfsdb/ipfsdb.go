@@ -2,23 +2,41 @@ package ipfsdb
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
-	"io"
-	"net/http"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	bolt "go.etcd.io/bbolt"
+
+	"yourproject/internal/eth"
+	"yourproject/internal/jcs"
 	"yourproject/internal/models"
 	"yourproject/internal/pinata"
 )
 
-// Mock interface for local development (simulates IPFS persistence)
+// Mock interface for local development (simulates IPFS persistence).
+// Use New for a purely in-memory store or Open for a file-backed one.
+// All methods are safe for concurrent use.
 type IPFSDB struct {
+	mu             sync.RWMutex
 	store          map[string]interface{}
 	crawlerResults map[string][]*models.CrawlerResult // artworkID -> results
 	userArtworks   map[string][]string                // userID -> artworkIDs
+	bolt           *bolt.DB                           // nil when running in-memory only
 }
 
+var _ Store = (*IPFSDB)(nil)
+
+// New creates an in-memory IPFSDB whose contents are lost on restart
 func New() *IPFSDB {
 	return &IPFSDB{
 		store:          make(map[string]interface{}),
@@ -27,18 +45,71 @@ func New() *IPFSDB {
 	}
 }
 
+// Save stores a value under key. Artworks and crawler results are also
+// reflected in the ownership and crawler indexes.
 func (db *IPFSDB) Save(key string, value interface{}) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := db.persist(key, value); err != nil {
+		return fmt.Errorf("failed to persist %s: %w", key, err)
+	}
+	db.reindexLocked(db.store[key], value)
 	db.store[key] = value
 	fmt.Println("Saved to mock IPFS:", key)
 	return nil
 }
 
+// Delete removes key and drops it from the secondary indexes. Deleting a
+// missing key is not an error.
+func (db *IPFSDB) Delete(key string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	old, ok := db.store[key]
+	if !ok {
+		return nil
+	}
+	if err := db.unpersist(key); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	db.unindexLocked(old)
+	delete(db.store, key)
+	return nil
+}
+
+// Update calls fn with the value stored under key, if any, and saves what it
+// returns in its place; nil leaves the store unchanged. The write lock is
+// held throughout, so no other write interleaves. fn must not call db.
+func (db *IPFSDB) Update(key string, fn func(value interface{}, ok bool) interface{}) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	old, ok := db.store[key]
+	value := fn(old, ok)
+	if value == nil {
+		return nil
+	}
+	if err := db.persist(key, value); err != nil {
+		return fmt.Errorf("failed to persist %s: %w", key, err)
+	}
+	db.reindexLocked(old, value)
+	db.store[key] = value
+	return nil
+}
+
 func (db *IPFSDB) Get(key string) (interface{}, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	val, ok := db.store[key]
 	return val, ok
 }
 
 func (db *IPFSDB) ListKeys() []string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	keys := make([]string, 0, len(db.store))
 	for k := range db.store {
 		keys = append(keys, k)
@@ -48,6 +119,9 @@ func (db *IPFSDB) ListKeys() []string {
 
 // FindUserByAuthenticatorID retrieves a user by their Microsoft Authenticator ID
 func (db *IPFSDB) FindUserByAuthenticatorID(authID string) (*models.User, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	for _, val := range db.store {
 		if user, ok := val.(*models.User); ok {
 			if user.AuthenticatorID == authID {
@@ -58,8 +132,43 @@ func (db *IPFSDB) FindUserByAuthenticatorID(authID string) (*models.User, bool)
 	return nil, false
 }
 
+// FindUserByIdentity retrieves a user by the issuer and subject of their
+// login identity
+func (db *IPFSDB) FindUserByIdentity(issuer, subject string) (*models.User, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, val := range db.store {
+		if user, ok := val.(*models.User); ok {
+			if user.Issuer == issuer && user.Subject == subject {
+				return user, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// FindUserByWalletAddress retrieves the user a wallet is linked to. Addresses
+// compare case-insensitively.
+func (db *IPFSDB) FindUserByWalletAddress(address string) (*models.User, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, val := range db.store {
+		if user, ok := val.(*models.User); ok {
+			if user.WalletAddress != "" && strings.EqualFold(user.WalletAddress, address) {
+				return user, true
+			}
+		}
+	}
+	return nil, false
+}
+
 // GetAllArtworks returns all artworks in the database
 func (db *IPFSDB) GetAllArtworks(ctx context.Context) ([]*models.Artwork, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	artworks := []*models.Artwork{}
 	for _, val := range db.store {
 		if artwork, ok := val.(*models.Artwork); ok {
@@ -85,42 +194,26 @@ func (db *IPFSDB) GetArtworkByID(ctx context.Context, id string) (*models.Artwor
 
 // StoreCrawlerResult stores a crawler result in the database
 func (db *IPFSDB) StoreCrawlerResult(ctx context.Context, result *models.CrawlerResult) error {
-	// Store in main store
-	db.Save(result.ID, result)
-
-	// Store in crawler results map for quick lookup
-	if db.crawlerResults[result.OriginalArtworkID] == nil {
-		db.crawlerResults[result.OriginalArtworkID] = []*models.CrawlerResult{}
-	}
-	db.crawlerResults[result.OriginalArtworkID] = append(db.crawlerResults[result.OriginalArtworkID], result)
-
-	return nil
+	return db.Save(result.ID, result)
 }
 
 // GetCrawlerResultsByArtworkID retrieves all crawler results for a specific artwork
 func (db *IPFSDB) GetCrawlerResultsByArtworkID(ctx context.Context, artworkID string) ([]*models.CrawlerResult, error) {
-	results := db.crawlerResults[artworkID]
-	if results == nil {
-		return []*models.CrawlerResult{}, nil
-	}
-	return results, nil
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	// Copy so callers never share the index slice
+	return append([]*models.CrawlerResult{}, db.crawlerResults[artworkID]...), nil
 }
 
 // GetCrawlerResultsByUserID retrieves all crawler results for artworks owned by a user
 func (db *IPFSDB) GetCrawlerResultsByUserID(ctx context.Context, userID string) ([]*models.CrawlerResult, error) {
-	allResults := []*models.CrawlerResult{}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
 
-	// Get all artworks for this user
-	for _, val := range db.store {
-		if artwork, ok := val.(*models.Artwork); ok {
-			if artwork.ArtistID == userID {
-				// Get all crawler results for this artwork
-				results := db.crawlerResults[artwork.ID]
-				if results != nil {
-					allResults = append(allResults, results...)
-				}
-			}
-		}
+	allResults := []*models.CrawlerResult{}
+	for _, artworkID := range db.userArtworks[userID] {
+		allResults = append(allResults, db.crawlerResults[artworkID]...)
 	}
 
 	return allResults, nil
@@ -128,51 +221,40 @@ func (db *IPFSDB) GetCrawlerResultsByUserID(ctx context.Context, userID string)
 
 // UpdateCrawlerResultStatus updates the status of a crawler result
 func (db *IPFSDB) UpdateCrawlerResultStatus(ctx context.Context, resultID string, status string) error {
-	val, ok := db.Get(resultID)
-	if !ok {
-		return fmt.Errorf("crawler result not found")
-	}
-
-	if result, ok := val.(*models.CrawlerResult); ok {
+	_, err := UpdateRecord(db, resultID, func(result *models.CrawlerResult) bool {
 		result.Status = status
-		db.Save(resultID, result)
-		return nil
+		return true
+	})
+	if errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("crawler result not found")
 	}
-
-	return fmt.Errorf("invalid crawler result data")
+	return err
 }
 
 // StoreArtwork stores an artwork and tracks user ownership
 func (db *IPFSDB) StoreArtwork(artwork *models.Artwork) error {
-	db.Save(artwork.ID, artwork)
-
-	// Track user's artworks
-	if db.userArtworks[artwork.ArtistID] == nil {
-		db.userArtworks[artwork.ArtistID] = []string{}
-	}
-	db.userArtworks[artwork.ArtistID] = append(db.userArtworks[artwork.ArtistID], artwork.ID)
-
-	return nil
+	return db.Save(artwork.ID, artwork)
 }
 
 // StorageService provides storage operations for artwork
 type StorageService struct {
-	db     *IPFSDB
+	db     Store
 	pinata *pinata.Client
 }
 
 // NewStorageService creates a new storage service
-func NewStorageService(db *IPFSDB) *StorageService {
+func NewStorageService(db Store) *StorageService {
 	return &StorageService{db: db, pinata: pinata.NewFromEnv()}
 }
 
-// GetDB returns the underlying IPFSDB instance
-func (s *StorageService) GetDB() *IPFSDB {
+// GetDB returns the underlying Store instance
+func (s *StorageService) GetDB() Store {
 	return s.db
 }
 
-// StoreArtwork stores artwork on IPFS and blockchain
-func (s *StorageService) StoreArtwork(ctx context.Context, data []byte, metadata *DAGMetadata) (string, string, error) {
+// StoreArtwork stores artwork content and metadata on IPFS and returns the content CID.
+// On-chain registration is handled separately by BlockchainClient.
+func (s *StorageService) StoreArtwork(ctx context.Context, data []byte, metadata *DAGMetadata) (string, error) {
 	var cid string
 	var err error
 
@@ -180,7 +262,7 @@ func (s *StorageService) StoreArtwork(ctx context.Context, data []byte, metadata
 		// Use UploadFile method with metadata
 		cid, err = s.pinata.UploadFile(data, metadata)
 		if err != nil {
-			return "", "", fmt.Errorf("pinata upload failed: %w", err)
+			return "", fmt.Errorf("pinata upload failed: %w", err)
 		}
 	} else {
 		// Fallback mock CID
@@ -193,32 +275,43 @@ func (s *StorageService) StoreArtwork(ctx context.Context, data []byte, metadata
 	// Set ContentCID in metadata
 	metadata.ContentCID = cid
 
-	// Mock tx hash or provided by blockchain stub
-	txHash := fmt.Sprintf("0x%s", metadata.ArtworkID)
-	if len(txHash) > 66 {
-		txHash = txHash[:66]
+	// Persist content (by CID) and metadata (by artwork ID) for quick lookup.
+	// The artwork ID key itself is reserved for the models.Artwork record.
+	if err := s.db.Save(cid, data); err != nil {
+		return "", fmt.Errorf("failed to save artwork content: %w", err)
+	}
+	if err := s.db.Save(dagMetadataKey(metadata.ArtworkID), metadata); err != nil {
+		return "", fmt.Errorf("failed to save artwork metadata: %w", err)
 	}
 
-	// Persist mapping in mock DB for quick lookup
-	s.db.Save(metadata.ArtworkID, map[string]interface{}{
-		"data":     data,
-		"metadata": metadata,
-		"cid":      cid,
-	})
+	return cid, nil
+}
 
-	return cid, txHash, nil
+// dagMetadataKey returns the store key holding the DAGMetadata of an artwork
+func dagMetadataKey(artworkID string) string {
+	return fmt.Sprintf("/ipfs/dag-%s", artworkID)
 }
 
 // VerifyArtwork verifies artwork from blockchain/IPFS
 func (s *StorageService) VerifyArtwork(ctx context.Context, artworkID string) (*DAGMetadata, *models.ProofCertificate, error) {
 	// Mock implementation
-	val, ok := s.db.Get(artworkID)
+	val, ok := s.db.Get(dagMetadataKey(artworkID))
 	if !ok {
 		return nil, nil, fmt.Errorf("artwork not found")
 	}
 
-	data := val.(map[string]interface{})
-	metadata := data["metadata"].(*DAGMetadata)
+	metadata, ok := val.(*DAGMetadata)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid artwork metadata")
+	}
+
+	// The registration tx hash and chain live on the artwork record
+	var txHash, chain, status string
+	if artwork, err := s.db.GetArtworkByID(ctx, artworkID); err == nil {
+		txHash = artwork.BlockchainTxHash
+		chain = artwork.BlockchainChain
+		status = artwork.BlockchainStatus
+	}
 
 	proof := &models.ProofCertificate{
 		CertificateID:    artworkID,
@@ -227,7 +320,9 @@ func (s *StorageService) VerifyArtwork(ctx context.Context, artworkID string) (*
 		PromptHash:       metadata.PromptHash,
 		ContentHash:      metadata.ContentHash,
 		IPFSHash:         metadata.ContentCID,
-		BlockchainTxHash: metadata.ContentCID,
+		BlockchainTxHash: txHash,
+		BlockchainChain:  chain,
+		BlockchainStatus: status,
 		NoiseSignature:   metadata.NoiseSignature,
 		Timestamp:        metadata.Timestamp,
 		IssuedAt:         time.Now(),
@@ -239,11 +334,11 @@ func (s *StorageService) VerifyArtwork(ctx context.Context, artworkID string) (*
 
 // IPFSClient provides IPFS operations
 type IPFSClient struct {
-	db *IPFSDB
+	db Store
 }
 
 // NewIPFSClient creates a new IPFS client
-func NewIPFSClient(db *IPFSDB) *IPFSClient {
+func NewIPFSClient(db Store) *IPFSClient {
 	return &IPFSClient{db: db}
 }
 
@@ -264,8 +359,11 @@ func (c *IPFSClient) DownloadFile(cid string) ([]byte, error) {
 		return resp, nil
 	}
 
-	data := val.(map[string]interface{})
-	return data["data"].([]byte), nil
+	data, ok := val.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("invalid content for %s", cid)
+	}
+	return data, nil
 }
 
 // httpGet is a tiny indirection to avoid importing net/http at top-level where not needed
@@ -290,14 +388,204 @@ func httpGet(url string) ([]byte, error) {
 	return b, nil
 }
 
-// BlockchainClient provides blockchain operations
+// BlockchainClient provides blockchain operations across the configured chains
 type BlockchainClient struct {
-	db *IPFSDB
+	db     Store
+	chains *eth.ChainRegistry
+}
+
+// NewBlockchainClient creates a new blockchain client. An empty chain
+// registry disables every on-chain feature. Artwork records follow the
+// registration batches of every chain.
+func NewBlockchainClient(db Store, chains *eth.ChainRegistry) *BlockchainClient {
+	c := &BlockchainClient{db: db, chains: chains}
+	for _, chain := range chains.Chains() {
+		if chain.Batcher != nil {
+			chain.Batcher.OnUpdate(c.applyAnchor)
+		}
+	}
+	return c
+}
+
+// applyAnchor copies a batch status change onto the artwork record. The tx
+// hash is only filled in once the batch is in a block.
+func (c *BlockchainClient) applyAnchor(anchor eth.ArtworkAnchor) {
+	_, err := UpdateRecord(c.db, anchor.ArtworkID, func(artwork *models.Artwork) bool {
+		artwork.BlockchainStatus = string(anchor.Status)
+		switch anchor.Status {
+		case eth.AnchorMined, eth.AnchorConfirmed:
+			artwork.BlockchainTxHash = anchor.TxHash
+		default:
+			artwork.BlockchainTxHash = ""
+		}
+		return true
+	})
+	if errors.Is(err, ErrNotFound) {
+		log.Printf("⚠️  Anchor update for unknown artwork %s", anchor.ArtworkID)
+		return
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to update artwork %s: %v", anchor.ArtworkID, err)
+		return
+	}
+	if anchor.Status == eth.AnchorMined {
+		log.Printf("✅ Artwork %s registered on-chain: %s", anchor.ArtworkID, anchor.TxHash)
+	}
+}
+
+// Chain resolves a chain by name; "" selects the default chain. It returns
+// nil, nil when no name is given and no chain is configured at all.
+func (c *BlockchainClient) Chain(name string) (*eth.Chain, error) {
+	if chain, ok := c.chains.Get(name); ok {
+		return chain, nil
+	}
+	if name == "" {
+		if len(c.chains.Chains()) == 0 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("no default chain configured, available chains: %s", strings.Join(c.chains.Names(), ", "))
+	}
+	return nil, fmt.Errorf("unknown chain %q, available chains: %s", name, strings.Join(c.chains.Names(), ", "))
+}
+
+// RegisterArtwork queues the given metadata for the chain's next
+// ProofOfArt.batchRegisterArtworks transaction
+func (c *BlockchainClient) RegisterArtwork(chain *eth.Chain, metadata *DAGMetadata) (*eth.ArtworkAnchor, error) {
+	if chain.Batcher == nil {
+		return nil, fmt.Errorf("blockchain registration not configured on %s", chain.Name)
+	}
+	return chain.Batcher.Enqueue(metadata.ArtworkID, metadata.ContentHash, metadata.ContentCID, metadata.NoiseSignature)
+}
+
+// Anchor returns the registration status of an artwork on chain
+func (c *BlockchainClient) Anchor(chain *eth.Chain, artworkID string) (*eth.ArtworkAnchor, bool) {
+	if chain.Batcher == nil {
+		return nil, false
+	}
+	return chain.Batcher.Status(artworkID)
+}
+
+// StoreManifest anchors a manifest CID via ImageProvenance.storeManifest on chain
+func (c *BlockchainClient) StoreManifest(ctx context.Context, chain *eth.Chain, cid string) (*eth.TxRecord, error) {
+	if chain.Provenance == nil {
+		return nil, fmt.Errorf("manifest anchoring not configured on %s", chain.Name)
+	}
+	return chain.Provenance.StoreManifest(ctx, cid)
+}
+
+// QueueManifest adds a manifest CID to the chain's next Merkle batch
+func (c *BlockchainClient) QueueManifest(chain *eth.Chain, cid string) error {
+	if chain.Manifests == nil {
+		return fmt.Errorf("merkle manifest anchoring not enabled on %s", chain.Name)
+	}
+	return chain.Manifests.Enqueue(cid)
+}
+
+// ManifestProof finds the chain a manifest CID was batched on and returns
+// its inclusion proof. found is false when no chain tracks the CID.
+func (c *BlockchainClient) ManifestProof(cid string) (*eth.Chain, *eth.ManifestProof, bool, error) {
+	for _, chain := range c.chains.Chains() {
+		if chain.Manifests == nil {
+			continue
+		}
+		if _, _, ok := chain.Manifests.Status(cid); !ok {
+			continue
+		}
+		proof, err := chain.Manifests.Proof(cid)
+		return chain, proof, true, err
+	}
+	return nil, nil, false, nil
+}
+
+// TransactionDetails returns the chain, live receipt and confirmation count
+// of a transaction submitted by this server
+func (c *BlockchainClient) TransactionDetails(ctx context.Context, hash string) (*eth.Chain, *eth.TxDetails, bool, error) {
+	return c.chains.FindTransaction(ctx, hash)
 }
 
-// NewBlockchainClient creates a new blockchain client
-func NewBlockchainClient(db *IPFSDB) *BlockchainClient {
-	return &BlockchainClient{db: db}
+// ManifestStoredEvents decodes ManifestStored logs emitted by the chain's
+// ImageProvenance contract in receipt
+func (c *BlockchainClient) ManifestStoredEvents(chain *eth.Chain, receipt *types.Receipt) ([]*eth.ImageProvenanceManifestStored, error) {
+	if chain.Provenance == nil {
+		return []*eth.ImageProvenanceManifestStored{}, nil
+	}
+	return chain.Provenance.ManifestStoredEvents(receipt)
+}
+
+// ChainVerification is the result of comparing an artwork's on-chain proof
+// with its DAG metadata
+type ChainVerification struct {
+	Registered     bool
+	ContentMatches bool
+	IPFSMatches    bool
+	OwnerMatches   bool
+	Steps          []string
+}
+
+// Passed reports whether every on-chain check succeeded
+func (v *ChainVerification) Passed() bool {
+	return v.Registered && v.ContentMatches && v.IPFSMatches && v.OwnerMatches
+}
+
+// VerifyArtwork reads getArtworkProof/verifyOwnership from the chain's
+// ProofOfArt contract and compares the result with metadata
+func (c *BlockchainClient) VerifyArtwork(ctx context.Context, chain *eth.Chain, metadata *DAGMetadata) (*ChainVerification, error) {
+	registry := chain.Artworks
+	if registry == nil {
+		return nil, fmt.Errorf("blockchain registration not configured on %s", chain.Name)
+	}
+
+	result := &ChainVerification{}
+
+	proof, err := registry.GetArtworkProof(ctx, metadata.ArtworkID)
+	if err != nil {
+		result.Steps = append(result.Steps, fmt.Sprintf("Blockchain registration: FAILED (%v)", err))
+		return result, nil
+	}
+	result.Registered = proof.Exists
+	if !proof.Exists {
+		result.Steps = append(result.Steps, "Blockchain registration: FAILED (artwork not found on-chain)")
+		return result, nil
+	}
+	result.Steps = append(result.Steps, fmt.Sprintf("Blockchain registration: PASSED (registered at unix time %s)", proof.Timestamp))
+
+	result.ContentMatches = proof.ContentHash == metadata.ContentHash
+	if result.ContentMatches {
+		result.Steps = append(result.Steps, "On-chain content hash: PASSED")
+	} else {
+		result.Steps = append(result.Steps, fmt.Sprintf("On-chain content hash: FAILED (chain %s, metadata %s)", proof.ContentHash, metadata.ContentHash))
+	}
+
+	result.IPFSMatches = proof.IpfsHash == metadata.ContentCID
+	if result.IPFSMatches {
+		result.Steps = append(result.Steps, "On-chain IPFS hash: PASSED")
+	} else {
+		result.Steps = append(result.Steps, fmt.Sprintf("On-chain IPFS hash: FAILED (chain %s, metadata %s)", proof.IpfsHash, metadata.ContentCID))
+	}
+
+	// Registrations are submitted by the platform signer on behalf of the
+	// artist, so the on-chain owner is either the artist or that signer.
+	switch {
+	case !common.IsHexAddress(metadata.ArtistWallet):
+		result.Steps = append(result.Steps, fmt.Sprintf("On-chain ownership: FAILED (invalid artist wallet %q)", metadata.ArtistWallet))
+	default:
+		artist := common.HexToAddress(metadata.ArtistWallet)
+		owns, err := registry.VerifyOwnership(ctx, metadata.ArtworkID, artist)
+		switch {
+		case err != nil:
+			result.Steps = append(result.Steps, fmt.Sprintf("On-chain ownership: FAILED (%v)", err))
+		case owns:
+			result.OwnerMatches = true
+			result.Steps = append(result.Steps, "On-chain ownership: PASSED (artist wallet)")
+		case proof.ArtistWallet == registry.From():
+			result.OwnerMatches = true
+			result.Steps = append(result.Steps, fmt.Sprintf("On-chain ownership: PASSED (registered by platform signer %s on behalf of %s)", proof.ArtistWallet.Hex(), artist.Hex()))
+		default:
+			result.Steps = append(result.Steps, fmt.Sprintf("On-chain ownership: FAILED (registered to %s, expected %s)", proof.ArtistWallet.Hex(), artist.Hex()))
+		}
+	}
+
+	return result, nil
 }
 
 // DAGMetadata represents metadata for IPFS DAG
@@ -311,4 +599,25 @@ type DAGMetadata struct {
 	Timestamp      time.Time         `json:"timestamp"`
 	Metadata       map[string]string `json:"metadata"`
 	ContentCID     string            `json:"content_cid"`
+	Signature      string            `json:"signature,omitempty"` // artist's detached Ed25519 signature over SigningBytes
+}
+
+// SigningBytes returns the bytes the artist signs: the metadata as RFC 8785
+// canonical JSON without its signature and without the content CID, which
+// is only known once the content is pinned (ContentHash already binds the
+// content)
+func (m *DAGMetadata) SigningBytes() ([]byte, error) {
+	unsigned := *m
+	unsigned.Signature = ""
+	unsigned.ContentCID = ""
+	return jcs.Marshal(&unsigned)
+}
+
+// LegacySigningBytes returns what artworks signed before canonical JSON
+// was introduced signed: the same fields in encoding/json's output
+func (m *DAGMetadata) LegacySigningBytes() ([]byte, error) {
+	unsigned := *m
+	unsigned.Signature = ""
+	unsigned.ContentCID = ""
+	return json.Marshal(&unsigned)
 }
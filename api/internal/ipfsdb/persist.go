@@ -0,0 +1,245 @@
+package ipfsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"yourproject/internal/eth"
+	"yourproject/internal/models"
+)
+
+// recordsBucket holds every key saved through IPFSDB.Save
+var recordsBucket = []byte("records")
+
+// Record kinds used to tag persisted values so they decode back to their Go types
+const (
+	kindUser           = "user"
+	kindUserKey        = "user_key"
+	kindSigningKey     = "signing_key"
+	kindWalletNonce    = "wallet_nonce"
+	kindArtwork        = "artwork"
+	kindCrawlerResult  = "crawler_result"
+	kindDAGMetadata    = "dag_metadata"
+	kindTx             = "eth_tx"
+	kindManifestEvent  = "eth_manifest_event"
+	kindArtworkEvent   = "eth_artwork_event"
+	kindCheckpoint     = "eth_index_checkpoint"
+	kindAnchor         = "eth_anchor"
+	kindManifestBatch  = "eth_manifest_batch"
+	kindManifestAnchor = "eth_manifest_anchor"
+	kindBytes          = "bytes"
+	kindJSON           = "json"
+)
+
+// record is the on-disk envelope for a single value
+type record struct {
+	Kind  string          `json:"kind"`
+	Value json.RawMessage `json:"value"`
+}
+
+// encodeRecord serializes a value together with its kind tag
+func encodeRecord(value interface{}) ([]byte, error) {
+	kind := kindJSON
+	switch value.(type) {
+	case *models.User:
+		kind = kindUser
+	case *models.UserKey:
+		kind = kindUserKey
+	case *models.SigningKeyRecord:
+		kind = kindSigningKey
+	case *models.WalletNonce:
+		kind = kindWalletNonce
+	case *models.Artwork:
+		kind = kindArtwork
+	case *models.CrawlerResult:
+		kind = kindCrawlerResult
+	case *DAGMetadata:
+		kind = kindDAGMetadata
+	case *eth.TxRecord:
+		kind = kindTx
+	case *eth.ManifestEvent:
+		kind = kindManifestEvent
+	case *eth.ArtworkEvent:
+		kind = kindArtworkEvent
+	case *eth.IndexCheckpoint:
+		kind = kindCheckpoint
+	case *eth.ArtworkAnchor:
+		kind = kindAnchor
+	case *eth.ManifestBatch:
+		kind = kindManifestBatch
+	case *eth.ManifestAnchor:
+		kind = kindManifestAnchor
+	case []byte:
+		kind = kindBytes
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s record: %w", kind, err)
+	}
+
+	return json.Marshal(record{Kind: kind, Value: raw})
+}
+
+// decodeRecord restores a value from its envelope. Untyped values come back
+// as the generic JSON representation (maps, slices, strings, numbers).
+func decodeRecord(data []byte) (interface{}, error) {
+	var rec record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode record: %w", err)
+	}
+
+	var value interface{}
+	switch rec.Kind {
+	case kindUser:
+		value = &models.User{}
+	case kindUserKey:
+		value = &models.UserKey{}
+	case kindSigningKey:
+		value = &models.SigningKeyRecord{}
+	case kindWalletNonce:
+		value = &models.WalletNonce{}
+	case kindArtwork:
+		value = &models.Artwork{}
+	case kindCrawlerResult:
+		value = &models.CrawlerResult{}
+	case kindDAGMetadata:
+		value = &DAGMetadata{}
+	case kindTx:
+		value = &eth.TxRecord{}
+	case kindManifestEvent:
+		value = &eth.ManifestEvent{}
+	case kindArtworkEvent:
+		value = &eth.ArtworkEvent{}
+	case kindCheckpoint:
+		value = &eth.IndexCheckpoint{}
+	case kindAnchor:
+		value = &eth.ArtworkAnchor{}
+	case kindManifestBatch:
+		value = &eth.ManifestBatch{}
+	case kindManifestAnchor:
+		value = &eth.ManifestAnchor{}
+	case kindBytes:
+		var b []byte
+		if err := json.Unmarshal(rec.Value, &b); err != nil {
+			return nil, fmt.Errorf("failed to decode bytes record: %w", err)
+		}
+		return b, nil
+	case kindJSON:
+		var v interface{}
+		if err := json.Unmarshal(rec.Value, &v); err != nil {
+			return nil, fmt.Errorf("failed to decode json record: %w", err)
+		}
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unknown record kind: %q", rec.Kind)
+	}
+
+	if err := json.Unmarshal(rec.Value, value); err != nil {
+		return nil, fmt.Errorf("failed to decode %s record: %w", rec.Kind, err)
+	}
+	return value, nil
+}
+
+// Open opens (or creates) a bbolt-backed IPFSDB at path. All records are
+// loaded into memory and the crawler/user indexes are rebuilt from them;
+// every subsequent Save is written through to disk.
+func Open(path string) (*IPFSDB, error) {
+	bdb, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open storage file %s: %w", path, err)
+	}
+
+	db := New()
+	db.bolt = bdb
+
+	err = bdb.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(recordsBucket)
+		if err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			value, err := decodeRecord(v)
+			if err != nil {
+				return fmt.Errorf("key %s: %w", k, err)
+			}
+			db.store[string(k)] = value
+			return nil
+		})
+	})
+	if err != nil {
+		bdb.Close()
+		return nil, fmt.Errorf("failed to load storage file %s: %w", path, err)
+	}
+
+	db.rebuildIndexes()
+	return db, nil
+}
+
+// Close releases the underlying storage file, if any
+func (db *IPFSDB) Close() error {
+	if db.bolt == nil {
+		return nil
+	}
+	return db.bolt.Close()
+}
+
+// persist writes a single value to the storage file
+func (db *IPFSDB) persist(key string, value interface{}) error {
+	if db.bolt == nil {
+		return nil
+	}
+
+	data, err := encodeRecord(value)
+	if err != nil {
+		return err
+	}
+
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(recordsBucket).Put([]byte(key), data)
+	})
+}
+
+// unpersist removes a single key from the storage file
+func (db *IPFSDB) unpersist(key string) error {
+	if db.bolt == nil {
+		return nil
+	}
+
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(recordsBucket).Delete([]byte(key))
+	})
+}
+
+// rebuildIndexes recomputes crawlerResults and userArtworks from the main store
+func (db *IPFSDB) rebuildIndexes() {
+	db.crawlerResults = make(map[string][]*models.CrawlerResult)
+	db.userArtworks = make(map[string][]string)
+
+	artworks := []*models.Artwork{}
+	for _, val := range db.store {
+		switch v := val.(type) {
+		case *models.CrawlerResult:
+			db.crawlerResults[v.OriginalArtworkID] = append(db.crawlerResults[v.OriginalArtworkID], v)
+		case *models.Artwork:
+			artworks = append(artworks, v)
+		}
+	}
+
+	for _, results := range db.crawlerResults {
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].DetectedAt.Before(results[j].DetectedAt)
+		})
+	}
+
+	sort.SliceStable(artworks, func(i, j int) bool {
+		return artworks[i].CreatedAt.Before(artworks[j].CreatedAt)
+	})
+	for _, artwork := range artworks {
+		db.userArtworks[artwork.ArtistID] = append(db.userArtworks[artwork.ArtistID], artwork.ID)
+	}
+}
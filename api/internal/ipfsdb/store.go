@@ -0,0 +1,121 @@
+package ipfsdb
+
+import (
+	"context"
+	"errors"
+
+	"yourproject/internal/models"
+)
+
+// ErrNotFound is returned by UpdateRecord when no record of the requested
+// type is stored under the key
+var ErrNotFound = errors.New("record not found")
+
+// Store is the storage contract used by handlers, auth and the crawler.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	Save(key string, value interface{}) error
+	Get(key string) (interface{}, bool)
+	Update(key string, fn func(value interface{}, ok bool) interface{}) error
+	Delete(key string) error
+	ListKeys() []string
+
+	FindUserByAuthenticatorID(authID string) (*models.User, bool)
+	FindUserByIdentity(issuer, subject string) (*models.User, bool)
+	FindUserByWalletAddress(address string) (*models.User, bool)
+
+	StoreArtwork(artwork *models.Artwork) error
+	GetAllArtworks(ctx context.Context) ([]*models.Artwork, error)
+	GetArtworkByID(ctx context.Context, id string) (*models.Artwork, error)
+
+	StoreCrawlerResult(ctx context.Context, result *models.CrawlerResult) error
+	GetCrawlerResultsByArtworkID(ctx context.Context, artworkID string) ([]*models.CrawlerResult, error)
+	GetCrawlerResultsByUserID(ctx context.Context, userID string) ([]*models.CrawlerResult, error)
+	UpdateCrawlerResultStatus(ctx context.Context, resultID string, status string) error
+}
+
+// UpdateRecord changes the *T stored under key: fn gets a copy of it and
+// reports whether it changed it, and the copy is then saved in its place.
+// Values returned by Get are shared with every other reader and must never
+// be modified in place, so records are only changed through here. It
+// returns the record as stored afterwards.
+func UpdateRecord[T any](s Store, key string, fn func(*T) bool) (*T, error) {
+	var result *T
+	err := s.Update(key, func(value interface{}, ok bool) interface{} {
+		stored, isT := value.(*T)
+		if !ok || !isT {
+			return nil
+		}
+		updated := *stored
+		if !fn(&updated) {
+			result = stored
+			return nil
+		}
+		result = &updated
+		return &updated
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, ErrNotFound
+	}
+	return result, nil
+}
+
+// reindexLocked moves a key from its old value to its new value in the
+// secondary indexes, so re-saving a key never duplicates or reorders
+// entries. Caller must hold db.mu.
+func (db *IPFSDB) reindexLocked(old, value interface{}) {
+	switch v := value.(type) {
+	case *models.Artwork:
+		if o, ok := old.(*models.Artwork); ok && o.ArtistID == v.ArtistID {
+			return
+		}
+	case *models.CrawlerResult:
+		if o, ok := old.(*models.CrawlerResult); ok && o.OriginalArtworkID == v.OriginalArtworkID {
+			for i, r := range db.crawlerResults[v.OriginalArtworkID] {
+				if r.ID == o.ID {
+					db.crawlerResults[v.OriginalArtworkID][i] = v
+					return
+				}
+			}
+		}
+	}
+
+	db.unindexLocked(old)
+	db.indexLocked(value)
+}
+
+// indexLocked adds value to the secondary indexes. Caller must hold db.mu.
+func (db *IPFSDB) indexLocked(value interface{}) {
+	switch v := value.(type) {
+	case *models.Artwork:
+		db.userArtworks[v.ArtistID] = append(db.userArtworks[v.ArtistID], v.ID)
+	case *models.CrawlerResult:
+		db.crawlerResults[v.OriginalArtworkID] = append(db.crawlerResults[v.OriginalArtworkID], v)
+	}
+}
+
+// unindexLocked removes a previously stored value from the secondary
+// indexes so re-saving a key never duplicates entries. Caller must hold db.mu.
+func (db *IPFSDB) unindexLocked(value interface{}) {
+	switch v := value.(type) {
+	case *models.Artwork:
+		ids := db.userArtworks[v.ArtistID]
+		for i, id := range ids {
+			if id == v.ID {
+				db.userArtworks[v.ArtistID] = append(ids[:i:i], ids[i+1:]...)
+				break
+			}
+		}
+	case *models.CrawlerResult:
+		results := db.crawlerResults[v.OriginalArtworkID]
+		for i, r := range results {
+			if r.ID == v.ID {
+				db.crawlerResults[v.OriginalArtworkID] = append(results[:i:i], results[i+1:]...)
+				break
+			}
+		}
+	}
+}
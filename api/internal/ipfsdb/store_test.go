@@ -0,0 +1,60 @@
+package ipfsdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"yourproject/internal/models"
+)
+
+func TestUpdateRecordReplacesStoredValue(t *testing.T) {
+	db := New()
+	ctx := context.Background()
+	original := &models.Artwork{ID: "artwork-1", ArtistID: "alice", Title: "Dawn"}
+	if err := db.StoreArtwork(original); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := UpdateRecord(db, "artwork-1", func(a *models.Artwork) bool {
+		a.ArtistID = "bob"
+		return true
+	})
+	if err != nil {
+		t.Fatalf("UpdateRecord: %v", err)
+	}
+	if original.ArtistID != "alice" {
+		t.Errorf("stored value changed in place: artist %s", original.ArtistID)
+	}
+	if stored, _ := db.GetArtworkByID(ctx, "artwork-1"); stored != updated || stored.ArtistID != "bob" {
+		t.Errorf("stored %+v, want the updated copy", stored)
+	}
+
+	// The ownership index follows the new artist
+	bob := &models.CrawlerResult{ID: "result-1", OriginalArtworkID: "artwork-1"}
+	if err := db.StoreCrawlerResult(ctx, bob); err != nil {
+		t.Fatal(err)
+	}
+	if results, _ := db.GetCrawlerResultsByUserID(ctx, "bob"); len(results) != 1 {
+		t.Errorf("bob owns %d results, want 1", len(results))
+	}
+	if results, _ := db.GetCrawlerResultsByUserID(ctx, "alice"); len(results) != 0 {
+		t.Errorf("alice still owns %d results", len(results))
+	}
+
+	// Returning false saves nothing and returns the stored record
+	unchanged, err := UpdateRecord(db, "artwork-1", func(a *models.Artwork) bool {
+		a.Title = "Dusk"
+		return false
+	})
+	if err != nil || unchanged != updated || updated.Title != "Dawn" {
+		t.Errorf("unchanged update returned %+v, %v", unchanged, err)
+	}
+
+	if _, err := UpdateRecord(db, "missing", func(*models.Artwork) bool { return true }); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing key: %v, want ErrNotFound", err)
+	}
+	if _, err := UpdateRecord(db, "artwork-1", func(*models.User) bool { return true }); !errors.Is(err, ErrNotFound) {
+		t.Errorf("record of another type: %v, want ErrNotFound", err)
+	}
+}
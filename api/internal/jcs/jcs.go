@@ -0,0 +1,237 @@
+// Package jcs implements the JSON Canonicalization Scheme (RFC 8785).
+//
+// Everything the backend hashes or signs as JSON goes through Marshal, so
+// any implementation of RFC 8785 (the extension, third-party verifiers)
+// derives the same bytes: object members sorted by their UTF-16 code units,
+// no insignificant whitespace, minimal string escaping and numbers
+// serialized like ECMAScript's Number.prototype.toString. vectors.json holds
+// golden vectors (input, canonical form and its BLAKE3) to check against.
+package jcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrInvalid is returned for input that is not I-JSON (RFC 7493): invalid
+// UTF-8, duplicate member names or numbers outside the IEEE 754 double range
+var ErrInvalid = errors.New("jcs: input is not I-JSON")
+
+// Marshal returns the canonical JSON encoding of v. v is encoded with
+// encoding/json first, so struct tags and json.Marshaler apply.
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return Transform(buf.Bytes())
+}
+
+// Transform returns the canonical form of a JSON document
+func Transform(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%w: invalid UTF-8", ErrInvalid)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out bytes.Buffer
+	if err := writeValue(dec, &out); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("jcs: unexpected data after top-level value")
+	}
+	return out.Bytes(), nil
+}
+
+// writeValue reads the next value from dec and writes its canonical form
+func writeValue(dec *json.Decoder, out *bytes.Buffer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			return writeObject(dec, out)
+		}
+		return writeArray(dec, out)
+	case string:
+		writeString(out, t)
+	case json.Number:
+		f, err := strconv.ParseFloat(t.String(), 64)
+		if err != nil {
+			return fmt.Errorf("%w: number %s out of range", ErrInvalid, t)
+		}
+		s, err := FormatNumber(f)
+		if err != nil {
+			return err
+		}
+		out.WriteString(s)
+	case bool:
+		out.WriteString(strconv.FormatBool(t))
+	case nil:
+		out.WriteString("null")
+	}
+	return nil
+}
+
+// writeObject writes the members of an object, sorted by the UTF-16 code
+// units of their names, after its opening brace has been read
+func writeObject(dec *json.Decoder, out *bytes.Buffer) error {
+	type member struct {
+		name  string
+		key   []uint16
+		value []byte
+	}
+
+	members := []member{}
+	seen := map[string]bool{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name := tok.(string)
+		if seen[name] {
+			return fmt.Errorf("%w: duplicate member %q", ErrInvalid, name)
+		}
+		seen[name] = true
+
+		var value bytes.Buffer
+		if err := writeValue(dec, &value); err != nil {
+			return err
+		}
+		members = append(members, member{name: name, key: utf16.Encode([]rune(name)), value: value.Bytes()})
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i].key, members[j].key
+		for n := 0; n < len(a) && n < len(b); n++ {
+			if a[n] != b[n] {
+				return a[n] < b[n]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	out.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		writeString(out, m.name)
+		out.WriteByte(':')
+		out.Write(m.value)
+	}
+	out.WriteByte('}')
+	return nil
+}
+
+// writeArray writes the elements of an array, in order, after its opening
+// bracket has been read
+func writeArray(dec *json.Decoder, out *bytes.Buffer) error {
+	out.WriteByte('[')
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		if err := writeValue(dec, out); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	out.WriteByte(']')
+	return nil
+}
+
+// writeString writes s quoted, escaping only what RFC 8785 requires: the
+// quote, the backslash and control characters, using the short forms where
+// JSON has them
+func writeString(out *bytes.Buffer, s string) {
+	out.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			out.WriteString(`\"`)
+		case '\\':
+			out.WriteString(`\\`)
+		case '\b':
+			out.WriteString(`\b`)
+		case '\f':
+			out.WriteString(`\f`)
+		case '\n':
+			out.WriteString(`\n`)
+		case '\r':
+			out.WriteString(`\r`)
+		case '\t':
+			out.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(out, `\u%04x`, r)
+			} else {
+				out.WriteRune(r)
+			}
+		}
+	}
+	out.WriteByte('"')
+}
+
+// FormatNumber serializes f the way ECMAScript's Number.prototype.toString
+// does (RFC 8785 section 3.2.2.3). NaN and infinities have no JSON form.
+func FormatNumber(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("%w: %v has no JSON representation", ErrInvalid, f)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+
+	// Shortest round-tripping digits and decimal exponent: f = 0.digits * 10^n
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exp)
+	n, k := e+1, len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k), nil
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:], nil
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits, nil
+	}
+
+	s := digits[:1]
+	if k > 1 {
+		s += "." + digits[1:]
+	}
+	if n-1 >= 0 {
+		return sign + s + "e+" + strconv.Itoa(n-1), nil
+	}
+	return sign + s + "e" + strconv.Itoa(n-1), nil
+}
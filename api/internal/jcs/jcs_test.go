@@ -0,0 +1,67 @@
+package jcs
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/zeebo/blake3"
+)
+
+// vector is one entry of vectors.json
+type vector struct {
+	Name      string `json:"name"`
+	Input     string `json:"input"`
+	Canonical string `json:"canonical"`
+	BLAKE3    string `json:"blake3"`
+}
+
+func TestVectors(t *testing.T) {
+	data, err := os.ReadFile("vectors.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("invalid vectors.json: %v", err)
+	}
+	if len(vectors) == 0 {
+		t.Fatal("vectors.json has no vectors")
+	}
+
+	for _, v := range vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			got, err := Transform([]byte(v.Input))
+			if err != nil {
+				t.Fatalf("Transform: %v", err)
+			}
+			if string(got) != v.Canonical {
+				t.Errorf("canonical form\n got %s\nwant %s", got, v.Canonical)
+			}
+			sum := blake3.Sum256(got)
+			if digest := hex.EncodeToString(sum[:]); digest != v.BLAKE3 {
+				t.Errorf("BLAKE3 %s, want %s", digest, v.BLAKE3)
+			}
+
+			// The canonical form is its own canonical form
+			again, err := Transform(got)
+			if err != nil || string(again) != string(got) {
+				t.Errorf("canonical form is not stable: %s (%v)", again, err)
+			}
+		})
+	}
+}
+
+func TestTransformRejectsNonIJSON(t *testing.T) {
+	for name, input := range map[string]string{
+		"duplicate member": `{"a":1,"a":2}`,
+		"invalid UTF-8":    "{\"a\":\"\xff\"}",
+		"number overflow":  `[1e400]`,
+	} {
+		if _, err := Transform([]byte(input)); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: got %v, want ErrInvalid", name, err)
+		}
+	}
+}
@@ -2,16 +2,63 @@ package models
 
 import (
 	"time"
+
+	"yourproject/internal/jcs"
 )
 
 // User represents an artist or admirer in the system
 type User struct {
-	ID              string    `json:"id" bson:"_id"`
-	WalletAddress   string    `json:"wallet_address" bson:"wallet_address"`
-	PublicKey       string    `json:"public_key" bson:"public_key"`
-	UserType        string    `json:"user_type" bson:"user_type"` // "artist" or "admirer"
-	CreatedAt       time.Time `json:"created_at" bson:"created_at"`
-	AuthenticatorID string    `json:"authenticator_id" bson:"authenticator_id"`
+	ID              string     `json:"id" bson:"_id"`
+	WalletAddress   string     `json:"wallet_address" bson:"wallet_address"`
+	PublicKey       string     `json:"public_key" bson:"public_key"`
+	UserType        string     `json:"user_type" bson:"user_type"` // role: "artist", "admirer" or "admin"
+	CreatedAt       time.Time  `json:"created_at" bson:"created_at"`
+	AuthenticatorID string     `json:"authenticator_id,omitempty" bson:"authenticator_id"` // legacy bare Microsoft user ID, before identities were keyed by issuer
+	Issuer          string     `json:"issuer" bson:"issuer"`                               // OIDC issuer of the user's identity
+	Subject         string     `json:"subject" bson:"subject"`                             // user's ID at Issuer; (Issuer, Subject) is unique
+	DisplayName     string     `json:"display_name,omitempty" bson:"display_name"`
+	WalletLinkedAt  *time.Time `json:"wallet_linked_at,omitempty" bson:"wallet_linked_at"` // when WalletAddress was proven with a signed EIP-4361 message
+}
+
+// WalletNonce is a single-use nonce issued for a Sign-In with Ethereum
+// (EIP-4361) message linking a wallet to a user
+type WalletNonce struct {
+	Nonce     string    `json:"nonce" bson:"_id"`
+	UserID    string    `json:"user_id" bson:"user_id"`
+	IssuedAt  time.Time `json:"issued_at" bson:"issued_at"`
+	ExpiresAt time.Time `json:"expires_at" bson:"expires_at"`
+}
+
+// UserKey is a user's Ed25519 signing key as held in the server-side
+// keystore. The private key seed is sealed with the keystore key.
+type UserKey struct {
+	UserID        string    `json:"user_id" bson:"_id"`
+	KeyID         string    `json:"key_id" bson:"key_id"`                 // base64url public key, the JWS kid
+	PublicKey     string    `json:"public_key" bson:"public_key"`         // base64, same as User.PublicKey
+	SealedPrivate string    `json:"sealed_private" bson:"sealed_private"` // base64 nonce || AES-GCM ciphertext
+	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
+}
+
+// Signing key statuses
+const (
+	KeyStatusActive  = "active"  // the user's current signing key
+	KeyStatusRotated = "rotated" // replaced; signatures made with it stay valid
+	KeyStatusRevoked = "revoked" // compromised or withdrawn; signatures no longer count
+)
+
+// SigningKeyRecord is the public, permanent record of a signing key a user
+// has held. Records are kept after rotation so old signatures stay
+// verifiable, and revocation is recorded rather than deleting the key.
+type SigningKeyRecord struct {
+	KeyID            string     `json:"key_id" bson:"_id"`
+	UserID           string     `json:"user_id" bson:"user_id"`
+	PublicKey        string     `json:"public_key" bson:"public_key"` // base64
+	Status           string     `json:"status" bson:"status"`
+	CreatedAt        time.Time  `json:"created_at" bson:"created_at"`
+	RotatedAt        *time.Time `json:"rotated_at,omitempty" bson:"rotated_at"`
+	ReplacedBy       string     `json:"replaced_by,omitempty" bson:"replaced_by"` // key ID of the next key
+	RevokedAt        *time.Time `json:"revoked_at,omitempty" bson:"revoked_at"`
+	RevocationReason string     `json:"revocation_reason,omitempty" bson:"revocation_reason"`
 }
 
 // Artwork represents a generated or imported AI artwork
@@ -24,11 +71,15 @@ type Artwork struct {
 	ContentType       string            `json:"content_type" bson:"content_type"` // "image", "video", "audio", "text"
 	OriginalFileHash  string            `json:"original_file_hash" bson:"original_file_hash"`
 	WatermarkedHash   string            `json:"watermarked_hash" bson:"watermarked_hash"`
+	PerceptualHash    string            `json:"perceptual_hash,omitempty" bson:"perceptual_hash"` // pHash of the watermarked image
 	IPFSHash          string            `json:"ipfs_hash" bson:"ipfs_hash"`
 	PublicKeyEmbedded string            `json:"public_key_embedded" bson:"public_key_embedded"`
-	NoisePattern      string            `json:"noise_pattern" bson:"noise_pattern"` // Unique pixel arrangement signature
+	NoisePattern      string            `json:"noise_pattern" bson:"noise_pattern"`     // Unique pixel arrangement signature
+	NoiseSeed         string            `json:"noise_seed,omitempty" bson:"noise_seed"` // per-artwork pattern seed, sealed with the watermark key
 	GPGSignature      string            `json:"gpg_signature" bson:"gpg_signature"`
 	BlockchainTxHash  string            `json:"blockchain_tx_hash" bson:"blockchain_tx_hash"`
+	BlockchainChain   string            `json:"blockchain_chain,omitempty" bson:"blockchain_chain"`   // chain registry name
+	BlockchainStatus  string            `json:"blockchain_status,omitempty" bson:"blockchain_status"` // queued, submitted, mined, confirmed or failed
 	DAGNodeID         string            `json:"dag_node_id" bson:"dag_node_id"`
 	Metadata          map[string]string `json:"metadata" bson:"metadata"`
 	CreatedAt         time.Time         `json:"created_at" bson:"created_at"`
@@ -46,7 +97,11 @@ type ProofCertificate struct {
 	ContentHash       string    `json:"content_hash" bson:"content_hash"`
 	IPFSHash          string    `json:"ipfs_hash" bson:"ipfs_hash"`
 	BlockchainTxHash  string    `json:"blockchain_tx_hash" bson:"blockchain_tx_hash"`
-	GPGSignature      string    `json:"gpg_signature" bson:"gpg_signature"`
+	BlockchainChain   string    `json:"blockchain_chain,omitempty" bson:"blockchain_chain"`
+	BlockchainStatus  string    `json:"blockchain_status,omitempty" bson:"blockchain_status"`
+	BlockchainTxURL   string    `json:"blockchain_tx_url,omitempty" bson:"blockchain_tx_url"`
+	PublicKey         string    `json:"public_key,omitempty" bson:"public_key"` // artist's Ed25519 key
+	GPGSignature      string    `json:"gpg_signature" bson:"gpg_signature"`     // artist's detached Ed25519 signature over SigningBytes
 	NoiseSignature    string    `json:"noise_signature" bson:"noise_signature"`
 	Timestamp         time.Time `json:"timestamp" bson:"timestamp"`
 	IssuedAt          time.Time `json:"issued_at" bson:"issued_at"`
@@ -54,6 +109,14 @@ type ProofCertificate struct {
 	SmartContractAddr string    `json:"smart_contract_addr" bson:"smart_contract_addr"`
 }
 
+// SigningBytes returns the bytes the artist signs: the certificate as
+// RFC 8785 canonical JSON without its signature
+func (p *ProofCertificate) SigningBytes() ([]byte, error) {
+	unsigned := *p
+	unsigned.GPGSignature = ""
+	return jcs.Marshal(&unsigned)
+}
+
 // VerificationRequest represents a request to verify artwork authenticity
 type VerificationRequest struct {
 	FileHash      string `json:"file_hash"`
@@ -72,7 +135,10 @@ type VerificationResult struct {
 	TamperDetected    bool      `json:"tamper_detected"`
 	SimilarityScore   float64   `json:"similarity_score"`
 	BlockchainTxHash  string    `json:"blockchain_tx_hash"`
+	BlockchainChain   string    `json:"blockchain_chain,omitempty"`
+	BlockchainTxURL   string    `json:"blockchain_tx_url,omitempty"`
 	CertificateURL    string    `json:"certificate_url"`
+	MatchedBy         string    `json:"matched_by,omitempty"` // "hash", "watermark" or "phash" for uploaded files
 	VerificationSteps []string  `json:"verification_steps"`
 }
 
@@ -83,6 +149,7 @@ type GenerationRequest struct {
 	ContentType string            `json:"content_type"` // "image", "text", "audio"
 	LLMProvider string            `json:"llm_provider"`
 	Parameters  map[string]string `json:"parameters"`
+	Chain       string            `json:"chain,omitempty"` // chain to register on, default chain when empty
 }
 
 // ImportRequest represents artwork imported from chrome extension
@@ -94,6 +161,7 @@ type ImportRequest struct {
 	Prompt         string            `json:"prompt"`
 	SourcePlatform string            `json:"source_platform"`
 	Metadata       map[string]string `json:"metadata"`
+	Chain          string            `json:"chain,omitempty"` // chain to register on, default chain when empty
 }
 
 // CrawlerResult represents findings from the similarity crawler
@@ -105,7 +173,8 @@ type CrawlerResult struct {
 	PHashDistance     int       `json:"phash_distance" bson:"phash_distance"`
 	TamperDetected    bool      `json:"tamper_detected" bson:"tamper_detected"`
 	DetectedAt        time.Time `json:"detected_at" bson:"detected_at"`
-	Status            string    `json:"status" bson:"status"` // "pending", "verified", "infringement"
+	Status            string    `json:"status" bson:"status"`                     // "pending", "verified", "infringement"
+	ReportedBy        string    `json:"reported_by,omitempty" bson:"reported_by"` // user who reported it; empty for crawler findings
 }
 
 // DAGNode represents a node in the IPFS DAG structure
@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"os"
 	"time"
+
+	"yourproject/internal/jcs"
 )
 
 // Client represents a Pinata API client
@@ -337,35 +339,9 @@ func (c *Client) PinJSONManifest(manifest map[string]interface{}) (string, error
 
 	url := "https://api.pinata.cloud/pinning/pinJSONToIPFS"
 
-	// Get a meaningful name from manifest if available
-	manifestName := fmt.Sprintf("manifest-%d", time.Now().Unix())
-	if imageCID, ok := manifest["image_cid"].(string); ok && imageCID != "" {
-		manifestName = fmt.Sprintf("manifest-%s", imageCID[:8])
-	}
-
-	// Prepare request body with pinataMetadata
-	requestBody := map[string]interface{}{
-		"pinataContent": manifest,
-		"pinataMetadata": map[string]interface{}{
-			"name": manifestName,
-			"keyvalues": map[string]string{
-				"type":      "artwork_manifest",
-				"timestamp": fmt.Sprintf("%d", time.Now().Unix()),
-			},
-		},
-		"pinataOptions": map[string]interface{}{
-			"cidVersion": 1,
-		},
-	}
-
-	// Add creator to keyvalues if available
-	if creator, ok := manifest["creator"].(string); ok && creator != "" {
-		requestBody["pinataMetadata"].(map[string]interface{})["keyvalues"].(map[string]string)["creator"] = creator
-	}
-
-	jsonData, err := json.Marshal(requestBody)
+	jsonData, err := manifestRequestBody(manifest)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return "", err
 	}
 
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
@@ -406,6 +382,53 @@ func (c *Client) PinJSONManifest(manifest map[string]interface{}) (string, error
 	return ipfsHash, nil
 }
 
+// manifestRequestBody builds the pinJSONToIPFS request for a manifest, with
+// the manifest as RFC 8785 canonical JSON
+func manifestRequestBody(manifest map[string]interface{}) ([]byte, error) {
+	// Get a meaningful name from manifest if available
+	manifestName := fmt.Sprintf("manifest-%d", time.Now().Unix())
+	if imageCID, ok := manifest["image_cid"].(string); ok && imageCID != "" {
+		manifestName = fmt.Sprintf("manifest-%s", imageCID[:8])
+	}
+
+	// The content is sent as RFC 8785 canonical JSON
+	content, err := jcs.Marshal(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize manifest: %w", err)
+	}
+
+	// Prepare request body with pinataMetadata
+	requestBody := map[string]interface{}{
+		"pinataContent": json.RawMessage(content),
+		"pinataMetadata": map[string]interface{}{
+			"name": manifestName,
+			"keyvalues": map[string]string{
+				"type":      "artwork_manifest",
+				"timestamp": fmt.Sprintf("%d", time.Now().Unix()),
+			},
+		},
+		"pinataOptions": map[string]interface{}{
+			"cidVersion": 1,
+		},
+	}
+
+	// Add creator to keyvalues if available
+	if creator, ok := manifest["creator"].(string); ok && creator != "" {
+		requestBody["pinataMetadata"].(map[string]interface{})["keyvalues"].(map[string]string)["creator"] = creator
+	}
+
+	// pinataContent must stay byte for byte the canonical JSON the
+	// manifest hash is computed over, so <, > and & are not escaped
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(requestBody); err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return buf.Bytes(), nil
+
+}
+
 // UnpinFile removes a file from Pinata
 func (c *Client) UnpinFile(ipfsHash string) error {
 	if !c.enabled {
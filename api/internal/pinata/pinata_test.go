@@ -0,0 +1,39 @@
+package pinata
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"yourproject/internal/jcs"
+)
+
+func TestManifestRequestBodyKeepsCanonicalContent(t *testing.T) {
+	manifest := map[string]interface{}{
+		"image_cid": "bafkreihdwdcefgh4dqkjv67uzcmw7ojee6xedzdetojuzjevtenxquvyku",
+		"creator":   "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf",
+		"title":     "Rock <&> Roll",
+		"prompt":    "a <b>bold</b> \"penguin\" & friends",
+	}
+	canonical, err := jcs.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := manifestRequestBody(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var request struct {
+		PinataContent json.RawMessage `json:"pinataContent"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		t.Fatalf("invalid request body: %v", err)
+	}
+	if !bytes.Equal(request.PinataContent, canonical) {
+		t.Errorf("pinataContent is\n%s\nwant the canonical manifest\n%s", request.PinataContent, canonical)
+	}
+	if !bytes.Contains(body, []byte("Rock <&> Roll")) {
+		t.Errorf("request body escapes HTML characters: %s", body)
+	}
+}
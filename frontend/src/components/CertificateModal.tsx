@@ -10,6 +10,8 @@ interface CertificateData {
   content_hash?: string
   ipfs_hash?: string
   blockchain_tx_hash?: string
+  blockchain_chain?: string
+  blockchain_tx_url?: string
   noise_signature?: string
   timestamp?: string
   issued_at?: string
@@ -195,7 +197,7 @@ export default function CertificateModal({ certificate, isOpen, onClose }: Certi
                             )}
                           </button>
                           <a
-                            href={`https://etherscan.io/tx/${certificate.blockchain_tx_hash}`}
+                            href={certificate.blockchain_tx_url || `https://etherscan.io/tx/${certificate.blockchain_tx_hash}`}
                             target="_blank"
                             rel="noopener noreferrer"
                             className="text-xs text-green-400 hover:text-green-300"
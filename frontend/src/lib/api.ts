@@ -18,8 +18,6 @@ api.interceptors.request.use(
       url.includes('/verify') || 
       url.includes('/certificate') ||
       url.includes('/health') ||
-      url.includes('/upload') ||
-      url.includes('/manifests') ||
       fullUrl.includes('/verify') ||
       fullUrl.includes('/certificate') ||
       fullUrl.includes('/health')